@@ -0,0 +1,7 @@
+// Package api menyimpan kontrak HTTP (OpenAPI) service orders.
+package api
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
@@ -2,14 +2,28 @@ package main
 
 import (
 	"context"
+	"github.com/ariefcatur/go-realtime-orders.git/api"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/catalog"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/grpcx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/health"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/httpx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/inventory"
 	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/migrate"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/ordersvc"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/settings"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
 	"github.com/joho/godotenv"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -20,44 +34,193 @@ import (
 func main() {
 	_ = godotenv.Load()
 
-	cfg := config.Load()
+	cfg := config.MustLoad("api")
+	orders.SetTopics(orders.Topics(cfg.Kafka.Topics))
+	redisx.ApplyTTLs(cfg.Redis)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Logging (slog, level/format dari LOG_LEVEL / LOG_FORMAT)
+	logx.Setup(logx.Config{
+		Service: cfg.ServiceName, Level: cfg.Log.Level, Format: cfg.Log.Format,
+		SampleInitial: cfg.Log.SampleInitial, SampleThereafter: cfg.Log.SampleThereafter,
+	})
+
+	// Setting runtime: di-reload dari file config tanpa restart (lihat internal/settings)
+	rs := settings.NewStore(settings.FromConfig(cfg), cfg.File)
+	rs.Subscribe(func(s settings.Settings) {
+		logx.SetLevel(s.LogLevel)
+		redisx.TTLIdempotency.Set(s.IdempotencyTTL)
+		redisx.TTLStatusCache.Set(s.StatusCacheTTL)
+		redisx.TTLAvailability.Set(s.AvailabilityCacheTTL)
+	})
+	if cfg.File != "" && cfg.ReloadInterval > 0 {
+		go rs.Watch(ctx, cfg.File, cfg.ReloadInterval, settings.Loader("api", os.Args[1:]))
+	}
+
+	// Tracing (exporter dari TRACING_EXPORTER)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: cfg.ServiceName, Exporter: cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint, SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logx.Fatal("tracing setup failed", "err", err)
+	}
+
 	// DB
-	db, err := postgres.Connect(ctx, cfg.PostgresDSN)
+	db, err := postgres.Connect(ctx, cfg.Postgres)
 	if err != nil {
-		log.Fatalf("db connect: %v", err)
+		logx.Fatal("db connect failed", "err", err)
 	}
 	defer db.Close()
+	if cfg.Postgres.AutoMigrate {
+		if err := migrate.Auto(ctx, db); err != nil {
+			logx.Fatal("migrate failed", "err", err)
+		}
+	}
+	metrics.RegisterPgxPool(cfg.ServiceName, db)
 
 	// Redis
-	rdb := redisx.New(cfg.RedisAddr)
+	rdb := redisx.New(cfg.Redis)
 	defer rdb.Close()
+	rdb.AddHook(metrics.RedisHook{})
+	rdb.AddHook(tracing.RedisHook{})
 
 	// Kafka producer
-	prod := kafkax.NewProducer(cfg.KafkaBrokers, orders.TopicOrderCreated, 1024)
+	prod := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicOrderCreated, cfg.Kafka.ProducerBuffer)
 	prod.Start(ctx)
 
-	// Repo & handler
-	repo := &orders.Repo{DB: db}
-	router := httpx.NewRouter()
-	oh := &httpx.OrdersHandler{
-		Repo:     repo,
-		Producer: prod,
-		Redis:    rdb,
-		Service:  cfg.ServiceName,
+	// Readiness: dependency + producer inbox + consumer lag (check consumer ditambah di bawah)
+	hr := &health.Registry{Timeout: cfg.Health.CheckTimeout, CacheTTL: cfg.Health.CacheTTL}
+	hr.Register("postgres", 0, health.Postgres(db))
+	hr.Register("redis", 0, health.Redis(rdb))
+	hr.Register("kafka", 0, health.Kafka(cfg.Kafka.Brokers))
+	hr.Register("producer:"+orders.TopicOrderCreated, 0, health.ProducerInbox(prod, cfg.Health.MaxInboxRatio))
+
+	// Feature flag (definisi di Postgres, cache Redis, invalidasi pub/sub)
+	fc := &flags.Client{Repo: &orders.FlagRepo{DB: db}, Redis: rdb}
+	go fc.Run(ctx)
+
+	// Service (dipakai bersama HTTP & gRPC)
+	svc := &ordersvc.Service{
+		Repo:      &orders.Repo{DB: db, Tax: &orders.TaxRuleRepo{DB: db}},
+		Cache:     ordersvc.RedisCache{Client: rdb},
+		Publisher: prod,
+		Name:      cfg.ServiceName,
+		Limits: validation.Limits{
+			MaxItemsPerOrder: cfg.API.MaxItemsPerOrder,
+			MaxQtyPerLine:    cfg.API.MaxQtyPerLine,
+			MaxBodyBytes:     cfg.API.MaxBodyBytes,
+		},
+		QuoteTTL: cfg.API.QuoteTTL,
+		Flags:    fc,
+	}
+
+	// HTTP handler
+	oh := &httpx.OrdersHandler{Orders: svc, MaxBodyBytes: cfg.API.MaxBodyBytes}
+
+	// Catalog admin (event ke topic catalog.products)
+	catProd := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicCatalog, cfg.Kafka.AdminProducerBuffer)
+	catProd.Start(ctx)
+	hr.Register("producer:"+orders.TopicCatalog, 0, health.ProducerInbox(catProd, cfg.Health.MaxInboxRatio))
+	ch := &httpx.CatalogHandler{
+		Catalog:      &catalog.Service{Repo: &orders.CatalogRepo{DB: db}, Publisher: catProd, Name: cfg.ServiceName},
+		MaxBodyBytes: cfg.API.MaxBodyBytes,
+	}
+
+	// Stock admin (restock / adjustment, event ke inventory.stock.adjusted; alert ke inventory.stock.alerts)
+	adjProd := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockAdjusted, cfg.Kafka.AdminProducerBuffer)
+	adjProd.Start(ctx)
+	alertProd := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockAlerts, cfg.Kafka.AdminProducerBuffer)
+	alertProd.Start(ctx)
+	hr.Register("producer:"+orders.TopicStockAdjusted, 0, health.ProducerInbox(adjProd, cfg.Health.MaxInboxRatio))
+	hr.Register("producer:"+orders.TopicStockAlerts, 0, health.ProducerInbox(alertProd, cfg.Health.MaxInboxRatio))
+	avail := &inventory.Availability{Repo: &orders.InventoryRepo{DB: db}, Redis: rdb} // TTL live dari redisx.TTLAvailability
+	ih := &httpx.InventoryHandler{
+		Stock: &inventory.StockAdmin{
+			Repo:        &orders.InventoryRepo{DB: db},
+			Producer:    adjProd,
+			ServiceName: cfg.ServiceName,
+			Alerts: &inventory.StockWatcher{
+				Repo: &orders.AlertRepo{DB: db}, Producer: alertProd, ServiceName: cfg.ServiceName, Cooldown: cfg.Inventory.StockAlertCooldown,
+			},
+		},
+		Availability: avail,
+		MaxBodyBytes: cfg.API.MaxBodyBytes,
+	}
+
+	// Invalidasi cache availability dari event stok & catalog (satu consumer group per topic)
+	for _, topic := range []string{orders.TopicStockReserved, orders.TopicStockReleased, orders.TopicStockAdjusted, orders.TopicCatalog} {
+		cons := kafkax.NewConsumer(cfg.Kafka.Brokers, cfg.ServiceName+"-availability-"+topic, topic, 1)
+		hr.Register("consumer:availability-"+topic, 0, health.ConsumerLag(cons, cfg.Health.MaxConsumerLag))
+		go func(topic string) {
+			if err := cons.Start(ctx, avail.HandleEvent); err != nil {
+				slog.Error("availability consumer exit", "topic", topic, "err", err)
+			}
+		}(topic)
+	}
+
+	// Hitung order final (metrics) dari event orchestrator
+	finCons := kafkax.NewConsumer(cfg.Kafka.Brokers, cfg.ServiceName+"-finalized", orders.TopicOrderFinalized, 1)
+	hr.Register("consumer:finalized", 0, health.ConsumerLag(finCons, cfg.Health.MaxConsumerLag))
+	go func() {
+		if err := finCons.Start(ctx, svc.HandleOrderFinalized); err != nil {
+			slog.Error("finalized consumer exit", "err", err)
+		}
+	}()
+
+	// Hold reservasi (extend selama pembayaran berjalan)
+	hh := &httpx.HoldsHandler{
+		Holds:        &inventory.Holds{Repo: &orders.ReservationRepo{DB: db}, MaxHold: cfg.Inventory.HoldMax},
+		MaxBodyBytes: cfg.API.MaxBodyBytes,
+	}
+
+	// Promosi & kupon (dipakai pricing saat order dibuat)
+	ph := &httpx.PromotionsHandler{Promotions: &orders.PromotionRepo{DB: db}, MaxBodyBytes: cfg.API.MaxBodyBytes}
+
+	// /admin/* hanya dengan bearer token dari config; principal-nya dicatat sebagai actor
+	admin, err := httpx.NewAdminAuth(cfg.API.AdminTokens)
+	if err != nil {
+		logx.Fatal("admin tokens invalid", "err", err)
+	}
+	if len(cfg.API.AdminTokens) == 0 {
+		slog.Warn("api.admin_tokens empty, all /admin/* requests will be rejected")
+	}
+
+	router := httpx.API{
+		Orders: oh, Catalog: ch, Inventory: ih, Holds: hh, Promotions: ph,
+		Admin:    admin,
+		Health:   &httpx.HealthHandler{Health: hr},
+		Settings: &httpx.SettingsHandler{Settings: rs},
+		Flags:    &httpx.FlagsHandler{Flags: fc, MaxBodyBytes: cfg.API.MaxBodyBytes}, // feature flag admin
+	}.Router()
+
+	// route yang terdaftar harus sama dengan api/openapi.json (field request/response dijaga test httpx)
+	if err := httpx.CheckRoutes(router, api.OpenAPI); err != nil {
+		logx.Fatal("openapi route check failed", "err", err)
 	}
-	oh.Register(router)
 
 	// HTTP server
-	srv := &http.Server{Addr: cfg.HTTPAddr, Handler: router}
+	srv := &http.Server{Addr: cfg.API.HTTPAddr, Handler: router}
 
 	// graceful shutdown
 	go func() {
-		log.Printf("HTTP listening at %s", cfg.HTTPAddr)
+		slog.Info("HTTP listening", "addr", cfg.API.HTTPAddr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("listen: %v", err)
+			logx.Fatal("http listen failed", "err", err)
+		}
+	}()
+
+	// gRPC server
+	gsrv := grpcx.NewServer(&grpcx.OrderServer{Orders: svc, WatchInterval: time.Second})
+	lis, err := net.Listen("tcp", cfg.API.GRPCAddr)
+	if err != nil {
+		logx.Fatal("grpc listen failed", "err", err)
+	}
+	go func() {
+		slog.Info("gRPC listening", "addr", cfg.API.GRPCAddr)
+		if err := gsrv.Serve(lis); err != nil {
+			logx.Fatal("grpc serve failed", "err", err)
 		}
 	}()
 
@@ -65,12 +228,22 @@ func main() {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
-	log.Println("shutting down...")
+	slog.Info("shutting down", "drain_delay", cfg.Health.ShutdownDrainDelay.String())
+	hr.SetDraining() // /readyz -> 503, beri waktu load balancer berhenti mengirim traffic
+	time.Sleep(cfg.Health.ShutdownDrainDelay)
 
 	ctx2, cancel2 := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel2()
 	_ = srv.Shutdown(ctx2)
-	prod.Close()      // tutup inbox -> flush & close writer
+	gsrv.GracefulStop()
+	prod.Close() // tutup inbox -> flush & close writer
+	catProd.Close()
+	adjProd.Close()
+	alertProd.Close()
 	cancel()          // stop producer loop
 	prod.WaitClosed() // drain
+	catProd.WaitClosed()
+	adjProd.WaitClosed()
+	alertProd.WaitClosed()
+	_ = shutdownTracing(ctx2) // flush span tersisa
 }
@@ -3,92 +3,238 @@ package main
 import (
 	"context"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/health"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/inventory"
 	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/migrate"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/settings"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
 	"github.com/joho/godotenv"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 )
 
-func mustAtoi(s, def string) int {
-	if s == "" {
-		s = def
-	}
-	i, err := strconv.Atoi(s)
-	if err != nil {
-		return 1
-	}
-	return i
-}
-
 func main() {
 	_ = godotenv.Load()
-	cfg := config.Load()
+	cfg := config.MustLoad("inventory")
+	orders.SetTopics(orders.Topics(cfg.Kafka.Topics))
+	redisx.ApplyTTLs(cfg.Redis)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Logging (slog, level/format dari LOG_LEVEL / LOG_FORMAT)
+	logx.Setup(logx.Config{
+		Service: cfg.ServiceName + "-inventory", Level: cfg.Log.Level, Format: cfg.Log.Format,
+		SampleInitial: cfg.Log.SampleInitial, SampleThereafter: cfg.Log.SampleThereafter,
+	})
+
+	// Setting runtime: di-reload dari file config tanpa restart (lihat internal/settings)
+	rs := settings.NewStore(settings.FromConfig(cfg), cfg.File)
+	rs.Subscribe(func(s settings.Settings) {
+		logx.SetLevel(s.LogLevel)
+		redisx.TTLDedup.Set(s.DedupTTL)
+	})
+	if cfg.File != "" && cfg.ReloadInterval > 0 {
+		go rs.Watch(ctx, cfg.File, cfg.ReloadInterval, settings.Loader("inventory", os.Args[1:]))
+	}
+
+	// Tracing (exporter dari TRACING_EXPORTER)
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: cfg.ServiceName + "-inventory", Exporter: cfg.Tracing.Exporter,
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint, SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logx.Fatal("tracing setup failed", "err", err)
+	}
+
 	// DB
-	db, err := postgres.Connect(ctx, cfg.PostgresDSN)
+	db, err := postgres.Connect(ctx, cfg.Postgres)
 	if err != nil {
-		log.Fatalf("db: %v", err)
+		logx.Fatal("db connect failed", "err", err)
 	}
 	defer db.Close()
+	if cfg.Postgres.AutoMigrate {
+		if err := migrate.Auto(ctx, db); err != nil {
+			logx.Fatal("migrate failed", "err", err)
+		}
+	}
+	metrics.RegisterPgxPool(cfg.ServiceName+"-inventory", db)
 
 	// Redis
-	rdb := redisx.New(cfg.RedisAddr)
+	rdb := redisx.New(cfg.Redis)
 	defer rdb.Close()
+	rdb.AddHook(metrics.RedisHook{})
+	rdb.AddHook(tracing.RedisHook{})
+
+	// /metrics, /livez, /readyz (proses ini tidak punya HTTP API); check producer & consumer ditambah di bawah
+	hr := &health.Registry{Timeout: cfg.Health.CheckTimeout, CacheTTL: cfg.Health.CacheTTL}
+	hr.Register("postgres", 0, health.Postgres(db))
+	hr.Register("redis", 0, health.Redis(rdb))
+	hr.Register("kafka", 0, health.Kafka(cfg.Kafka.Brokers))
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("GET /livez", hr.LiveHandler())
+	mux.Handle("GET /readyz", hr.ReadyHandler())
+	mux.Handle("GET /admin/settings", rs.Handler())
+	msrv := &http.Server{Addr: cfg.Inventory.MetricsAddr, Handler: mux}
+	go func() {
+		slog.Info("metrics listening", "addr", cfg.Inventory.MetricsAddr)
+		if err := msrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("metrics listen failed", "err", err)
+		}
+	}()
 
 	// Producers: reserved & rejected (dua topic berbeda)
-	pOK := kafkax.NewProducer(cfg.KafkaBrokers, orders.TopicStockReserved, 1024)
+	pOK := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockReserved, cfg.Kafka.ProducerBuffer)
 	pOK.Start(ctx)
-	pRJ := kafkax.NewProducer(cfg.KafkaBrokers, orders.TopicStockRejected, 1024)
+	pRJ := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockRejected, cfg.Kafka.ProducerBuffer)
 	pRJ.Start(ctx)
+	pRL := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockReleased, cfg.Kafka.ProducerBuffer)
+	pRL.Start(ctx)
+	pAL := kafkax.NewProducer(cfg.Kafka.Brokers, orders.TopicStockAlerts, cfg.Kafka.AdminProducerBuffer)
+	pAL.Start(ctx)
+	for topic, p := range map[string]*kafkax.Producer{
+		orders.TopicStockReserved: pOK, orders.TopicStockRejected: pRJ, orders.TopicStockReleased: pRL, orders.TopicStockAlerts: pAL,
+	} {
+		hr.Register("producer:"+topic, 0, health.ProducerInbox(p, cfg.Health.MaxInboxRatio))
+	}
+
+	strategy, err := orders.StrategyByName(cfg.Inventory.AllocationStrategy)
+	if err != nil {
+		logx.Fatal("invalid config", "err", err)
+	}
+
+	repo := &orders.ReservationRepo{DB: db, Strategy: strategy, HoldTTL: cfg.Inventory.HoldTTL}
+	alerts := &inventory.StockWatcher{
+		Repo:        &orders.AlertRepo{DB: db},
+		Producer:    pAL,
+		ServiceName: cfg.ServiceName + "-inventory",
+		Cooldown:    cfg.Inventory.StockAlertCooldown,
+	}
 
 	// Service
 	svc := &inventory.Service{
-		Repo:           &orders.ReservationRepo{DB: db},
+		Repo:           repo,
 		Redis:          rdb,
 		ProducerOK:     pOK,
 		ProducerReject: pRJ,
 		ServiceName:    cfg.ServiceName + "-inventory",
+		Alerts:         alerts,
+	}
+
+	// Rollout allocation strategy baru lewat feature flag inventory.allocation_rollout
+	if cfg.Inventory.RolloutAllocationStrategy != "" {
+		rollout, err := orders.StrategyByName(cfg.Inventory.RolloutAllocationStrategy)
+		if err != nil {
+			logx.Fatal("invalid config", "err", err)
+		}
+		svc.Flags = &flags.Client{Repo: &orders.FlagRepo{DB: db}, Redis: rdb}
+		svc.RolloutStrategy = rollout
+		go svc.Flags.Run(ctx)
+	}
+
+	// Produk hot (flash sale) lewat counter Redis dulu
+	if cfg.Inventory.HotStockEnabled {
+		svc.Hot = &inventory.HotStock{Redis: rdb, Repo: repo, Stock: &orders.InventoryRepo{DB: db}, Workers: cfg.Inventory.HotStockWorkers}
+		svc.Hot.OnWritten = svc.HotWritten
+		svc.Hot.Start(ctx)
+		go svc.Hot.RunReconciler(ctx, cfg.Inventory.HotStockSyncInterval)
+		// kill switch live: features.hot_stock=false -> reservasi langsung ke Postgres
+		svc.HotEnabled = rs.Enabled(settings.FeatureHotStock, true)
 	}
 
 	// Consumer
-	group := getenv("INVENTORY_GROUP", "inventory-svc")
-	workers := mustAtoi(os.Getenv("INVENTORY_WORKERS"), "8")
-	cons := kafkax.NewConsumer(cfg.KafkaBrokers, group, orders.TopicOrderCreated, workers)
+	group := cfg.Inventory.ConsumerGroup
+	workers := cfg.Inventory.Workers
+	cons := kafkax.NewConsumer(cfg.Kafka.Brokers, group, orders.TopicOrderCreated, workers)
+	hr.Register("consumer:"+orders.TopicOrderCreated, 0, health.ConsumerLag(cons, cfg.Health.MaxConsumerLag))
+	rs.Subscribe(func(s settings.Settings) {
+		if s.InventoryWorkers != cons.Workers() {
+			cons.SetWorkers(s.InventoryWorkers)
+		}
+	})
 
 	go func() {
-		log.Printf("inventory consumer started: group=%s topic=%s workers=%d", group, orders.TopicOrderCreated, workers)
+		slog.Info("inventory consumer started", "group", group, "topic", orders.TopicOrderCreated, "workers", workers, "allocation", strategy.Name())
 		if err := cons.Start(ctx, svc.HandleOrderCreated); err != nil {
-			log.Printf("consumer exit: %v", err)
+			slog.Error("inventory consumer exit", "err", err)
+			cancel()
+		}
+	}()
+
+	// Restock -> isi backorder (group terpisah dari consumer order.created)
+	boCons := kafkax.NewConsumer(cfg.Kafka.Brokers, group+"-backorder", orders.TopicStockAdjusted, 1)
+	hr.Register("consumer:"+orders.TopicStockAdjusted, 0, health.ConsumerLag(boCons, cfg.Health.MaxConsumerLag))
+	go func() {
+		slog.Info("backorder consumer started", "group", group+"-backorder", "topic", orders.TopicStockAdjusted)
+		if err := boCons.Start(ctx, svc.HandleStockAdjusted); err != nil {
+			slog.Error("backorder consumer exit", "err", err)
 			cancel()
 		}
 	}()
 
+	// Reservasi dilepas (hold expired / release manual) -> stok kembali, isi backorder juga
+	relCons := kafkax.NewConsumer(cfg.Kafka.Brokers, group+"-backorder-released", orders.TopicStockReleased, 1)
+	hr.Register("consumer:"+orders.TopicStockReleased, 0, health.ConsumerLag(relCons, cfg.Health.MaxConsumerLag))
+	go func() {
+		slog.Info("backorder consumer started", "group", group+"-backorder-released", "topic", orders.TopicStockReleased)
+		if err := relCons.Start(ctx, svc.HandleStockReleased); err != nil {
+			slog.Error("backorder consumer exit", "err", err)
+			cancel()
+		}
+	}()
+
+	// Reconcile ledger vs products.stock
+	go inventory.RunReconciler(ctx, &orders.InventoryRepo{DB: db}, cfg.Inventory.ReconcileInterval)
+
+	// Sweeper hold reservasi yang expired
+	holds := &inventory.Holds{
+		Repo:        repo,
+		Producer:    pRL,
+		ServiceName: cfg.ServiceName + "-inventory",
+		MaxHold:     cfg.Inventory.HoldMax,
+		BatchSize:   cfg.Inventory.HoldSweepBatch,
+		Alerts:      alerts,
+	}
+	go holds.RunExpirySweeper(ctx, cfg.Inventory.HoldSweepInterval)
+
 	// graceful shutdown
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	<-sig
-	log.Println("shutting down consumer...")
+	slog.Info("shutting down consumer", "drain_delay", cfg.Health.ShutdownDrainDelay.String())
+	hr.SetDraining() // /readyz -> 503 dulu, baru consumer & producer dihentikan
+	time.Sleep(cfg.Health.ShutdownDrainDelay)
+	_ = msrv.Close()
 	cancel()
 	time.Sleep(500 * time.Millisecond)
+	if svc.Hot != nil {
+		// reservasi hot yang sudah di-ack tapi belum tertulis ke Postgres
+		fctx, fcancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := svc.Hot.Flush(fctx); err != nil {
+			slog.Error("hotstock flush", "err", err)
+		}
+		fcancel()
+	}
 	pOK.Close()
 	pRJ.Close()
+	pRL.Close()
+	pAL.Close()
 	pOK.WaitClosed()
 	pRJ.WaitClosed()
-}
-
-func getenv(k, def string) string {
-	if v := os.Getenv(k); v != "" {
-		return v
-	}
-	return def
+	pRL.WaitClosed()
+	pAL.WaitClosed()
+	tctx, tcancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer tcancel()
+	_ = shutdownTracing(tctx) // flush span tersisa
 }
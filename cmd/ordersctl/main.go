@@ -0,0 +1,89 @@
+// ordersctl: CLI operator untuk database / order. Config sama dengan service (file, env, flag);
+// flag config ditulis sebelum subcommand.
+//
+//	go run ./cmd/ordersctl migrate up [version]
+//	go run ./cmd/ordersctl migrate down [steps]
+//	go run ./cmd/ordersctl migrate status [-o table|json]
+//	go run ./cmd/ordersctl migrate seed
+//	go run ./cmd/ordersctl order get <id|external_id> [-o table|json]
+//	go run ./cmd/ordersctl order status <id|external_id> <STATUS> -reason "..."
+//	go run ./cmd/ordersctl order release <id|external_id> -reason "..." [-force]
+//	go run ./cmd/ordersctl order reemit <id|external_id> [-dry-run]
+//	go run ./cmd/ordersctl order stuck [-status CREATED,STOCK_RESERVED] [-older-than 15m] [-limit 50]
+//	go run ./cmd/ordersctl config print
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/joho/godotenv"
+)
+
+const usage = `usage: ordersctl [config flags] <command>
+
+commands:
+  migrate up [version]     jalankan migration schema yang belum jalan (sampai version)
+  migrate down [steps]     rollback migration schema terakhir (default 1)
+  migrate status           daftar migration & seed beserta state-nya
+  migrate seed             jalankan seed data contoh yang baru / berubah
+  order get <ref>          detail order (id atau external_id): item, reservasi, status DB & cache
+  order status <ref> <STATUS> -reason "..." [-actor nama]
+                           paksa transisi status (tetap divalidasi CanTransition, dicatat)
+  order release <ref> -reason "..." [-actor nama] [-force]
+                           lepas reservasi order, status -> FAILED (dicatat) + kirim StockReleased
+  order reemit <ref> [-dry-run]
+                           kirim ulang event terakhir order (OrderCreated / StockReserved)
+  order stuck [-status S1,S2] [-older-than 15m] [-limit 50]
+                           order yang statusnya tidak bergerak
+  config print             config efektif (secret di-redact)
+
+output: -o table|json (setelah command, mis. "migrate status -o json")`
+
+func main() {
+	_ = godotenv.Load()
+	cfg, rest, err := config.Load("ordersctl", os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if len(rest) == 0 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	// log ke stderr (text) supaya stdout bersih untuk -o json
+	logx.Setup(logx.Config{Level: cfg.Log.Level, Format: logx.FormatText})
+	orders.SetTopics(orders.Topics(cfg.Kafka.Topics))
+	redisx.ApplyTTLs(cfg.Redis)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch rest[0] {
+	case "config":
+		_, err = config.Command(os.Stdout, rest, cfg)
+	case "migrate":
+		err = migrateCmd(ctx, cfg, rest[1:])
+	case "order":
+		err = orderCmd(ctx, cfg, rest[1:])
+	default:
+		err = fmt.Errorf("unknown command %q\n\n%s", rest[0], usage)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
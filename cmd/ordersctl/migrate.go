@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/migrate"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
+)
+
+// migrateCmd: up [version] | down [steps] | status | seed.
+func migrateCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command (up | down | status | seed)")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("migrate "+sub, flag.ContinueOnError)
+	out := outputFlag(fs)
+	pos, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	n := 0
+	if len(pos) > 0 {
+		v, err := strconv.Atoi(pos[0])
+		if err != nil || v < 0 {
+			return fmt.Errorf("migrate %s: invalid number %q", sub, pos[0])
+		}
+		n = v
+	}
+
+	db, err := postgres.Connect(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("db connect: %w", err)
+	}
+	defer db.Close()
+	m, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+
+	var done []migrate.Migration
+	switch sub {
+	case "up":
+		done, err = m.Up(ctx, n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		done, err = m.Down(ctx, n)
+	case "seed":
+		done, err = m.Seed(ctx)
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(st))
+		for _, s := range st {
+			at := "-"
+			if s.AppliedAt != nil {
+				at = timeCol(*s.AppliedAt)
+			}
+			rows = append(rows, []string{s.Kind, fmt.Sprintf("%03d", s.Version), s.Name, s.State, at})
+		}
+		return write(os.Stdout, *out, st, []string{"KIND", "VERSION", "NAME", "STATE", "APPLIED AT"}, rows)
+	default:
+		return fmt.Errorf("unknown migrate command %q (up | down | status | seed)", sub)
+	}
+	// yang sudah jalan tetap ditampilkan walau ada yang gagal di tengah
+	type result struct {
+		Kind    string `json:"kind"`
+		Version int    `json:"version"`
+		Name    string `json:"name"`
+	}
+	res := make([]result, 0, len(done))
+	rows := make([][]string, 0, len(done))
+	for _, mg := range done {
+		res = append(res, result{mg.Kind, mg.Version, mg.Name})
+		rows = append(rows, []string{mg.Kind, fmt.Sprintf("%03d", mg.Version), mg.Name})
+	}
+	if len(done) == 0 && *out == outputTable {
+		fmt.Println("nothing to do")
+	} else if werr := write(os.Stdout, *out, res, []string{"KIND", "VERSION", "NAME"}, rows); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/ordersvc"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// nilai Envelope.Producer untuk event yang dikirim dari ordersctl
+const producerName = "ordersctl"
+
+// orderOps: dependency command order; Redis dibuat hanya kalau dipakai (get / status).
+type orderOps struct {
+	cfg config.Config
+	db  *pgxpool.Pool
+	rdb *redis.Client
+}
+
+func (o *orderOps) repo() *orders.Repo { return &orders.Repo{DB: o.db} }
+
+func (o *orderOps) cache() ordersvc.RedisCache {
+	if o.rdb == nil {
+		o.rdb = redisx.New(o.cfg.Redis)
+	}
+	return ordersvc.RedisCache{Client: o.rdb}
+}
+
+// orderCmd: get | status | release | reemit | stuck. Order bisa disebut lewat id atau external_id.
+func orderCmd(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing order command (get | status | release | reemit | stuck)")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("order "+sub, flag.ContinueOnError)
+	out := outputFlag(fs)
+	var run func(ctx context.Context, o *orderOps, format string, args []string) error
+	switch sub {
+	case "get":
+		run = orderGet
+	case "status":
+		reason := fs.String("reason", "", "alasan perubahan (wajib, dicatat di order_status_changes)")
+		actor := fs.String("actor", defaultActor(), "siapa yang mengubah")
+		run = func(ctx context.Context, o *orderOps, format string, args []string) error {
+			return orderStatus(ctx, o, format, args, *reason, *actor)
+		}
+	case "release":
+		force := fs.Bool("force", false, "tetap release walau order sudah PAID / COMPLETED (status tidak diubah)")
+		reason := fs.String("reason", "", "alasan release (wajib, dicatat di order_status_changes)")
+		actor := fs.String("actor", defaultActor(), "siapa yang me-release")
+		run = func(ctx context.Context, o *orderOps, format string, args []string) error {
+			return orderRelease(ctx, o, format, args, *force, *reason, *actor)
+		}
+	case "reemit":
+		dryRun := fs.Bool("dry-run", false, "tampilkan event tanpa mengirim ke Kafka")
+		run = func(ctx context.Context, o *orderOps, format string, args []string) error {
+			return orderReemit(ctx, o, format, args, *dryRun)
+		}
+	case "stuck":
+		statuses := fs.String("status", "", "daftar status dipisah koma (default semua status yang belum final)")
+		olderThan := fs.Duration("older-than", 15*time.Minute, "status tidak berubah selama minimal ini")
+		limit := fs.Int("limit", 50, "jumlah maksimum order")
+		run = func(ctx context.Context, o *orderOps, format string, _ []string) error {
+			return orderStuck(ctx, o, format, *statuses, *olderThan, *limit)
+		}
+	default:
+		return fmt.Errorf("unknown order command %q (get | status | release | reemit | stuck)", sub)
+	}
+	pos, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	db, err := postgres.Connect(ctx, cfg.Postgres)
+	if err != nil {
+		return fmt.Errorf("db connect: %w", err)
+	}
+	defer db.Close()
+	o := &orderOps{cfg: cfg, db: db}
+	defer func() {
+		if o.rdb != nil {
+			_ = o.rdb.Close()
+		}
+	}()
+	return run(ctx, o, *out, pos)
+}
+
+func defaultActor() string {
+	if u := os.Getenv("USER"); u != "" {
+		return u
+	}
+	return producerName
+}
+
+// findOrder: argumen pertama = id / external_id.
+func findOrder(ctx context.Context, o *orderOps, args []string) (orders.OrderDetail, error) {
+	if len(args) == 0 || args[0] == "" {
+		return orders.OrderDetail{}, errors.New("missing order id / external_id")
+	}
+	d, err := o.repo().FindOrder(ctx, args[0])
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		return d, fmt.Errorf("%w: %s", err, args[0])
+	}
+	return d, err
+}
+
+func orderGet(ctx context.Context, o *orderOps, format string, args []string) error {
+	d, err := findOrder(ctx, o, args)
+	if err != nil {
+		return err
+	}
+	// status di cache API bisa tertinggal dari DB; ditampilkan supaya beda-nya kelihatan
+	view := struct {
+		orders.OrderDetail
+		CachedStatus orders.Status `json:"cached_status,omitempty"`
+	}{OrderDetail: d}
+	if st, ok, err := o.cache().GetStatus(ctx, d.ID); err != nil {
+		slog.Warn("status cache unavailable", "err", err)
+	} else if ok {
+		view.CachedStatus = st
+	}
+	if format != outputTable {
+		return write(os.Stdout, format, view, nil, nil)
+	}
+
+	cached := string(view.CachedStatus)
+	if cached == "" {
+		cached = "-"
+	}
+	w := os.Stdout
+	if err := write(w, format, nil, []string{"FIELD", "VALUE"}, [][]string{
+		{"id", d.ID},
+		{"external_id", d.ExternalID},
+		{"user_id", d.UserID},
+		{"status", string(d.Status)},
+		{"cached_status", cached},
+		{"total", money(d.TotalCents, d.Currency)},
+		{"discount", money(d.DiscountCents, d.Currency)},
+		{"tax", money(d.TaxCents, d.Currency)},
+		{"coupon", orDash(d.CouponCode)},
+		{"created_at", timeCol(d.CreatedAt)},
+		{"updated_at", timeCol(d.UpdatedAt)},
+	}); err != nil {
+		return err
+	}
+
+	rows := make([][]string, 0, len(d.Items))
+	for _, it := range d.Items {
+		fulfilled := "-"
+		if it.FulfilledQty != nil {
+			fulfilled = strconv.Itoa(*it.FulfilledQty)
+		}
+		rows = append(rows, []string{it.SKU, it.ProductID, strconv.Itoa(it.Qty), fulfilled,
+			money(it.PriceCents, d.Currency), money(it.FinalCents, d.Currency), money(it.TaxCents, d.Currency)})
+	}
+	fmt.Fprintln(w)
+	if err := write(w, format, nil, []string{"SKU", "PRODUCT_ID", "QTY", "FULFILLED", "PRICE", "FINAL", "TAX"}, rows); err != nil {
+		return err
+	}
+
+	rows = rows[:0]
+	for _, r := range d.Reservations {
+		exp := "-"
+		if r.ExpiresAt != nil {
+			exp = timeCol(*r.ExpiresAt)
+		}
+		rows = append(rows, []string{r.ProductID, r.WarehouseCode, strconv.Itoa(r.Qty), r.Status, timeCol(r.CreatedAt), exp})
+	}
+	fmt.Fprintln(w)
+	if err := write(w, format, nil, []string{"PRODUCT_ID", "WAREHOUSE", "QTY", "RESERVATION", "CREATED_AT", "EXPIRES_AT"}, rows); err != nil {
+		return err
+	}
+
+	if len(d.StatusChanges) == 0 {
+		return nil
+	}
+	rows = rows[:0]
+	for _, c := range d.StatusChanges {
+		rows = append(rows, []string{timeCol(c.CreatedAt), string(c.From), string(c.To), c.Actor, c.Reason})
+	}
+	fmt.Fprintln(w)
+	return write(w, format, nil, []string{"CHANGED_AT", "FROM", "TO", "ACTOR", "REASON"}, rows)
+}
+
+// orderStatus: order status <ref> <STATUS> -reason "...". Cache status API ikut diperbarui.
+func orderStatus(ctx context.Context, o *orderOps, format string, args []string, reason, actor string) error {
+	if len(args) < 2 {
+		return errors.New("usage: order status <id|external_id> <STATUS> -reason \"...\"")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("-reason is required")
+	}
+	d, err := findOrder(ctx, o, args)
+	if err != nil {
+		return err
+	}
+	to := orders.Status(strings.ToUpper(args[1]))
+	from, err := o.repo().ForceStatus(ctx, d.ID, to, strings.TrimSpace(reason), actor)
+	if err != nil {
+		return err
+	}
+	slog.Info("order status forced", "order_id", d.ID, "from", string(from), "to", string(to), "actor", actor, "reason", reason)
+	if err := o.cache().SetStatus(ctx, d.ID, to); err != nil {
+		slog.Warn("status cache update failed, API may serve the old status until it expires", "order_id", d.ID, "err", err)
+	}
+
+	res := struct {
+		OrderID string        `json:"order_id"`
+		From    orders.Status `json:"from"`
+		To      orders.Status `json:"to"`
+	}{d.ID, from, to}
+	return write(os.Stdout, format, res, []string{"ORDER_ID", "FROM", "TO"}, [][]string{{d.ID, string(from), string(to)}})
+}
+
+// orderRelease: lepas semua reservasi RESERVED, order dipindah ke FAILED (dengan reason) di tx yang
+// sama, lalu kirim StockReleased (reason MANUAL_RELEASE). PAID / COMPLETED (-force) dan FAILED tetap
+// statusnya, hanya stoknya yang dikembalikan.
+func orderRelease(ctx context.Context, o *orderOps, format string, args []string, force bool, reason, actor string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("-reason is required")
+	}
+	d, err := findOrder(ctx, o, args)
+	if err != nil {
+		return err
+	}
+	if (d.Status == orders.StatusPaid || d.Status == orders.StatusCompleted) && !force {
+		return fmt.Errorf("order %s is %s; use -force to release its reservations anyway", d.ID, d.Status)
+	}
+	var to orders.Status
+	if orders.CanTransition(d.Status, orders.StatusFailed) {
+		to = orders.StatusFailed
+	}
+	res := &orders.ReservationRepo{DB: o.db}
+	from, allocs, err := res.ReleaseOrder(ctx, d.ID, to, reason, actor)
+	if err != nil {
+		return err
+	}
+	if to != "" {
+		slog.Info("order status forced", "order_id", d.ID, "from", string(from), "to", string(to), "actor", actor, "reason", reason)
+		if err := o.cache().SetStatus(ctx, d.ID, to); err != nil {
+			slog.Warn("status cache update failed, API may serve the old status until it expires", "order_id", d.ID, "err", err)
+		}
+	}
+	if len(allocs) == 0 {
+		fmt.Fprintln(os.Stderr, "no reserved stock to release")
+		return nil
+	}
+	slog.Info("reservations released", "order_id", d.ID, "allocations", len(allocs))
+
+	payload := orders.StockReleasedPayload{OrderID: d.ID, Reason: orders.ReleaseManual, Allocations: allocs}
+	if _, err := send(ctx, o.cfg, orders.TopicStockReleased, orders.EventStockReleased, orders.VersionDefault, d.ID, payload, false); err != nil {
+		return fmt.Errorf("stock released but %s not published: %w", orders.EventStockReleased, err)
+	}
+
+	rows := make([][]string, 0, len(allocs))
+	for _, a := range allocs {
+		rows = append(rows, []string{a.ProductID, a.WarehouseCode, strconv.Itoa(a.Qty)})
+	}
+	return write(os.Stdout, format, payload, []string{"PRODUCT_ID", "WAREHOUSE", "QTY"}, rows)
+}
+
+// orderReemit: kirim ulang event terakhir order, dirakit dari state di DB:
+// CREATED -> OrderCreated, STOCK_RESERVED / PARTIALLY_RESERVED -> StockReserved. Status lain
+// berasal dari event luar (payment / orchestrator) yang tidak tersimpan di sini.
+// Event baru memakai event_id baru; consumer tetap idempotent per order. OrderCreated untuk order
+// lama (sebelum region & fulfilment policy disimpan) ditolak supaya tidak diproses dengan policy default.
+func orderReemit(ctx context.Context, o *orderOps, format string, args []string, dryRun bool) error {
+	d, err := findOrder(ctx, o, args)
+	if err != nil {
+		return err
+	}
+	var (
+		topic, eventType string
+		version          int
+		payload          any
+	)
+	switch d.Status {
+	case orders.StatusCreated:
+		topic, eventType, version = orders.TopicOrderCreated, orders.EventOrderCreated, orders.VersionOrderCreated
+		payload, err = o.repo().CreatedPayload(ctx, d.ID)
+	case orders.StatusStockReserved, orders.StatusPartiallyReserved:
+		topic, eventType, version = orders.TopicStockReserved, orders.EventStockReserved, orders.VersionStockReserved
+		payload, err = (&orders.ReservationRepo{DB: o.db}).ReservedPayload(ctx, d.ID)
+	default:
+		return fmt.Errorf("order %s is %s: last event is not produced by this service, nothing to re-emit", d.ID, d.Status)
+	}
+	if err != nil {
+		return err
+	}
+	ev, err := send(ctx, o.cfg, topic, eventType, version, d.ID, payload, dryRun)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		slog.Info("event re-emitted", "order_id", d.ID, "event_type", eventType, "event_id", ev.EventID, "topic", topic)
+	}
+	sent := "yes"
+	if dryRun {
+		sent = "no (dry run)"
+	}
+	return write(os.Stdout, format, ev, []string{"EVENT", "VERSION", "TOPIC", "EVENT_ID", "SENT"},
+		[][]string{{eventType, strconv.Itoa(version), topic, ev.EventID, sent}})
+}
+
+func orderStuck(ctx context.Context, o *orderOps, format, statuses string, olderThan time.Duration, limit int) error {
+	var sts []orders.Status
+	for _, s := range strings.Split(statuses, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			sts = append(sts, orders.Status(strings.ToUpper(s)))
+		}
+	}
+	if limit <= 0 {
+		return errors.New("-limit must be > 0")
+	}
+	list, err := o.repo().StuckOrders(ctx, sts, olderThan, limit)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(list))
+	for _, od := range list {
+		rows = append(rows, []string{od.ID, od.ExternalID, string(od.Status), money(od.TotalCents, od.Currency),
+			timeCol(od.UpdatedAt), time.Since(od.UpdatedAt).Round(time.Second).String()})
+	}
+	return write(os.Stdout, format, list, []string{"ORDER_ID", "EXTERNAL_ID", "STATUS", "TOTAL", "UPDATED_AT", "AGE"}, rows)
+}
+
+// send: bungkus payload dalam Envelope lalu kirim sinkron (dryRun = hanya dirakit).
+func send(ctx context.Context, cfg config.Config, topic, eventType string, version int, orderID string, payload any, dryRun bool) (orders.Envelope, error) {
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		EventVersion:  version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      producerName,
+		CorrelationID: orderID,
+		Payload:       kafkax.MustMarshal(payload),
+	}
+	if dryRun {
+		return ev, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	err := kafkax.Send(ctx, cfg.Kafka.Brokers, topic, orders.PartitionKey(orderID), kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(eventType)},
+		kafkago.Header{Key: "x-event-version", Value: []byte(strconv.Itoa(version))},
+	)
+	return ev, err
+}
+
+func money(cents int, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", cents/100, cents%100, currency)
+}
+
+func timeCol(t time.Time) string { return t.Local().Format(time.DateTime) }
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
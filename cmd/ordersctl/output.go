@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// outputFlag: -o table|json untuk setiap command.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("o", outputTable, "output: table | json")
+}
+
+// parseArgs: seperti fs.Parse tapi flag boleh ditulis setelah argumen posisi
+// ("order status <ref> PAID -reason ..."). Return argumen posisi.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return pos, nil
+		}
+		pos = append(pos, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// write: json = v apa adanya; table = header + rows (kolom dipisah tab).
+func write(w io.Writer, format string, v any, header []string, rows [][]string) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, row := range append([][]string{header}, rows...) {
+			for j, col := range row {
+				if j > 0 {
+					fmt.Fprint(tw, "\t")
+				}
+				fmt.Fprint(tw, col)
+			}
+			fmt.Fprintln(tw)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output %q (table | json)", format)
+	}
+}
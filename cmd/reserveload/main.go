@@ -0,0 +1,269 @@
+// reserveload: load test + benchmark reservasi terhadap satu SKU panas.
+//
+// Membuat produk HOT baru dengan stok terbatas dan N order untuk produk itu, lalu menjalankan
+// reservasi secara paralel; sebagian order langsung di-ReleaseAll. Setelah selesai dicek: stok tidak
+// negatif, stok + qty RESERVED = stok awal, warehouse_stock = products.stock, dan ledger tidak drift.
+// Exit code 1 kalau ada yang dilanggar. Invariant yang sama (jalur postgres, skala kecil) dijalankan
+// test integrasi TestReserveConcurrentKeepsStockConsistent di internal/orders.
+//
+// -path memilih jalur reservasi: postgres (ReservationRepo langsung), redis (inventory.HotStock,
+// produk HOT di-flag hot, order di-ack dari counter dan ditulis async) atau both (dua run berurutan
+// dengan order yang sama bentuknya, throughput dibandingkan). Khusus -path postgres, setiap order juga
+// berisi produk COLD (stok berlimpah) dalam urutan berlawanan, pemicu deadlock kalau lock tidak
+// deterministik; order campuran seperti itu tidak pernah lewat jalur async HotStock.
+//
+//	go run ./cmd/reserveload -orders 500 -concurrency 32 -stock 100 -path both
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/inventory"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
+)
+
+type params struct {
+	orders, conc, stock, qty, releaseEvery int
+	mixed                                  bool // order berisi produk COLD juga (hanya -path postgres)
+}
+
+type stats struct {
+	reserved, rejected, released, failed int64
+	elapsed                              time.Duration
+	problems                             []string
+}
+
+func main() {
+	_ = godotenv.Load()
+	cfg, _, err := config.Load("reserveload", nil) // flag milik tool ini sendiri, config dari file/env
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var p params
+	flag.IntVar(&p.orders, "orders", 500, "jumlah order")
+	flag.IntVar(&p.conc, "concurrency", 32, "worker paralel")
+	flag.IntVar(&p.stock, "stock", 100, "stok awal SKU panas")
+	flag.IntVar(&p.qty, "qty", 1, "qty SKU panas per order")
+	flag.IntVar(&p.releaseEvery, "release-every", 4, "setiap order ke-N langsung di-release (0 = tidak ada)")
+	path := flag.String("path", "postgres", "postgres | redis | both")
+	dsn := flag.String("dsn", cfg.Postgres.DSN, "postgres DSN")
+	redisAddr := flag.String("redis", cfg.Redis.Addr, "redis addr (path redis)")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pg := cfg.Postgres
+	pg.DSN = *dsn
+	db, err := postgres.Connect(ctx, pg)
+	if err != nil {
+		log.Fatalf("db: %v", err)
+	}
+	defer db.Close()
+
+	var paths []string
+	switch *path {
+	case "postgres", "redis":
+		paths = []string{*path}
+	case "both":
+		paths = []string{"postgres", "redis"}
+	default:
+		log.Fatalf("unknown -path %q", *path)
+	}
+	p.mixed = *path == "postgres"
+
+	var rdb *redis.Client
+	failed := false
+	results := map[string]stats{}
+	for _, pth := range paths {
+		if pth == "redis" && rdb == nil {
+			rc := cfg.Redis
+			rc.Addr = *redisAddr
+			rdb = redisx.New(rc)
+			defer rdb.Close()
+		}
+		st := run(ctx, db, rdb, pth, p)
+		results[pth] = st
+		log.Printf("[%s] orders=%d reserved=%d rejected=%d released=%d errors=%d in %s (%.0f orders/s)",
+			pth, p.orders, st.reserved, st.rejected, st.released, st.failed, st.elapsed, float64(p.orders)/st.elapsed.Seconds())
+		for _, pr := range st.problems {
+			log.Printf("[%s] FAIL %s", pth, pr)
+			failed = true
+		}
+	}
+	if len(paths) == 2 {
+		pg, rd := results["postgres"].elapsed, results["redis"].elapsed
+		log.Printf("redis vs postgres: %.2fx throughput", pg.Seconds()/rd.Seconds())
+	}
+	if failed {
+		os.Exit(1)
+	}
+	log.Printf("OK: stok konsisten")
+}
+
+// run: satu putaran load test dengan produk & order baru.
+func run(ctx context.Context, db *pgxpool.Pool, rdb *redis.Client, path string, p params) stats {
+	tag := uuid.NewString()[:8]
+	cat := &orders.CatalogRepo{DB: db}
+	hot, err := cat.CreateProduct(ctx, orders.NewProduct{SKU: "LOAD-HOT-" + tag, Name: "load hot", PriceCents: 100, Stock: p.stock}, "reserveload")
+	if err != nil {
+		log.Fatalf("create hot product: %v", err)
+	}
+	var cold *orders.Product
+	if p.mixed {
+		c, err := cat.CreateProduct(ctx, orders.NewProduct{SKU: "LOAD-COLD-" + tag, Name: "load cold", PriceCents: 100, Stock: p.orders * 10}, "reserveload")
+		if err != nil {
+			log.Fatalf("create cold product: %v", err)
+		}
+		cold = &c
+	}
+
+	// mixed: order genap hot dulu, ganjil cold dulu
+	repo := &orders.Repo{DB: db}
+	orderIDs := make([]string, p.orders)
+	lines := make([][]orders.ItemQty, p.orders)
+	for i := range orderIDs {
+		items := []orders.ItemInput{{ProductID: hot.ID, Qty: p.qty}}
+		if cold != nil {
+			items = append(items, orders.ItemInput{ProductID: cold.ID, Qty: 1})
+			if i%2 == 1 {
+				items[0], items[1] = items[1], items[0]
+			}
+		}
+		created, err := repo.CreateOrderTx(ctx, fmt.Sprintf("LOAD-%s-%d", tag, i), uuid.NewString(), items, orders.PricingOptions{})
+		if err != nil {
+			log.Fatalf("create order: %v", err)
+		}
+		orderIDs[i] = created.OrderID
+		for _, it := range items {
+			lines[i] = append(lines[i], orders.ItemQty{ProductID: it.ProductID, Qty: it.Qty})
+		}
+	}
+
+	var st stats
+	var reserved, rejected, released, failed atomic.Int64
+	res := &orders.ReservationRepo{DB: db, HoldTTL: time.Hour}
+	// settle: hasil reservasi yang sudah tersimpan di Postgres (release baru aman setelah ini)
+	settle := func(i int, r orders.ReserveResult, err error) {
+		switch {
+		case err != nil:
+			failed.Add(1)
+			log.Printf("reserve %s: %v", orderIDs[i], err)
+			return
+		case r.Status == "":
+			rejected.Add(1)
+			return
+		}
+		reserved.Add(1)
+		if p.releaseEvery > 0 && i%p.releaseEvery == 0 {
+			if _, err := res.ReleaseAll(ctx, orderIDs[i]); err != nil {
+				failed.Add(1)
+				log.Printf("release %s: %v", orderIDs[i], err)
+				return
+			}
+			released.Add(1)
+		}
+	}
+
+	reserve := res.Reserve
+	var hs *inventory.HotStock
+	if path == "redis" {
+		on := true
+		if _, err := cat.UpdateProduct(ctx, hot.ID, hot.Version, orders.ProductPatch{Hot: &on}); err != nil {
+			log.Fatalf("flag hot: %v", err)
+		}
+		idx := make(map[string]int, len(orderIDs))
+		for i, id := range orderIDs {
+			idx[id] = i
+		}
+		hs = &inventory.HotStock{Redis: rdb, Repo: res, Stock: &orders.InventoryRepo{DB: db}}
+		hs.OnWritten = func(_ context.Context, req orders.ReserveRequest, r orders.ReserveResult, err error) {
+			settle(idx[req.OrderID], r, err)
+		}
+		hs.Start(ctx)
+		reserve = hs.Reserve
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < p.conc; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r, err := reserve(ctx, orders.ReserveRequest{OrderID: orderIDs[i], Items: lines[i]})
+				if err == nil && r.Pending {
+					continue // di-settle OnWritten
+				}
+				settle(i, r, err)
+			}
+		}()
+	}
+	for i := range orderIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if hs != nil {
+		// waktu jalur redis termasuk write async ke Postgres
+		if err := hs.Flush(ctx); err != nil {
+			log.Printf("hotstock flush: %v", err)
+		}
+	}
+	st.elapsed = time.Since(start)
+	st.reserved, st.rejected, st.released, st.failed = reserved.Load(), rejected.Load(), released.Load(), failed.Load()
+
+	st.problems = check(ctx, db, hot, p.stock)
+	if cold != nil {
+		st.problems = append(st.problems, check(ctx, db, *cold, p.orders*10)...)
+	}
+	if st.failed > 0 {
+		st.problems = append(st.problems, fmt.Sprintf("%d reserve/release error(s)", st.failed))
+	}
+	return st
+}
+
+// check: invariant stok satu produk setelah load test.
+func check(ctx context.Context, db *pgxpool.Pool, p orders.Product, initial int) []string {
+	var stock, whStock, held, ledger int
+	err := db.QueryRow(ctx, `
+		SELECT p.stock,
+		       (SELECT COALESCE(SUM(stock), 0)::int FROM warehouse_stock WHERE product_id = p.id),
+		       (SELECT COALESCE(SUM(qty), 0)::int FROM reservations WHERE product_id = p.id AND status = 'RESERVED'),
+		       (SELECT COALESCE(SUM(delta), 0)::int FROM inventory_movements WHERE product_id = p.id)
+		FROM products p WHERE p.id = $1`, p.ID).Scan(&stock, &whStock, &held, &ledger)
+	if err != nil {
+		return []string{fmt.Sprintf("%s: %v", p.SKU, err)}
+	}
+	log.Printf("%s: stock=%d warehouse=%d reserved=%d ledger=%d initial=%d", p.SKU, stock, whStock, held, ledger, initial)
+
+	var out []string
+	if stock < 0 || whStock < 0 {
+		out = append(out, fmt.Sprintf("%s: negative stock (%d / %d)", p.SKU, stock, whStock))
+	}
+	if stock+held != initial {
+		out = append(out, fmt.Sprintf("%s: stock leak: stock %d + reserved %d != initial %d", p.SKU, stock, held, initial))
+	}
+	if whStock != stock {
+		out = append(out, fmt.Sprintf("%s: warehouse_stock %d != products.stock %d", p.SKU, whStock, stock))
+	}
+	if ledger != stock {
+		out = append(out, fmt.Sprintf("%s: ledger %d != stock %d", p.SKU, ledger, stock))
+	}
+	return out
+}
@@ -0,0 +1,15 @@
+// Package db menyimpan file SQL (schema migration + seed) yang di-embed ke binary; dijalankan
+// lewat internal/migrate.
+package db
+
+import "embed"
+
+// Migrations: NNN_nama.sql (up) + NNN_nama.down.sql (opsional).
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Seeds: data contoh / demo, terpisah dari schema (ordersctl migrate seed).
+//
+//go:embed seeds/*.sql
+var Seeds embed.FS
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_orders_updated ON orders;
+DROP TRIGGER IF EXISTS trg_products_updated ON products;
+DROP FUNCTION IF EXISTS set_updated_at();
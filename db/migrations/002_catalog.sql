@@ -0,0 +1,5 @@
+-- Catalog management: optimistic concurrency (version) + soft archive
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_active_sku ON products(sku) WHERE archived_at IS NULL;
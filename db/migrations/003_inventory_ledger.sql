@@ -0,0 +1,20 @@
+-- Ledger semua perubahan stok. Invariant: products.stock = SUM(delta) per product.
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id),
+    delta INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('INITIAL','RESERVE','RELEASE','RESTOCK','ADJUSTMENT','SHRINKAGE')),
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    order_id UUID NULL REFERENCES orders(id) ON DELETE SET NULL,
+    stock_after INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_movements_product_created ON inventory_movements(product_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_movements_order ON inventory_movements(order_id) WHERE order_id IS NOT NULL;
+
+-- Backfill: stok yang sudah ada sebelum ledger dicatat sebagai INITIAL (sekali saja)
+INSERT INTO inventory_movements(product_id, delta, kind, reason, actor, stock_after)
+SELECT p.id, p.stock, 'INITIAL', 'ledger backfill', 'migration', p.stock
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM inventory_movements m WHERE m.product_id = p.id);
@@ -0,0 +1,14 @@
+-- Reservasi yang di-split ke beberapa gudang digabung lagi per (order, product)
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS uq_reservation_wh;
+DELETE FROM reservations r USING reservations d
+WHERE r.order_id = d.order_id AND r.product_id = d.product_id AND r.id > d.id;
+ALTER TABLE reservations DROP COLUMN IF EXISTS warehouse_id;
+DO $$
+BEGIN
+ALTER TABLE reservations ADD CONSTRAINT uq_reservation UNIQUE(order_id, product_id);
+EXCEPTION WHEN duplicate_object THEN
+  NULL;
+END $$;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,44 @@
+-- Multi-warehouse: stok per gudang. products.stock tetap dipertahankan sebagai total semua gudang.
+CREATE TABLE IF NOT EXISTS warehouses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    region TEXT NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 100, -- makin kecil makin diutamakan
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    stock INTEGER NOT NULL CHECK (stock >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (warehouse_id, product_id)
+);
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product ON warehouse_stock(product_id);
+
+-- Gudang default: semua stok lama dipindah ke sini
+INSERT INTO warehouses(code, name, region, priority)
+VALUES ('WH-MAIN', 'Main warehouse', 'default', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO warehouse_stock(warehouse_id, product_id, stock)
+SELECT w.id, p.id, p.stock
+FROM products p CROSS JOIN warehouses w
+WHERE w.code = 'WH-MAIN'
+ON CONFLICT (warehouse_id, product_id) DO NOTHING;
+
+-- Reservation sekarang per (order, product, warehouse): satu item bisa di-split ke beberapa gudang
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS warehouse_id UUID NULL REFERENCES warehouses(id);
+UPDATE reservations SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'WH-MAIN') WHERE warehouse_id IS NULL;
+ALTER TABLE reservations ALTER COLUMN warehouse_id SET NOT NULL;
+ALTER TABLE reservations DROP CONSTRAINT IF EXISTS uq_reservation;
+DO $$
+BEGIN
+ALTER TABLE reservations ADD CONSTRAINT uq_reservation_wh UNIQUE(order_id, product_id, warehouse_id);
+EXCEPTION WHEN duplicate_object THEN
+  NULL;
+END $$;
+
+ALTER TABLE inventory_movements ADD COLUMN IF NOT EXISTS warehouse_id UUID NULL REFERENCES warehouses(id);
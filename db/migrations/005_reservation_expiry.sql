@@ -0,0 +1,8 @@
+-- Hold TTL: reservasi RESERVED yang lewat expires_at di-release oleh sweeper (cmd/inventory).
+ALTER TABLE reservations ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NULL;
+
+-- reservasi lama yang masih RESERVED dapat TTL default dari created_at
+UPDATE reservations SET expires_at = created_at + interval '15 minutes'
+WHERE status = 'RESERVED' AND expires_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reservations_expiry ON reservations(expires_at) WHERE status = 'RESERVED';
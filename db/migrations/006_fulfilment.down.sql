@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS backorders;
+ALTER TABLE order_items DROP COLUMN IF EXISTS fulfilled_qty;
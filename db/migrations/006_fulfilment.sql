@@ -0,0 +1,16 @@
+-- Partial fulfilment / backorder.
+-- fulfilled_qty: qty yang benar-benar di-reserve (NULL = belum diproses / penuh).
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS fulfilled_qty INTEGER NULL CHECK (fulfilled_qty >= 0);
+
+CREATE TABLE IF NOT EXISTS backorders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    qty INTEGER NOT NULL CHECK (qty > 0), -- sisa yang belum terpenuhi
+    region TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'PENDING', -- PENDING | FILLED | CANCELLED
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    filled_at TIMESTAMPTZ NULL
+);
+CREATE INDEX IF NOT EXISTS idx_backorders_pending ON backorders(product_id, created_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_backorders_order ON backorders(order_id);
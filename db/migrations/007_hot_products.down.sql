@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_products_hot;
+ALTER TABLE products DROP COLUMN IF EXISTS hot;
@@ -0,0 +1,3 @@
+-- Produk "hot" (flash sale): reservasi lewat counter Redis dulu (lihat internal/inventory/hotstock.go).
+ALTER TABLE products ADD COLUMN IF NOT EXISTS hot BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS idx_products_hot ON products(id) WHERE hot;
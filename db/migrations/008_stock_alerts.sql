@@ -0,0 +1,10 @@
+-- Low-stock alert: threshold reorder per produk + state terakhir (debounce event StockLow/StockDepleted).
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    product_id UUID PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    threshold INTEGER NOT NULL CHECK (threshold >= 0),
+    state TEXT NOT NULL DEFAULT 'OK', -- OK | LOW | DEPLETED
+    state_since TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_notified_at TIMESTAMPTZ NULL, -- terakhir publish StockLow (cooldown)
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_active ON stock_alerts(state) WHERE state <> 'OK';
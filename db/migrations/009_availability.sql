@@ -0,0 +1,2 @@
+-- Availability API: SUM(qty) reservasi aktif per produk
+CREATE INDEX IF NOT EXISTS idx_reservations_product_active ON reservations(product_id) WHERE status = 'RESERVED';
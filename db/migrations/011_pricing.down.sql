@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS coupon_code;
+ALTER TABLE orders DROP COLUMN IF EXISTS discount_cents;
+ALTER TABLE order_items DROP COLUMN IF EXISTS final_cents;
+ALTER TABLE order_items DROP COLUMN IF EXISTS discount_cents;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
+DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,46 @@
+-- Pricing: promosi otomatis (periode), kupon per user + batas pemakaian, breakdown harga per line.
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('PERCENT', 'FIXED', 'BUY_X_GET_Y')),
+    product_id UUID NULL REFERENCES products(id), -- NULL = seluruh order (PERCENT / FIXED)
+    percent INTEGER NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    amount_cents INTEGER NOT NULL DEFAULT 0 CHECK (amount_cents >= 0),
+    buy_qty INTEGER NOT NULL DEFAULT 0 CHECK (buy_qty >= 0),
+    get_qty INTEGER NOT NULL DEFAULT 0 CHECK (get_qty >= 0),
+    coupon_only BOOLEAN NOT NULL DEFAULT false, -- true = hanya lewat kode kupon
+    active BOOLEAN NOT NULL DEFAULT true,
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ends_at TIMESTAMPTZ NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_promotions_auto ON promotions(starts_at) WHERE active AND NOT coupon_only;
+
+CREATE TABLE IF NOT EXISTS coupons (
+    code TEXT PRIMARY KEY,
+    promotion_id UUID NOT NULL REFERENCES promotions(id),
+    user_id UUID NULL,                      -- NULL = boleh dipakai siapa saja
+    max_uses INTEGER NULL CHECK (max_uses > 0), -- NULL = tanpa batas
+    max_uses_per_user INTEGER NOT NULL DEFAULT 1 CHECK (max_uses_per_user > 0),
+    used_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL REFERENCES coupons(code),
+    user_id UUID NOT NULL,
+    order_id UUID NOT NULL UNIQUE REFERENCES orders(id) ON DELETE CASCADE,
+    discount_cents INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_user ON coupon_redemptions(code, user_id);
+
+-- price_cents tetap list price per unit; discount/final per line (total line setelah diskon)
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_cents INTEGER NOT NULL DEFAULT 0 CHECK (discount_cents >= 0);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS final_cents INTEGER NULL CHECK (final_cents >= 0);
+UPDATE order_items SET final_cents = price_cents * qty WHERE final_cents IS NULL;
+ALTER TABLE order_items ALTER COLUMN final_cents SET NOT NULL;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_cents INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_code TEXT NULL;
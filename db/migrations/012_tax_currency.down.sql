@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS order_taxes;
+DROP TABLE IF EXISTS tax_rules;
+ALTER TABLE promotions DROP COLUMN IF EXISTS currency;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_cents;
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_cents;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_category;
+ALTER TABLE products DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,37 @@
+-- Multi-currency + pajak per order.
+-- Kode mata uang ISO 4217; semua kolom *_cents mengikuti currency baris produk / order-nya.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_category TEXT NOT NULL DEFAULT 'STANDARD';
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_cents INTEGER NOT NULL DEFAULT 0 CHECK (tax_cents >= 0);
+
+-- tax_cents per line (total semua pajak line) dipakai saat total PARTIAL dihitung ulang
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_cents INTEGER NOT NULL DEFAULT 0 CHECK (tax_cents >= 0);
+
+-- FIXED amount_cents hanya berlaku untuk order dengan currency yang sama (NULL = semua currency)
+ALTER TABLE promotions ADD COLUMN IF NOT EXISTS currency CHAR(3) NULL;
+
+-- Rule pajak: region '' / category '' = wildcard. Per line dipakai rule paling spesifik
+-- (region+category > region > category > default); beberapa rule di level yang sama dijumlahkan.
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    region TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    rate_bp INTEGER NOT NULL CHECK (rate_bp BETWEEN 0 AND 10000), -- basis point, 1100 = 11%
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (region, category, name)
+);
+
+CREATE TABLE IF NOT EXISTS order_taxes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    name TEXT NOT NULL,
+    rate_bp INTEGER NOT NULL,
+    taxable_cents INTEGER NOT NULL,
+    tax_cents INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_order_taxes_order ON order_taxes(order_id);
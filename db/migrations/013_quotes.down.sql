@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS quote_id;
+DROP TABLE IF EXISTS quote_items;
+DROP TABLE IF EXISTS quotes;
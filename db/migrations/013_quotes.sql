@@ -0,0 +1,21 @@
+-- Quote: snapshot list price per produk untuk satu user, berlaku sampai expires_at.
+-- Order yang menyebut quote_id memakai harga ini (bukan harga products saat itu); satu quote satu order.
+CREATE TABLE IF NOT EXISTS quotes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    currency CHAR(3) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    order_id UUID NULL UNIQUE REFERENCES orders(id) ON DELETE SET NULL, -- terisi saat quote dipakai
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_quotes_open ON quotes(expires_at) WHERE order_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS quote_items (
+    quote_id UUID NOT NULL REFERENCES quotes(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    qty INTEGER NOT NULL CHECK (qty > 0),      -- qty maksimum yang boleh dipesan dengan harga ini
+    price_cents INTEGER NOT NULL CHECK (price_cents >= 0),
+    PRIMARY KEY (quote_id, product_id)
+);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS quote_id UUID NULL REFERENCES quotes(id);
@@ -0,0 +1,14 @@
+-- Feature flag untuk rollout bertahap (lihat internal/flags). Definisi di sini, di-cache di Redis
+-- (hash "flags") dan di-invalidate lewat pub/sub "flags:changed" setiap kali admin mengubah flag.
+--   boolean   : enabled = on untuk semua
+--   percentage: on kalau hash(key + target) % 100 < percent; target = user_id atau external_id order
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key TEXT PRIMARY KEY CHECK (key ~ '^[a-z0-9_.-]{1,64}$'),
+    description TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL CHECK (kind IN ('boolean','percentage')),
+    enabled BOOLEAN NOT NULL DEFAULT false,
+    percent INTEGER NOT NULL DEFAULT 0 CHECK (percent BETWEEN 0 AND 100),
+    target_by TEXT NOT NULL DEFAULT 'user_id' CHECK (target_by IN ('user_id','external_id')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,11 @@
+-- Audit perubahan status order di luar alur event (ordersctl order status): siapa, kapan, kenapa.
+CREATE TABLE IF NOT EXISTS order_status_changes (
+    id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL CHECK (reason <> ''),
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_order_status_changes_order ON order_status_changes(order_id, created_at);
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS fulfilment_policy;
+ALTER TABLE orders DROP COLUMN IF EXISTS region;
@@ -0,0 +1,4 @@
+-- Region & fulfilment policy yang dikirim di OrderCreated ikut disimpan, supaya event bisa dirakit
+-- ulang dari DB (ordersctl order reemit). NULL = order lama sebelum kolom ini ada (tidak diketahui).
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS region TEXT NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS fulfilment_policy TEXT NULL;
@@ -0,0 +1,41 @@
+-- Produk contoh + stok di WH-MAIN + threshold reorder (data demo, bukan schema).
+INSERT INTO products (sku, name, stock, price_cents)
+VALUES
+    ('SKU-APPLE','Apple',100,1500),
+    ('SKU-BREAD','Bread',50,12000),
+    ('SKU-MILK','Milk',80,18000),
+    ('SKU-RICE','Rice',200,55000),
+    ('SKU-TEA','Tea',150,7000)
+    ON CONFLICT (sku) DO UPDATE
+                             SET name = EXCLUDED.name,
+                             stock = EXCLUDED.stock,
+                             price_cents = EXCLUDED.price_cents,
+                             updated_at = now();
+
+-- Seed stok ditaruh di gudang default (WH-MAIN = total - stok gudang lain)
+INSERT INTO warehouse_stock(warehouse_id, product_id, stock)
+SELECT w.id, p.id, GREATEST(p.stock - COALESCE(o.other, 0), 0)
+FROM products p
+JOIN warehouses w ON w.code = 'WH-MAIN'
+LEFT JOIN (SELECT ws.product_id, SUM(ws.stock) AS other
+           FROM warehouse_stock ws JOIN warehouses x ON x.id = ws.warehouse_id AND x.code <> 'WH-MAIN'
+           GROUP BY ws.product_id) o ON o.product_id = p.id
+WHERE p.sku IN ('SKU-APPLE','SKU-BREAD','SKU-MILK','SKU-RICE','SKU-TEA')
+ON CONFLICT (warehouse_id, product_id) DO UPDATE
+    SET stock = EXCLUDED.stock, updated_at = now();
+
+-- Seed mengubah stok langsung; catat selisihnya di ledger supaya reconcile tetap 0 drift
+INSERT INTO inventory_movements(product_id, delta, kind, reason, actor, stock_after)
+SELECT p.id, p.stock - COALESCE(l.total, 0), 'ADJUSTMENT', 'seed', 'migration', p.stock
+FROM products p
+LEFT JOIN (SELECT product_id, SUM(delta) AS total FROM inventory_movements GROUP BY product_id) l
+       ON l.product_id = p.id
+WHERE p.sku IN ('SKU-APPLE','SKU-BREAD','SKU-MILK','SKU-RICE','SKU-TEA')
+  AND p.stock <> COALESCE(l.total, 0);
+
+-- Threshold reorder contoh (alert StockLow kalau stok <= threshold)
+INSERT INTO stock_alerts(product_id, threshold)
+SELECT p.id, t.threshold
+FROM products p
+JOIN (VALUES ('SKU-APPLE', 20), ('SKU-BREAD', 10), ('SKU-MILK', 15)) AS t(sku, threshold) ON t.sku = p.sku
+ON CONFLICT (product_id) DO UPDATE SET threshold = EXCLUDED.threshold, updated_at = now();
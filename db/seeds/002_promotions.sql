@@ -0,0 +1,13 @@
+-- Contoh: teh beli 2 gratis 1 (otomatis), kupon WELCOME10 diskon 10% (maks 1000 pemakaian)
+INSERT INTO promotions(id, name, kind, product_id, buy_qty, get_qty)
+SELECT '5b0b0f0e-0000-4000-8000-000000000001', 'Tea buy 2 get 1', 'BUY_X_GET_Y', p.id, 2, 1
+FROM products p WHERE p.sku = 'SKU-TEA'
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO promotions(id, name, kind, percent, coupon_only)
+VALUES ('5b0b0f0e-0000-4000-8000-000000000002', 'Welcome 10%', 'PERCENT', 10, true)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO coupons(code, promotion_id, max_uses)
+VALUES ('WELCOME10', '5b0b0f0e-0000-4000-8000-000000000002', 1000)
+ON CONFLICT (code) DO NOTHING;
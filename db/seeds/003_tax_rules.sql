@@ -0,0 +1,9 @@
+-- Contoh: PPN 11% default, bahan pokok (GROCERY) bebas pajak, region jakarta + pajak daerah 1%
+INSERT INTO tax_rules(region, category, name, rate_bp) VALUES
+    ('', '', 'VAT', 1100),
+    ('', 'GROCERY', 'VAT', 0),
+    ('id-jakarta', '', 'VAT', 1100),
+    ('id-jakarta', '', 'LOCAL', 100)
+ON CONFLICT (region, category, name) DO NOTHING;
+
+UPDATE products SET tax_category = 'GROCERY' WHERE sku IN ('SKU-RICE', 'SKU-MILK', 'SKU-BREAD') AND tax_category = 'STANDARD';
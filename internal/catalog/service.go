@@ -0,0 +1,117 @@
+package catalog
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Repository: bagian orders.CatalogRepo yang dipakai Service.
+type Repository interface {
+	CreateProduct(ctx context.Context, in orders.NewProduct, actor string) (orders.Product, error)
+	GetProductBySKU(ctx context.Context, sku string) (orders.Product, error)
+	UpdateProduct(ctx context.Context, id string, expectedVersion int, patch orders.ProductPatch) (orders.Product, error)
+	ArchiveProduct(ctx context.Context, id string, expectedVersion int) (orders.Product, error)
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, key, value []byte, headers ...kafkago.Header)
+}
+
+// Service: use-case admin katalog. Setiap perubahan di-publish ke orders.TopicCatalog
+// supaya cache / search index bisa mengikuti.
+type Service struct {
+	Repo      Repository
+	Publisher Publisher
+	Name      string
+}
+
+func (s *Service) Create(ctx context.Context, in orders.NewProduct, actor, traceID string) (orders.Product, error) {
+	if err := validation.Product(in.SKU, in.Name, in.PriceCents, in.Stock, in.Currency, in.TaxCategory); err != nil {
+		return orders.Product{}, err
+	}
+	p, err := s.Repo.CreateProduct(ctx, in, actor)
+	if err != nil {
+		return orders.Product{}, err
+	}
+	s.publish(ctx, orders.EventProductCreated, orders.VersionProduct, p.ID, traceID, orders.ProductCreatedPayload{
+		ProductID: p.ID, SKU: p.SKU, Name: p.Name, PriceCents: p.PriceCents, Stock: p.Stock, Version: p.Version,
+		Currency: p.Currency, TaxCategory: p.TaxCategory,
+	})
+	return p, nil
+}
+
+func (s *Service) GetBySKU(ctx context.Context, sku string) (orders.Product, error) {
+	return s.Repo.GetProductBySKU(ctx, sku)
+}
+
+func (s *Service) Update(ctx context.Context, id string, expectedVersion int, patch orders.ProductPatch, traceID string) (orders.Product, error) {
+	if err := validation.ProductPatch(patch.Name, patch.PriceCents, patch.Hot, patch.TaxCategory, expectedVersion); err != nil {
+		return orders.Product{}, err
+	}
+	p, err := s.Repo.UpdateProduct(ctx, id, expectedVersion, patch)
+	if err != nil {
+		return orders.Product{}, err
+	}
+	var changed []string
+	if patch.Name != nil {
+		changed = append(changed, "name")
+	}
+	if patch.PriceCents != nil {
+		changed = append(changed, "price_cents")
+	}
+	if patch.Hot != nil {
+		changed = append(changed, "hot")
+	}
+	if patch.TaxCategory != nil {
+		changed = append(changed, "tax_category")
+	}
+	s.publish(ctx, orders.EventProductUpdated, orders.VersionProduct, p.ID, traceID, orders.ProductUpdatedPayload{
+		ProductID: p.ID, SKU: p.SKU, Name: p.Name, PriceCents: p.PriceCents, Version: p.Version, Hot: p.Hot,
+		Currency: p.Currency, TaxCategory: p.TaxCategory, Changed: changed,
+	})
+	return p, nil
+}
+
+func (s *Service) Archive(ctx context.Context, id string, expectedVersion int, traceID string) (orders.Product, error) {
+	var v validation.Validator
+	v.Check(expectedVersion > 0, "version", "is required")
+	if err := v.Err(); err != nil {
+		return orders.Product{}, err
+	}
+	p, err := s.Repo.ArchiveProduct(ctx, id, expectedVersion)
+	if err != nil {
+		return orders.Product{}, err
+	}
+	archivedAt := time.Now().UTC()
+	if p.ArchivedAt != nil {
+		archivedAt = p.ArchivedAt.UTC()
+	}
+	s.publish(ctx, orders.EventProductArchived, orders.VersionDefault, p.ID, traceID, orders.ProductArchivedPayload{
+		ProductID: p.ID, SKU: p.SKU, Version: p.Version, ArchivedAt: archivedAt,
+	})
+	return p, nil
+}
+
+func (s *Service) publish(ctx context.Context, eventType string, version int, productID, traceID string, payload any) {
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		EventVersion:  version,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      s.Name,
+		TraceID:       traceID,
+		CorrelationID: productID,
+		Payload:       kafkax.MustMarshal(payload),
+	}
+	s.Publisher.Publish(ctx, []byte(productID), kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(eventType)},
+		kafkago.Header{Key: "x-event-version", Value: []byte(strconv.Itoa(version))},
+	)
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Errors: semua masalah config sekaligus (parse env/flag + Validate), bukan berhenti di yang pertama.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid config:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Load: Default -> file YAML -> env -> flag, lalu Validate. File diambil dari flag -config atau env
+// CONFIG_FILE (kosong = tanpa file). args biasanya os.Args[1:]; rest = argumen setelah flag
+// (subcommand, lihat Command). Config tetap dikembalikan walau err != nil supaya bisa di-print.
+func Load(name string, args []string) (cfg Config, rest []string, err error) {
+	cfg = Default()
+	fields := leaves(&cfg)
+
+	// flag di-parse dulu (perlu -config), tapi nilainya baru dipasang paling akhir
+	type setFlag struct {
+		f field
+		v string
+	}
+	var flagged []setFlag
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "file config YAML (env CONFIG_FILE)")
+	for _, f := range fields {
+		fs.Func(f.path, "env "+f.env, func(v string) error {
+			flagged = append(flagged, setFlag{f, v})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+	rest = fs.Args()
+
+	if *configFile != "" {
+		if err := loadFile(&cfg, *configFile); err != nil {
+			return cfg, rest, err
+		}
+		cfg.File = *configFile
+	}
+
+	var errs Errors
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if v, ok := os.LookupEnv(f.env); ok && v != "" {
+			if err := setValue(f.v, v); err != nil {
+				errs = append(errs, fmt.Sprintf("env %s: %v", f.env, err))
+			}
+		}
+	}
+	for _, s := range flagged {
+		if err := setValue(s.f.v, s.v); err != nil {
+			errs = append(errs, fmt.Sprintf("flag -%s: %v", s.f.path, err))
+		}
+	}
+
+	var verrs Errors
+	if err := cfg.Validate(); errors.As(err, &verrs) {
+		errs = append(errs, verrs...)
+	}
+	if len(errs) > 0 {
+		return cfg, rest, errs
+	}
+	return cfg, rest, nil
+}
+
+// loadFile: key yang tidak dikenal ditolak (typo di file tidak boleh diam-diam diabaikan).
+func loadFile(cfg *Config, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// field: satu nilai leaf Config (bukan struct section).
+type field struct {
+	path string // path yaml bertitik, mis. "api.http_addr" (juga nama flag)
+	env  string
+	v    reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func leaves(cfg *Config) []field {
+	var out []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			key := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if key == "" || key == "-" {
+				continue
+			}
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if sf.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key)
+				continue
+			}
+			out = append(out, field{path: key, env: sf.Tag.Get("env"), v: v.Field(i)})
+		}
+	}
+	walk(reflect.ValueOf(cfg).Elem(), "")
+	return out
+}
+
+// setValue: string (env/flag) ke tipe field. []string dari CSV, map[string]bool dari
+// "a=true,b=false" ("a" saja = true).
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.Bool {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		m := map[string]bool{}
+		for _, kv := range splitCSV(s) {
+			k, val, ok := strings.Cut(kv, "=")
+			b := true
+			if ok {
+				var err error
+				if b, err = strconv.ParseBool(strings.TrimSpace(val)); err != nil {
+					return fmt.Errorf("%s: %w", k, err)
+				}
+			}
+			m[strings.TrimSpace(k)] = b
+		}
+		v.Set(reflect.ValueOf(m))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitCSV(s)))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func splitCSV(s string) []string {
+	parts := strings.Split(s, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if t := strings.TrimSpace(p); t != "" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// Command: subcommand bersama cmd/*. "config print" menulis config efektif (YAML, secret
+// di-redact) ke w. handled=false kalau rest kosong (jalankan service seperti biasa).
+func Command(w io.Writer, rest []string, cfg Config) (handled bool, err error) {
+	if len(rest) == 0 {
+		return false, nil
+	}
+	if len(rest) == 2 && rest[0] == "config" && rest[1] == "print" {
+		return true, Print(w, cfg)
+	}
+	return true, fmt.Errorf("unknown command %q (available: config print)", strings.Join(rest, " "))
+}
+
+// Print: config sebagai YAML dengan urutan field sama seperti struct; durasi ditulis "15m0s".
+func Print(w io.Writer, cfg Config) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(toNode(reflect.ValueOf(cfg))); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func toNode(v reflect.Value) *yaml.Node {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		var val *yaml.Node
+		switch {
+		case sf.Type.Kind() == reflect.Struct:
+			val = toNode(v.Field(i))
+		case sf.Type.Kind() == reflect.Map:
+			val = &yaml.Node{Kind: yaml.MappingNode}
+			keys := v.Field(i).MapKeys()
+			sort.Slice(keys, func(a, b int) bool { return keys[a].String() < keys[b].String() })
+			for _, k := range keys {
+				val.Content = append(val.Content, scalar(k.String()), scalar(fmt.Sprint(v.Field(i).MapIndex(k).Interface())))
+			}
+		case sf.Type.Kind() == reflect.Slice:
+			val = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for j := 0; j < v.Field(i).Len(); j++ {
+				s := fmt.Sprint(v.Field(i).Index(j).Interface())
+				if sf.Tag.Get("secret") == "true" {
+					s = redact(s)
+				}
+				val.Content = append(val.Content, scalar(s))
+			}
+		default:
+			s := fmt.Sprint(v.Field(i).Interface())
+			if sf.Tag.Get("secret") == "true" {
+				s = redact(s)
+			}
+			val = scalar(s)
+		}
+		n.Content = append(n.Content, scalar(key), val)
+	}
+	return n
+}
+
+func scalar(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: s}
+}
+
+// redact: URL dengan password -> hanya password yang diganti (host & db tetap kelihatan).
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+	}
+	return redacted
+}
+
+// MustLoad: Load + Command untuk func main. Config tidak valid -> semua error ke stderr, exit 2;
+// subcommand (config print) -> exit setelah selesai.
+func MustLoad(name string) Config {
+	cfg, rest, err := Load(name, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	handled, cerr := Command(os.Stdout, rest, cfg)
+	if cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if handled {
+		os.Exit(0)
+	}
+	return cfg
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minAdminTokenLen: token admin API yang lebih pendek ditolak (gampang ditebak).
+const minAdminTokenLen = 16
+
+// Validate: cek semua field, kembalikan Errors berisi setiap masalah (nil kalau valid).
+func (c Config) Validate() error {
+	var errs Errors
+	add := func(path, format string, args ...any) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+	required := func(path, v string) {
+		if strings.TrimSpace(v) == "" {
+			add(path, "required")
+		}
+	}
+	addr := func(path, v string) {
+		if _, _, err := net.SplitHostPort(v); err != nil {
+			add(path, "must be host:port, got %q", v)
+		}
+	}
+	positive := func(path string, v int64) {
+		if v <= 0 {
+			add(path, "must be > 0, got %d", v)
+		}
+	}
+	duration := func(path string, d time.Duration) {
+		if d <= 0 {
+			add(path, "must be > 0, got %s", d)
+		}
+	}
+	oneOf := func(path, v string, allowed ...string) {
+		if !slices.Contains(allowed, strings.ToLower(v)) {
+			add(path, "must be one of %s, got %q", strings.Join(allowed, " | "), v)
+		}
+	}
+
+	required("service_name", c.ServiceName)
+	if c.ReloadInterval < 0 {
+		add("reload_interval", "must be >= 0, got %s", c.ReloadInterval)
+	}
+	for name := range c.Features {
+		if strings.TrimSpace(name) == "" {
+			add("features", "empty feature name")
+		}
+	}
+
+	a := c.API
+	addr("api.http_addr", a.HTTPAddr)
+	addr("api.grpc_addr", a.GRPCAddr)
+	positive("api.max_items_per_order", int64(a.MaxItemsPerOrder))
+	positive("api.max_qty_per_line", int64(a.MaxQtyPerLine))
+	positive("api.max_body_bytes", a.MaxBodyBytes)
+	duration("api.quote_ttl", a.QuoteTTL)
+	duration("api.availability_cache_ttl", a.AvailabilityCacheTTL)
+	for i, t := range a.AdminTokens {
+		principal, token, ok := strings.Cut(t, ":")
+		if !ok || strings.TrimSpace(principal) == "" || len(token) < minAdminTokenLen {
+			add(fmt.Sprintf("api.admin_tokens[%d]", i), "must be principal:token with a token of at least %d chars", minAdminTokenLen)
+		}
+	}
+
+	inv := c.Inventory
+	required("inventory.consumer_group", inv.ConsumerGroup)
+	positive("inventory.workers", int64(inv.Workers))
+	addr("inventory.metrics_addr", inv.MetricsAddr)
+	duration("inventory.reconcile_interval", inv.ReconcileInterval)
+	oneOf("inventory.allocation_strategy", inv.AllocationStrategy, "single", "closest", "split")
+	if inv.RolloutAllocationStrategy != "" {
+		oneOf("inventory.rollout_allocation_strategy", inv.RolloutAllocationStrategy, "single", "closest", "split")
+	}
+	duration("inventory.hold_ttl", inv.HoldTTL)
+	duration("inventory.hold_max", inv.HoldMax)
+	if inv.HoldMax < inv.HoldTTL {
+		add("inventory.hold_max", "must be >= inventory.hold_ttl (%s), got %s", inv.HoldTTL, inv.HoldMax)
+	}
+	duration("inventory.hold_sweep_interval", inv.HoldSweepInterval)
+	positive("inventory.hold_sweep_batch", int64(inv.HoldSweepBatch))
+	positive("inventory.hot_stock_workers", int64(inv.HotStockWorkers))
+	duration("inventory.hot_stock_sync_interval", inv.HotStockSyncInterval)
+	duration("inventory.stock_alert_cooldown", inv.StockAlertCooldown)
+
+	k := c.Kafka
+	if len(k.Brokers) == 0 {
+		add("kafka.brokers", "required")
+	}
+	for _, b := range k.Brokers {
+		addr("kafka.brokers", b)
+	}
+	positive("kafka.producer_buffer", int64(k.ProducerBuffer))
+	positive("kafka.admin_producer_buffer", int64(k.AdminProducerBuffer))
+	seen := map[string]string{}
+	for _, f := range leaves(&c) {
+		if !strings.HasPrefix(f.path, "kafka.topics.") {
+			continue
+		}
+		name := f.v.String()
+		required(f.path, name)
+		if prev, ok := seen[name]; ok && name != "" {
+			add(f.path, "topic %q already used by %s", name, prev)
+		}
+		seen[name] = f.path
+	}
+
+	r := c.Redis
+	addr("redis.addr", r.Addr)
+	if r.DB < 0 {
+		add("redis.db", "must be >= 0, got %d", r.DB)
+	}
+	if r.PoolSize < 0 {
+		add("redis.pool_size", "must be >= 0, got %d", r.PoolSize)
+	}
+	duration("redis.dial_timeout", r.DialTimeout)
+	duration("redis.read_timeout", r.ReadTimeout)
+	duration("redis.write_timeout", r.WriteTimeout)
+	duration("redis.idempotency_ttl", r.IdempotencyTTL)
+	duration("redis.status_cache_ttl", r.StatusCacheTTL)
+	duration("redis.dedup_ttl", r.DedupTTL)
+	duration("redis.saga_ttl", r.SagaTTL)
+	duration("redis.hot_inflight_ttl", r.HotInflightTTL)
+
+	p := c.Postgres
+	required("postgres.dsn", p.DSN)
+	positive("postgres.max_conns", int64(p.MaxConns))
+	if p.MinConns < 0 || p.MinConns > p.MaxConns {
+		add("postgres.min_conns", "must be between 0 and postgres.max_conns (%d), got %d", p.MaxConns, p.MinConns)
+	}
+	duration("postgres.max_conn_lifetime", p.MaxConnLifetime)
+	duration("postgres.health_check_period", p.HealthCheckPeriod)
+	duration("postgres.connect_timeout", p.ConnectTimeout)
+
+	oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	oneOf("log.format", c.Log.Format, "json", "text")
+	if c.Log.SampleInitial < 0 {
+		add("log.sample_initial", "must be >= 0, got %d", c.Log.SampleInitial)
+	}
+	if c.Log.SampleThereafter < 0 {
+		add("log.sample_thereafter", "must be >= 0, got %d", c.Log.SampleThereafter)
+	}
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if strings.EqualFold(c.Tracing.Exporter, "otlp") {
+		addr("tracing.otlp_endpoint", c.Tracing.OTLPEndpoint)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio", "must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	h := c.Health
+	duration("health.check_timeout", h.CheckTimeout)
+	duration("health.cache_ttl", h.CacheTTL)
+	positive("health.max_consumer_lag", h.MaxConsumerLag)
+	if h.MaxInboxRatio <= 0 || h.MaxInboxRatio > 1 {
+		add("health.max_inbox_ratio", "must be in (0, 1], got %g", h.MaxInboxRatio)
+	}
+	if h.ShutdownDrainDelay < 0 {
+		add("health.shutdown_drain_delay", "must be >= 0, got %s", h.ShutdownDrainDelay)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
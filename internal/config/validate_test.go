@@ -0,0 +1,93 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Validate tidak berhenti di error pertama: setiap field yang salah muncul di Errors.
+func TestValidateListsEveryError(t *testing.T) {
+	c := Default()
+	c.ServiceName = " "
+	c.API.HTTPAddr = "8080"
+	c.API.MaxItemsPerOrder = 0
+	c.API.AdminTokens = []string{"ops:short", "no-principal-token-0123456789"}
+	c.Inventory.AllocationStrategy = "nearest"
+	c.Inventory.HoldTTL, c.Inventory.HoldMax = time.Hour, time.Minute
+	c.Kafka.Brokers = nil
+	c.Kafka.Topics.StockRejected = c.Kafka.Topics.StockReserved
+	c.Postgres.MinConns = c.Postgres.MaxConns + 1
+	c.Log.Level = "verbose"
+	c.Tracing.SampleRatio = 2
+	c.Health.MaxInboxRatio = 0
+
+	var errs Errors
+	if err := c.Validate(); !errors.As(err, &errs) {
+		t.Fatalf("got %v, want Errors", err)
+	}
+	want := []string{
+		"service_name",
+		"api.http_addr",
+		"api.max_items_per_order",
+		"api.admin_tokens[0]",
+		"api.admin_tokens[1]",
+		"inventory.allocation_strategy",
+		"inventory.hold_max",
+		"kafka.brokers",
+		"kafka.topics.stock_rejected",
+		"postgres.min_conns",
+		"log.level",
+		"tracing.sample_ratio",
+		"health.max_inbox_ratio",
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, strings.SplitN(e, ": ", 2)[0])
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("error paths:\n got %v\nwant %v", got, want)
+	}
+	if msg := errs.Error(); strings.Count(msg, "\n  - ") != len(want) {
+		t.Errorf("Error() should list every problem on its own line:\n%s", msg)
+	}
+}
+
+// Load menggabungkan error parse env/flag dengan error Validate.
+func TestLoadCollectsParseAndValidationErrors(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("QUOTE_TTL", "soon")
+	t.Setenv("LOG_LEVEL", "loud")
+	_, _, err := Load("test", []string{"-api.max_qty_per_line=abc", "-redis.db=-1"})
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %v, want Errors", err)
+	}
+	for _, want := range []string{"env QUOTE_TTL", "flag -api.max_qty_per_line", "log.level", "redis.db"} {
+		if !slices.ContainsFunc(errs, func(e string) bool { return strings.HasPrefix(e, want) }) {
+			t.Errorf("missing %q in %v", want, errs)
+		}
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	c := Default()
+	c.API.AdminTokens = []string{"ops:ops-token-0123456789"}
+	var buf bytes.Buffer
+	if err := Print(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "ops-token-0123456789") || strings.Contains(out, ":secret@") {
+		t.Errorf("secret leaked:\n%s", out)
+	}
+}
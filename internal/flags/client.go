@@ -0,0 +1,230 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultMaxAge = 30 * time.Second
+	// field penanda di hash KeyFlags: cache terisi walau belum ada flag sama sekali
+	loadedField = "_loaded"
+)
+
+// errFlagsChanged: versi flag berubah selama load, cache tidak diisi.
+var errFlagsChanged = errors.New("feature flags changed")
+
+// Client: evaluasi flag + admin (Set/Delete menulis Postgres lalu invalidasi cache). Urutan baca:
+// snapshot memori (maks MaxAge) -> hash Redis -> Postgres (lalu isi Redis). Run harus jalan supaya
+// perubahan dari proses lain langsung terlihat; tanpa Run perubahan baru terlihat setelah MaxAge.
+type Client struct {
+	Repo   *orders.FlagRepo
+	Redis  *redis.Client
+	MaxAge time.Duration // umur snapshot memori; 0 -> 30s
+
+	mu       sync.Mutex
+	snap     map[string]orders.Flag // nil = perlu load
+	loadedAt time.Time
+}
+
+// Enabled: def dipakai kalau flag belum didefinisikan atau definisi tidak bisa dibaca.
+func (c *Client) Enabled(ctx context.Context, key string, sub Subject, def bool) bool {
+	return c.Evaluate(ctx, key, sub, def).Enabled
+}
+
+func (c *Client) Evaluate(ctx context.Context, key string, sub Subject, def bool) Evaluation {
+	all, err := c.flags(ctx)
+	var ev Evaluation
+	if err != nil {
+		logx.FromContext(ctx).Warn("feature flags unavailable, using default", "flag", key, "default", def, "err", err)
+		ev = Evaluation{Flag: key, Enabled: def, Reason: ReasonError, Bucket: -1}
+	} else if f, ok := all[key]; ok {
+		ev = Evaluate(key, &f, sub, def)
+	} else {
+		ev = Evaluate(key, nil, sub, def)
+	}
+	result := "off"
+	if ev.Enabled {
+		result = "on"
+	}
+	metrics.FlagEvaluations.WithLabelValues(key, result).Inc()
+	return ev
+}
+
+// flags: snapshot semua flag. Kalau reload gagal, snapshot lama (kalau ada) tetap dipakai.
+func (c *Client) flags(ctx context.Context) (map[string]orders.Flag, error) {
+	maxAge := c.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultMaxAge
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.snap != nil && time.Since(c.loadedAt) < maxAge {
+		return c.snap, nil
+	}
+	snap, err := c.load(ctx)
+	if err != nil {
+		if c.snap != nil {
+			slog.Warn("feature flags reload failed, keeping stale snapshot", "err", err)
+			return c.snap, nil
+		}
+		return nil, err
+	}
+	c.snap, c.loadedAt = snap, time.Now()
+	return snap, nil
+}
+
+func (c *Client) load(ctx context.Context) (map[string]orders.Flag, error) {
+	h, err := c.Redis.HGetAll(ctx, redisx.KeyFlags).Result()
+	if err != nil {
+		slog.Warn("feature flags cache read failed, falling back to postgres", "err", err)
+	}
+	if _, ok := h[loadedField]; ok {
+		out := make(map[string]orders.Flag, len(h))
+		for k, v := range h {
+			if k == loadedField {
+				continue
+			}
+			var f orders.Flag
+			if err := json.Unmarshal([]byte(v), &f); err != nil {
+				slog.Warn("feature flag cache entry invalid", "flag", k, "err", err)
+				continue
+			}
+			out[k] = f
+		}
+		metrics.CacheRequests.WithLabelValues("feature_flags", "hit").Inc()
+		return out, nil
+	}
+	metrics.CacheRequests.WithLabelValues("feature_flags", "miss").Inc()
+
+	// versi dibaca sebelum Postgres: kalau ada Set/Delete di antaranya, hasil List bisa sudah basi
+	ver, verErr := c.Redis.Get(ctx, redisx.KeyFlagsVersion).Result()
+	if errors.Is(verErr, redis.Nil) {
+		ver, verErr = "", nil
+	}
+	list, err := c.Repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]orders.Flag, len(list))
+	vals := []any{loadedField, "1"}
+	for _, f := range list {
+		out[f.Key] = f
+		b, _ := json.Marshal(f)
+		vals = append(vals, f.Key, b)
+	}
+	if verErr != nil {
+		return out, nil // versi tidak diketahui -> jangan isi cache
+	}
+	// isi cache hanya kalau versi belum berubah sejak sebelum List (WATCH: invalidate di tengah
+	// MULTI/EXEC membatalkan tx); gagal = tidak apa-apa, load berikutnya ke Postgres lagi
+	err = c.Redis.Watch(ctx, func(tx *redis.Tx) error {
+		cur, err := tx.Get(ctx, redisx.KeyFlagsVersion).Result()
+		if errors.Is(err, redis.Nil) {
+			cur, err = "", nil
+		}
+		if err != nil {
+			return err
+		}
+		if cur != ver {
+			return errFlagsChanged
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, redisx.KeyFlags)
+			pipe.HSet(ctx, redisx.KeyFlags, vals...)
+			pipe.Expire(ctx, redisx.KeyFlags, redisx.TTLFlags.Get())
+			return nil
+		})
+		return err
+	}, redisx.KeyFlagsVersion)
+	switch {
+	case errors.Is(err, errFlagsChanged), errors.Is(err, redis.TxFailedErr):
+		slog.Debug("feature flags changed while loading, cache not filled")
+	case err != nil:
+		slog.Warn("feature flags cache write failed", "err", err)
+	}
+	return out, nil
+}
+
+// Run: subscribe channel invalidasi sampai ctx selesai. Setiap pesan membuang snapshot memori.
+func (c *Client) Run(ctx context.Context) {
+	sub := c.Redis.Subscribe(ctx, redisx.ChannelFlags)
+	defer sub.Close()
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			slog.Debug("feature flag changed", "flag", m.Payload)
+			c.drop()
+		}
+	}
+}
+
+func (c *Client) drop() {
+	c.mu.Lock()
+	c.snap = nil
+	c.mu.Unlock()
+}
+
+// invalidate: naikkan versi + hapus cache Redis (satu tx, supaya load yang sedang berjalan tidak
+// mengisi ulang dengan data lama) lalu beri tahu semua proses (termasuk proses ini).
+func (c *Client) invalidate(ctx context.Context, key string) {
+	c.drop()
+	pipe := c.Redis.TxPipeline()
+	pipe.Incr(ctx, redisx.KeyFlagsVersion)
+	pipe.Del(ctx, redisx.KeyFlags)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.Warn("feature flags cache delete failed", "flag", key, "err", err)
+	}
+	if err := c.Redis.Publish(ctx, redisx.ChannelFlags, key).Err(); err != nil {
+		slog.Warn("feature flag invalidation publish failed", "flag", key, "err", err)
+	}
+}
+
+// List & Get admin: langsung dari Postgres (bukan cache).
+func (c *Client) List(ctx context.Context) ([]orders.Flag, error) { return c.Repo.List(ctx) }
+
+func (c *Client) Get(ctx context.Context, key string) (orders.Flag, error) {
+	return c.Repo.Get(ctx, key)
+}
+
+// Set: buat / ubah flag (sudah divalidasi caller), log nilai lama & baru.
+func (c *Client) Set(ctx context.Context, in orders.Flag) (orders.Flag, error) {
+	old, err := c.Repo.Get(ctx, in.Key)
+	if err != nil && !errors.Is(err, orders.ErrFlagNotFound) {
+		return orders.Flag{}, err
+	}
+	f, err := c.Repo.Upsert(ctx, in)
+	if err != nil {
+		return orders.Flag{}, err
+	}
+	c.invalidate(ctx, f.Key)
+	slog.Info("feature flag updated", "flag", f.Key, "kind", f.Kind,
+		"old_enabled", old.Enabled, "enabled", f.Enabled, "old_percent", old.Percent, "percent", f.Percent,
+		"target_by", f.TargetBy)
+	return f, nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	if err := c.Repo.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.invalidate(ctx, key)
+	slog.Info("feature flag deleted", "flag", key)
+	return nil
+}
@@ -0,0 +1,108 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+func TestEvaluate(t *testing.T) {
+	pct := func(percent int, targetBy string) *orders.Flag {
+		return &orders.Flag{Kind: orders.FlagPercentage, Enabled: true, Percent: percent, TargetBy: targetBy}
+	}
+	user := Subject{UserID: "user-1", ExternalID: "EXT-42"} // bucket orders.sku: user-1 = 38, EXT-42 = 95
+
+	for _, tc := range []struct {
+		name   string
+		f      *orders.Flag
+		sub    Subject
+		def    bool
+		want   bool
+		reason string
+		bucket int
+	}{
+		{"tidak ada, default on", nil, user, true, true, ReasonNotFound, -1},
+		{"tidak ada, default off", nil, user, false, false, ReasonNotFound, -1},
+		{"disabled menang atas default", &orders.Flag{Kind: orders.FlagBoolean}, user, true, false, ReasonDisabled, -1},
+		{"percentage disabled", &orders.Flag{Kind: orders.FlagPercentage, Percent: 100}, user, true, false, ReasonDisabled, -1},
+		{"boolean on", &orders.Flag{Kind: orders.FlagBoolean, Enabled: true}, Subject{}, false, true, ReasonEnabled, -1},
+		{"bucket di bawah percent", pct(39, orders.FlagTargetUserID), user, false, true, ReasonInRollout, 38},
+		{"bucket sama dengan percent", pct(38, orders.FlagTargetUserID), user, true, false, ReasonOutOfRollout, 38},
+		{"0% selalu off", pct(0, orders.FlagTargetUserID), user, true, false, ReasonOutOfRollout, 38},
+		{"100% selalu on", pct(100, orders.FlagTargetExternalID), user, false, true, ReasonInRollout, 95},
+		{"target external_id", pct(90, orders.FlagTargetExternalID), user, false, false, ReasonOutOfRollout, 95},
+		{"target kosong", pct(100, orders.FlagTargetUserID), Subject{ExternalID: "EXT-42"}, true, false, ReasonMissingTarget, -1},
+	} {
+		ev := Evaluate(OrdersSKU, tc.f, tc.sub, tc.def)
+		if ev.Enabled != tc.want || ev.Reason != tc.reason || ev.Bucket != tc.bucket || ev.Flag != OrdersSKU {
+			t.Errorf("%s: %+v, want enabled=%v reason=%s bucket=%d", tc.name, ev, tc.want, tc.reason, tc.bucket)
+		}
+	}
+}
+
+// Nilai bucket dipakai bersama cmd/api & cmd/inventory (dan tersimpan implisit di rollout yang sedang
+// berjalan): perubahan hash memindahkan user antar bucket.
+func TestBucketStable(t *testing.T) {
+	for _, tc := range []struct {
+		key, id string
+		want    int
+	}{
+		{OrdersSKU, "user-1", 38},
+		{AllocationRollout, "user-1", 42},
+		{OrdersSKU, "EXT-42", 95},
+	} {
+		if got := Bucket(tc.key, tc.id); got != tc.want {
+			t.Errorf("Bucket(%s, %s) = %d, want %d", tc.key, tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestBucketDistribution(t *testing.T) {
+	const n = 10_000
+	var counts [100]int
+	differ := 0
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		b := Bucket(OrdersSKU, id)
+		if b < 0 || b > 99 {
+			t.Fatalf("bucket %d out of range", b)
+		}
+		counts[b]++
+		if b != Bucket(AllocationRollout, id) {
+			differ++
+		}
+	}
+	// rollout 30%: kira-kira 30% user on
+	on := 0
+	for b := 0; b < 30; b++ {
+		on += counts[b]
+	}
+	if on < n*27/100 || on > n*33/100 {
+		t.Errorf("30%% rollout enabled %d of %d users", on, n)
+	}
+	// tiap flag memilih irisan user sendiri
+	if differ < n*9/10 {
+		t.Errorf("only %d of %d users land in a different bucket for another flag", differ, n)
+	}
+}
+
+// Menaikkan percent hanya menambah user: yang sudah on tetap on.
+func TestRolloutIsMonotonic(t *testing.T) {
+	f := &orders.Flag{Kind: orders.FlagPercentage, Enabled: true, TargetBy: orders.FlagTargetUserID}
+	for i := 0; i < 1000; i++ {
+		sub := Subject{UserID: fmt.Sprintf("user-%d", i)}
+		wasOn := false
+		for p := 0; p <= 100; p += 5 {
+			f.Percent = p
+			on := Evaluate(OrdersSKU, f, sub, false).Enabled
+			if wasOn && !on {
+				t.Fatalf("%s dropped out of rollout at %d%%", sub.UserID, p)
+			}
+			wasOn = on
+		}
+		if !wasOn {
+			t.Fatalf("%s not enabled at 100%%", sub.UserID)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package grpcx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	ordersv1 "github.com/ariefcatur/go-realtime-orders.git/api/orders/v1"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/ordersvc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OrderServer: implementasi ordersv1.OrderServiceServer di atas ordersvc.Service.
+type OrderServer struct {
+	ordersv1.UnimplementedOrderServiceServer
+
+	Orders *ordersvc.Service
+	// WatchInterval: jarak polling status untuk WatchOrder
+	WatchInterval time.Duration
+}
+
+func NewServer(s *OrderServer) *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(logUnary), grpc.ChainStreamInterceptor(logStream))
+	ordersv1.RegisterOrderServiceServer(srv, s)
+	return srv
+}
+
+func (s *OrderServer) CreateOrder(ctx context.Context, req *ordersv1.CreateOrderRequest) (*ordersv1.CreateOrderResponse, error) {
+	items := make([]orders.ItemInput, 0, len(req.GetItems()))
+	for _, it := range req.GetItems() {
+		items = append(items, orders.ItemInput{
+			ProductID: it.GetProductId(), Qty: int(it.GetQty()), ExpectedPriceCents: optInt(it.ExpectedPriceCents),
+		})
+	}
+	res, err := s.Orders.PlaceOrder(ctx, ordersvc.PlaceOrderInput{
+		ExternalID:       req.GetExternalId(),
+		UserID:           req.GetUserId(),
+		Region:           req.GetRegion(),
+		Items:            items,
+		TraceID:          traceID(ctx),
+		FulfilmentPolicy: orders.FulfilmentPolicy(req.GetFulfilmentPolicy()),
+		CouponCode:       req.GetCouponCode(),
+		Currency:         req.GetCurrency(),
+		QuoteID:          req.GetQuoteId(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCreateResp(res), nil
+}
+
+func (s *OrderServer) CreateOrderBySKU(ctx context.Context, req *ordersv1.CreateOrderBySKURequest) (*ordersv1.CreateOrderResponse, error) {
+	items := make([]orders.ItemInputSKU, 0, len(req.GetItems()))
+	for _, it := range req.GetItems() {
+		items = append(items, orders.ItemInputSKU{
+			SKU: it.GetSku(), Qty: int(it.GetQty()), ExpectedPriceCents: optInt(it.ExpectedPriceCents),
+		})
+	}
+	res, err := s.Orders.PlaceOrderBySKU(ctx, ordersvc.PlaceOrderBySKUInput{
+		ExternalID:       req.GetExternalId(),
+		UserID:           req.GetUserId(),
+		Region:           req.GetRegion(),
+		Items:            items,
+		TraceID:          traceID(ctx),
+		FulfilmentPolicy: orders.FulfilmentPolicy(req.GetFulfilmentPolicy()),
+		CouponCode:       req.GetCouponCode(),
+		Currency:         req.GetCurrency(),
+		QuoteID:          req.GetQuoteId(),
+	})
+	if err != nil {
+		// sama seperti HTTP: error repo di jalur SKU dianggap request salah (sku tidak ada, dst)
+		return nil, toStatus(err)
+	}
+	return toCreateResp(res), nil
+}
+
+func (s *OrderServer) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.GetOrderResponse, error) {
+	if req.GetOrderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing order_id")
+	}
+	v, err := s.Orders.GetOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ordersv1.GetOrderResponse{OrderId: v.OrderID, Status: string(v.Status)}, nil
+}
+
+func (s *OrderServer) ListProducts(ctx context.Context, _ *ordersv1.ListProductsRequest) (*ordersv1.ListProductsResponse, error) {
+	ps, err := s.Orders.ListProducts(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &ordersv1.ListProductsResponse{Products: make([]*ordersv1.Product, 0, len(ps))}
+	for _, p := range ps {
+		out.Products = append(out.Products, &ordersv1.Product{
+			Id:          p.ID,
+			Sku:         p.SKU,
+			Name:        p.Name,
+			Stock:       int32(p.Stock),
+			PriceCents:  int64(p.PriceCents),
+			CreatedAt:   timestamppb.New(p.CreatedAt),
+			UpdatedAt:   timestamppb.New(p.UpdatedAt),
+			Currency:    p.Currency,
+			TaxCategory: p.TaxCategory,
+		})
+	}
+	return out, nil
+}
+
+func (s *OrderServer) CreateQuote(ctx context.Context, req *ordersv1.CreateQuoteRequest) (*ordersv1.Quote, error) {
+	items := make([]orders.ItemInput, 0, len(req.GetItems()))
+	for _, it := range req.GetItems() {
+		items = append(items, orders.ItemInput{ProductID: it.GetProductId(), Qty: int(it.GetQty())})
+	}
+	q, err := s.Orders.CreateQuote(ctx, req.GetUserId(), items)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	out := &ordersv1.Quote{
+		QuoteId:   q.ID,
+		UserId:    q.UserID,
+		Currency:  q.Currency,
+		Items:     make([]*ordersv1.QuoteItem, 0, len(q.Items)),
+		ExpiresAt: timestamppb.New(q.ExpiresAt),
+	}
+	for _, it := range q.Items {
+		out.Items = append(out.Items, &ordersv1.QuoteItem{
+			ProductId: it.ProductID, Sku: it.SKU, Qty: int32(it.Qty), PriceCents: int64(it.PriceCents),
+		})
+	}
+	return out, nil
+}
+
+func (s *OrderServer) WatchOrder(req *ordersv1.WatchOrderRequest, stream grpc.ServerStreamingServer[ordersv1.OrderStatusUpdate]) error {
+	if req.GetOrderId() == "" {
+		return status.Error(codes.InvalidArgument, "missing order_id")
+	}
+	err := s.Orders.WatchStatus(stream.Context(), req.GetOrderId(), s.WatchInterval, func(st orders.Status) error {
+		return stream.Send(&ordersv1.OrderStatusUpdate{
+			OrderId:    req.GetOrderId(),
+			Status:     string(st),
+			ObservedAt: timestamppb.Now(),
+		})
+	})
+	if err != nil {
+		return toStatus(err)
+	}
+	return nil
+}
+
+func toCreateResp(res ordersvc.PlaceResult) *ordersv1.CreateOrderResponse {
+	out := &ordersv1.CreateOrderResponse{
+		OrderId:       res.OrderID,
+		TotalCents:    int64(res.TotalCents),
+		Idempotent:    res.Idempotent,
+		DiscountCents: int64(res.DiscountCents),
+		Items:         make([]*ordersv1.PricedItem, 0, len(res.Items)),
+		Currency:      res.Currency,
+		TaxCents:      int64(res.TaxCents),
+		Taxes:         make([]*ordersv1.TaxLine, 0, len(res.Taxes)),
+	}
+	for _, it := range res.Items {
+		out.Items = append(out.Items, &ordersv1.PricedItem{
+			ProductId:     it.ProductID,
+			Qty:           int32(it.Qty),
+			PriceCents:    int64(it.PriceCents),
+			DiscountCents: int64(it.DiscountCents),
+			FinalCents:    int64(it.FinalCents),
+			TaxCents:      int64(it.TaxCents),
+		})
+	}
+	for _, t := range res.Taxes {
+		out.Taxes = append(out.Taxes, &ordersv1.TaxLine{
+			ProductId:    t.ProductID,
+			Name:         t.Name,
+			RateBp:       int32(t.RateBP),
+			TaxableCents: int64(t.TaxableCents),
+			TaxCents:     int64(t.TaxCents),
+		})
+	}
+	return out
+}
+
+func optInt(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+// logUnary: padanan logRequests di httpx; logger (request_id, grpc_method) di context handler.
+func logUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx, log := logx.With(ctx, "request_id", traceID(ctx), "grpc_method", info.FullMethod)
+	resp, err := h(ctx, req)
+	logCall(ctx, log, err, start)
+	return resp, err
+}
+
+// logStream: WatchOrder; log satu kali saat stream selesai.
+func logStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+	start := time.Now()
+	ctx, log := logx.With(ss.Context(), "request_id", traceID(ss.Context()), "grpc_method", info.FullMethod)
+	err := h(srv, &loggedStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, log, err, start)
+	return err
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context { return s.ctx }
+
+func logCall(ctx context.Context, log *slog.Logger, err error, start time.Time) {
+	code := status.Code(err)
+	lvl := slog.LevelInfo
+	switch code {
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		lvl = slog.LevelError
+	}
+	attrs := []slog.Attr{slog.String("code", code.String()), slog.Int64("duration_ms", time.Since(start).Milliseconds())}
+	if err != nil {
+		attrs = append(attrs, slog.String("err", err.Error()))
+	}
+	log.LogAttrs(ctx, lvl, "grpc call", attrs...)
+}
+
+// traceID: padanan header X-Request-Id di HTTP.
+func traceID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("x-request-id"); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// toStatus: padanan writeOrderError di HTTP (ordersvc.Classify); hanya pembatalan ctx yang khusus gRPC.
+func toStatus(err error) error {
+	switch ordersvc.Classify(err) {
+	case ordersvc.ClassInvalid:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case ordersvc.ClassPriceChanged:
+		var pc *orders.PriceChangedError
+		if errors.As(err, &pc) {
+			return priceChangedStatus(pc)
+		}
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case ordersvc.ClassBusy:
+		return status.Error(codes.Unavailable, err.Error())
+	case ordersvc.ClassDisabled:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case ordersvc.ClassRejected:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case ordersvc.ClassNotFound:
+		return status.Error(codes.NotFound, "not found")
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// priceChangedStatus: padanan PriceChangedResp (409) di HTTP. ErrorInfo.Reason = PRICE_CHANGED, satu
+// PreconditionFailure violation per line yang berubah; metadata ErrorInfo berisi angka per line
+// (<product_id>.expected_cents / <product_id>.actual_cents) supaya client tidak perlu parsing teks.
+func priceChangedStatus(pc *orders.PriceChangedError) error {
+	st := status.New(codes.FailedPrecondition, pc.Error())
+	info := &errdetails.ErrorInfo{Reason: orders.ErrPriceChanged.Error(), Domain: "orders.v1", Metadata: map[string]string{}}
+	pf := &errdetails.PreconditionFailure{}
+	for _, c := range pc.Changes {
+		info.Metadata[c.ProductID+".expected_cents"] = fmt.Sprint(c.ExpectedCents)
+		info.Metadata[c.ProductID+".actual_cents"] = fmt.Sprint(c.ActualCents)
+		pf.Violations = append(pf.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        orders.ErrPriceChanged.Error(),
+			Subject:     c.ProductID,
+			Description: fmt.Sprintf("sku %s: expected %d got %d", c.SKU, c.ExpectedCents, c.ActualCents),
+		})
+	}
+	withDetails, err := st.WithDetails(info, pf)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
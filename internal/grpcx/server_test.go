@@ -0,0 +1,43 @@
+package grpcx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PRICE_CHANGED lewat gRPC membawa daftar line yang berubah, sama seperti body 409 di HTTP.
+func TestToStatusPriceChangedDetails(t *testing.T) {
+	pc := &orders.PriceChangedError{Changes: []orders.PriceChange{
+		{ProductID: "p-1", SKU: "SKU-A", ExpectedCents: 100, ActualCents: 120},
+		{ProductID: "p-2", SKU: "SKU-B", ExpectedCents: 50, ActualCents: 40},
+	}}
+	st := status.Convert(toStatus(fmt.Errorf("create order: %w", pc)))
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("code %s, want FailedPrecondition", st.Code())
+	}
+
+	var info *errdetails.ErrorInfo
+	var pf *errdetails.PreconditionFailure
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.PreconditionFailure:
+			pf = d
+		}
+	}
+	if info == nil || info.GetReason() != "PRICE_CHANGED" {
+		t.Fatalf("missing ErrorInfo PRICE_CHANGED: %v", st.Details())
+	}
+	if info.GetMetadata()["p-1.actual_cents"] != "120" || info.GetMetadata()["p-2.expected_cents"] != "50" {
+		t.Fatalf("unexpected metadata %v", info.GetMetadata())
+	}
+	if pf == nil || len(pf.GetViolations()) != 2 || pf.GetViolations()[1].GetSubject() != "p-2" {
+		t.Fatalf("unexpected violations %v", pf)
+	}
+}
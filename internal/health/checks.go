@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+)
+
+// Postgres: ping lewat pool (ambil koneksi + round trip).
+func Postgres(pool *pgxpool.Pool) CheckFunc {
+	return func(ctx context.Context) error { return pool.Ping(ctx) }
+}
+
+// Redis: PING.
+func Redis(c *redis.Client) CheckFunc {
+	return func(ctx context.Context) error { return c.Ping(ctx).Err() }
+}
+
+// Kafka: fetch metadata broker dari broker pertama yang bisa di-dial.
+func Kafka(brokers []string) CheckFunc {
+	return func(ctx context.Context) error {
+		if len(brokers) == 0 {
+			return errors.New("no kafka brokers configured")
+		}
+		var errs []error
+		for _, addr := range brokers {
+			conn, err := kafka.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if dl, ok := ctx.Deadline(); ok {
+				_ = conn.SetDeadline(dl)
+			}
+			_, err = conn.Brokers()
+			_ = conn.Close()
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// Lagger: kafkax.Consumer.
+type Lagger interface{ Lag() int64 }
+
+// ConsumerLag: gagal kalau total lag consumer > max.
+func ConsumerLag(c Lagger, max int64) CheckFunc {
+	return func(context.Context) error {
+		if lag := c.Lag(); lag > max {
+			return fmt.Errorf("consumer lag %d > %d", lag, max)
+		}
+		return nil
+	}
+}
+
+// Saturater: kafkax.Producer.
+type Saturater interface{ Saturation() float64 }
+
+// ProducerInbox: gagal kalau inbox producer terisi >= max (0..1).
+func ProducerInbox(p Saturater, max float64) CheckFunc {
+	return func(context.Context) error {
+		if s := p.Saturation(); s >= max {
+			return fmt.Errorf("producer inbox %.0f%% full", s*100)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckFunc: nil = sehat. ctx sudah diberi timeout per check.
+type CheckFunc func(ctx context.Context) error
+
+const (
+	StatusOK       = "ok"
+	StatusFail     = "fail"
+	StatusDraining = "draining"
+
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = 2 * time.Second
+)
+
+// Result: hasil satu check (di-cache selama Registry.CacheTTL).
+type Result struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report: body JSON /readyz.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	timeout time.Duration
+
+	mu   sync.Mutex // satu eksekusi per check; request paralel menunggu & pakai hasil cache
+	last Result
+}
+
+// Registry: kumpulan check readiness. Liveness tidak menjalankan check dependency (restart
+// proses tidak memperbaiki Postgres yang mati); readiness gagal kalau ada check gagal atau
+// proses sedang draining.
+type Registry struct {
+	Timeout  time.Duration // default per check; <= 0 -> 2s
+	CacheTTL time.Duration // umur hasil check; <= 0 -> 2s
+
+	mu       sync.RWMutex
+	checks   []*check
+	draining atomic.Bool
+}
+
+// Register: timeout <= 0 pakai Registry.Timeout.
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &check{name: name, fn: fn, timeout: timeout})
+}
+
+// SetDraining: dipanggil saat graceful shutdown dimulai; /readyz langsung 503 supaya load balancer
+// berhenti mengirim traffic sebelum server ditutup.
+func (r *Registry) SetDraining() { r.draining.Store(true) }
+
+func (r *Registry) Draining() bool { return r.draining.Load() }
+
+// Run: jalankan semua check paralel (atau ambil dari cache).
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.Draining() {
+		return Report{Status: StatusDraining}
+	}
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks...)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, c := range checks {
+		rep.Checks[c.name] = results[i]
+		if results[i].Status != StatusOK {
+			rep.Status = StatusFail
+		}
+	}
+	return rep
+}
+
+func (r *Registry) run(ctx context.Context, c *check) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl := r.CacheTTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < ttl {
+		return c.last
+	}
+
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = r.Timeout
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	cctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.fn(cctx)
+	res := Result{Status: StatusOK, DurationMs: time.Since(start).Milliseconds(), CheckedAt: start.UTC()}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	c.last = res
+	return res
+}
+
+// LiveHandler: 200 selama proses bisa melayani HTTP.
+func (r *Registry) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK})
+	})
+}
+
+// ReadyHandler: 200 kalau semua check ok, 503 kalau ada yang gagal / draining.
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Run(req.Context())
+		code := http.StatusOK
+		if rep.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeReport(w, code, rep)
+	})
+}
+
+func writeReport(w http.ResponseWriter, code int, rep Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,75 @@
+package httpx
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AdminAuth: bearer token untuk semua route /admin/*. Setiap token milik satu principal, dan principal
+// itu yang dicatat sebagai actor (ledger stok, audit katalog), bukan header dari client.
+// nil / tanpa token = /admin/* selalu ditolak.
+type AdminAuth struct {
+	tokens []adminToken
+}
+
+type adminToken struct {
+	principal string
+	token     []byte
+}
+
+type principalKey struct{}
+
+// NewAdminAuth: entries format "principal:token" (config api.admin_tokens).
+func NewAdminAuth(entries []string) (*AdminAuth, error) {
+	a := &AdminAuth{}
+	for _, e := range entries {
+		principal, token, ok := strings.Cut(e, ":")
+		if !ok || principal == "" || token == "" {
+			return nil, fmt.Errorf("admin token must be principal:token")
+		}
+		a.tokens = append(a.tokens, adminToken{principal: principal, token: []byte(token)})
+	}
+	return a, nil
+}
+
+// Middleware: dipasang di root router (lihat API.Router); path di luar /admin/ tidak disentuh.
+func (a *AdminAuth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/admin" && !strings.HasPrefix(r.URL.Path, "/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		principal, ok := a.principal(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	})
+}
+
+func (a *AdminAuth) principal(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if a == nil || !ok || token == "" {
+		return "", false
+	}
+	// bandingkan dengan semua token (constant time), jangan berhenti di yang pertama cocok
+	found := ""
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t.token, []byte(token)) == 1 {
+			found = t.principal
+		}
+	}
+	return found, found != ""
+}
+
+// actorOf: principal admin yang melakukan perubahan (dicatat di ledger / event). Hanya dipanggil
+// handler /admin/*, jadi selalu sudah lewat AdminAuth.
+func actorOf(r *http.Request) string {
+	p, _ := r.Context().Value(principalKey{}).(string)
+	return p
+}
@@ -0,0 +1,145 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/catalog"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// CatalogHandler: admin API produk (create, update, archive, get by SKU).
+type CatalogHandler struct {
+	Catalog      *catalog.Service
+	MaxBodyBytes int64
+}
+
+// CreateProductReq: currency / tax_category kosong = default (USD / STANDARD).
+type CreateProductReq struct {
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	PriceCents  int    `json:"price_cents"`
+	Stock       int    `json:"stock"`
+	Currency    string `json:"currency,omitempty"`
+	TaxCategory string `json:"tax_category,omitempty"`
+}
+
+// UpdateProductReq: version wajib (optimistic concurrency), field lain opsional.
+type UpdateProductReq struct {
+	Version     int     `json:"version"`
+	Name        *string `json:"name,omitempty"`
+	PriceCents  *int    `json:"price_cents,omitempty"`
+	Hot         *bool   `json:"hot,omitempty"` // flash sale
+	TaxCategory *string `json:"tax_category,omitempty"`
+}
+
+type ArchiveProductReq struct {
+	Version int `json:"version"`
+}
+
+func (h *CatalogHandler) Register(r *chi.Mux) {
+	r.Post("/admin/products", h.createProduct)
+	r.Get("/admin/products/sku/{sku}", h.getProductBySKU)
+	r.Patch("/admin/products/{id}", h.updateProduct)
+	r.Post("/admin/products/{id}/archive", h.archiveProduct)
+}
+
+func (h *CatalogHandler) createProduct(w http.ResponseWriter, r *http.Request) {
+	var req CreateProductReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := h.Catalog.Create(ctx, orders.NewProduct{
+		SKU: req.SKU, Name: req.Name, PriceCents: req.PriceCents, Stock: req.Stock,
+		Currency: req.Currency, TaxCategory: req.TaxCategory,
+	}, actorOf(r), r.Header.Get("X-Request-Id"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (h *CatalogHandler) getProductBySKU(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	p, err := h.Catalog.GetBySKU(ctx, chi.URLParam(r, "sku"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *CatalogHandler) updateProduct(w http.ResponseWriter, r *http.Request) {
+	var req UpdateProductReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	// id bukan UUID -> 400 di sini, bukan 500 dari Postgres (22P02)
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := h.Catalog.Update(ctx, chi.URLParam(r, "id"), req.Version,
+		orders.ProductPatch{Name: req.Name, PriceCents: req.PriceCents, Hot: req.Hot, TaxCategory: req.TaxCategory}, r.Header.Get("X-Request-Id"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *CatalogHandler) archiveProduct(w http.ResponseWriter, r *http.Request) {
+	var req ArchiveProductReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	p, err := h.Catalog.Archive(ctx, chi.URLParam(r, "id"), req.Version, r.Header.Get("X-Request-Id"))
+	if err != nil {
+		writeCatalogError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func writeCatalogError(w http.ResponseWriter, err error) {
+	var verrs validation.Errors
+	switch {
+	case errors.As(err, &verrs):
+		writeValidationError(w, err)
+	case errors.Is(err, orders.ErrProductNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	case errors.Is(err, orders.ErrVersionConflict),
+		errors.Is(err, orders.ErrSKUExists),
+		errors.Is(err, orders.ErrProductArchived):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,24 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// id produk yang bukan UUID ditolak sebelum sampai ke catalog / Postgres.
+func TestCatalogRejectsInvalidProductID(t *testing.T) {
+	r := NewRouter()
+	(&CatalogHandler{}).Register(r)
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPatch, "/admin/products/not-a-uuid"},
+		{http.MethodPost, "/admin/products/not-a-uuid/archive"},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(`{"version":1}`)))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"id"`) {
+			t.Errorf("%s %s: %d %s, want 400 on id", tc.method, tc.path, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// FlagsHandler: admin API feature flag. Perubahan langsung berlaku di semua proses (invalidasi
+// cache lewat Redis pub/sub, lihat flags.Client).
+type FlagsHandler struct {
+	Flags        *flags.Client
+	MaxBodyBytes int64
+}
+
+// PutFlagReq: percent & target_by hanya dipakai kind percentage (target_by default user_id).
+type PutFlagReq struct {
+	Description string `json:"description,omitempty"`
+	Kind        string `json:"kind"` // boolean | percentage
+	Enabled     bool   `json:"enabled"`
+	Percent     int    `json:"percent,omitempty"`
+	TargetBy    string `json:"target_by,omitempty"` // user_id | external_id
+}
+
+func (h *FlagsHandler) Register(r *chi.Mux) {
+	r.Get("/admin/flags", h.list)
+	r.Get("/admin/flags/{key}", h.get)
+	r.Put("/admin/flags/{key}", h.put)
+	r.Delete("/admin/flags/{key}", h.remove)
+	r.Get("/admin/flags/{key}/evaluate", h.evaluate)
+}
+
+func (h *FlagsHandler) list(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	fs, err := h.Flags.List(ctx)
+	if err != nil {
+		writeFlagError(w, err)
+		return
+	}
+	if fs == nil {
+		fs = []orders.Flag{}
+	}
+	writeJSON(w, http.StatusOK, fs)
+}
+
+func (h *FlagsHandler) get(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	f, err := h.Flags.Get(ctx, chi.URLParam(r, "key"))
+	if err != nil {
+		writeFlagError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (h *FlagsHandler) put(w http.ResponseWriter, r *http.Request) {
+	var req PutFlagReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	in := orders.Flag{
+		Key: chi.URLParam(r, "key"), Description: req.Description, Kind: req.Kind,
+		Enabled: req.Enabled, Percent: req.Percent, TargetBy: req.TargetBy,
+	}
+	if in.TargetBy == "" {
+		in.TargetBy = orders.FlagTargetUserID
+	}
+	if in.Kind == orders.FlagBoolean {
+		in.Percent = 0
+	}
+	if err := validation.Flag(in); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	f, err := h.Flags.Set(ctx, in)
+	if err != nil {
+		writeFlagError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, f)
+}
+
+func (h *FlagsHandler) remove(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := h.Flags.Delete(ctx, chi.URLParam(r, "key")); err != nil {
+		writeFlagError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// evaluate: hasil flag untuk user_id / external_id tertentu (debug rollout), lewat cache yang
+// sama dengan handler order. Flag yang tidak ada dievaluasi off.
+func (h *FlagsHandler) evaluate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	q := r.URL.Query()
+	ev := h.Flags.Evaluate(ctx, chi.URLParam(r, "key"), flags.Subject{UserID: q.Get("user_id"), ExternalID: q.Get("external_id")}, false)
+	writeJSON(w, http.StatusOK, ev)
+}
+
+func writeFlagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, orders.ErrFlagNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,19 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/health"
+	"github.com/go-chi/chi/v5"
+)
+
+// HealthHandler: /livez (proses hidup) & /readyz (dependency siap, 503 saat draining).
+// /healthz di NewRouter tetap ada untuk probe lama.
+type HealthHandler struct {
+	Health *health.Registry
+}
+
+func (h *HealthHandler) Register(r *chi.Mux) {
+	r.Method(http.MethodGet, "/livez", h.Health.LiveHandler())
+	r.Method(http.MethodGet, "/readyz", h.Health.ReadyHandler())
+}
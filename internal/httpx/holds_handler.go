@@ -0,0 +1,61 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/inventory"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// HoldsHandler: perpanjang hold reservasi selama pembayaran masih berjalan.
+type HoldsHandler struct {
+	Holds        *inventory.Holds
+	MaxBodyBytes int64
+}
+
+type ExtendHoldReq struct {
+	ExtendSeconds int `json:"extend_seconds"`
+}
+
+type ExtendHoldResp struct {
+	OrderID   string    `json:"order_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (h *HoldsHandler) Register(r *chi.Mux) {
+	r.Post("/orders/{id}/hold/extend", h.extend)
+}
+
+func (h *HoldsHandler) extend(w http.ResponseWriter, r *http.Request) {
+	var req ExtendHoldReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	orderID := chi.URLParam(r, "id")
+	var v validation.Validator
+	v.UUID("id", orderID)
+	v.Check(req.ExtendSeconds > 0 && req.ExtendSeconds <= 3600, "extend_seconds", "must be between 1 and 3600")
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	exp, err := h.Holds.Extend(ctx, orderID, time.Duration(req.ExtendSeconds)*time.Second)
+	if errors.Is(err, orders.ErrHoldNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, ExtendHoldResp{OrderID: orderID, ExpiresAt: exp.UTC()})
+}
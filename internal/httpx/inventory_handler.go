@@ -0,0 +1,289 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/inventory"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// InventoryHandler: admin API stok (restock, adjustment, ledger, reconcile, threshold reorder)
+// + daftar low-stock alert dan availability per SKU untuk client.
+type InventoryHandler struct {
+	Stock        *inventory.StockAdmin
+	Availability *inventory.Availability
+	MaxBodyBytes int64
+}
+
+// maxAvailabilitySKUs: satu keranjang belanja per request.
+const maxAvailabilitySKUs = 100
+
+// AvailabilityItem: angka stok kosong kalau view=bucket.
+type AvailabilityItem struct {
+	SKU       string `json:"sku"`
+	ProductID string `json:"product_id"`
+	Status    string `json:"status"` // IN_STOCK | LOW | OUT
+	Available *int   `json:"available,omitempty"`
+	Reserved  *int   `json:"reserved,omitempty"`
+	OnHand    *int   `json:"on_hand,omitempty"`
+}
+
+type AvailabilityResp struct {
+	Items    []AvailabilityItem `json:"items"`
+	NotFound []string           `json:"not_found"`
+}
+
+// WarehouseID kosong = gudang default.
+type RestockReq struct {
+	WarehouseID string `json:"warehouse_id,omitempty"`
+	Qty         int    `json:"qty"`
+	Reason      string `json:"reason"`
+}
+
+type AdjustStockReq struct {
+	WarehouseID string `json:"warehouse_id,omitempty"`
+	Delta       int    `json:"delta"`
+	Kind        string `json:"kind"` // ADJUSTMENT | SHRINKAGE
+	Reason      string `json:"reason"`
+}
+
+type ReorderThresholdReq struct {
+	Threshold int `json:"threshold"`
+}
+
+type ReconcileResp struct {
+	OK     bool           `json:"ok"`
+	Drifts []orders.Drift `json:"drifts"`
+}
+
+func (h *InventoryHandler) Register(r *chi.Mux) {
+	r.Post("/admin/products/{id}/restock", h.restock)
+	r.Post("/admin/products/{id}/adjust", h.adjust)
+	r.Get("/admin/products/{id}/movements", h.movements)
+	r.Get("/admin/inventory/reconcile", h.reconcile)
+	r.Put("/admin/products/{id}/reorder-threshold", h.setThreshold)
+	r.Delete("/admin/products/{id}/reorder-threshold", h.removeThreshold)
+	r.Get("/inventory/alerts", h.alerts)
+	r.Get("/inventory/availability", h.availability)
+}
+
+func (h *InventoryHandler) restock(w http.ResponseWriter, r *http.Request) {
+	var req RestockReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if req.WarehouseID != "" {
+		v.UUID("warehouse_id", req.WarehouseID)
+	}
+	v.Check(req.Qty > 0, "qty", "must be greater than 0")
+	v.Required("reason", req.Reason)
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	m, err := h.Stock.Restock(ctx, chi.URLParam(r, "id"), req.WarehouseID, req.Qty, req.Reason, actorOf(r), r.Header.Get("X-Request-Id"))
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func (h *InventoryHandler) adjust(w http.ResponseWriter, r *http.Request) {
+	var req AdjustStockReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if req.WarehouseID != "" {
+		v.UUID("warehouse_id", req.WarehouseID)
+	}
+	v.Check(req.Delta != 0, "delta", "must not be 0")
+	v.Check(req.Kind == orders.MoveAdjustment || req.Kind == orders.MoveShrinkage, "kind", "must be ADJUSTMENT or SHRINKAGE")
+	v.Check(req.Kind != orders.MoveShrinkage || req.Delta < 0, "delta", "must be negative for SHRINKAGE")
+	v.Required("reason", req.Reason)
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	m, err := h.Stock.Adjust(ctx, chi.URLParam(r, "id"), req.WarehouseID, req.Delta, req.Kind, req.Reason, actorOf(r), r.Header.Get("X-Request-Id"))
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, m)
+}
+
+func (h *InventoryHandler) movements(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > 1000 {
+			writeValidationError(w, validation.Errors{{Field: "limit", Message: "must be between 1 and 1000"}})
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	ms, err := h.Stock.Movements(ctx, chi.URLParam(r, "id"), limit)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	if ms == nil {
+		ms = []orders.Movement{}
+	}
+	writeJSON(w, http.StatusOK, ms)
+}
+
+func (h *InventoryHandler) reconcile(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	drifts, err := h.Stock.Reconcile(ctx)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	if drifts == nil {
+		drifts = []orders.Drift{}
+	}
+	writeJSON(w, http.StatusOK, ReconcileResp{OK: len(drifts) == 0, Drifts: drifts})
+}
+
+func (h *InventoryHandler) setThreshold(w http.ResponseWriter, r *http.Request) {
+	var req ReorderThresholdReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	v.Check(req.Threshold >= 0, "threshold", "must be >= 0")
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	a, err := h.Stock.SetThreshold(ctx, chi.URLParam(r, "id"), req.Threshold)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (h *InventoryHandler) removeThreshold(w http.ResponseWriter, r *http.Request) {
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	if err := h.Stock.RemoveThreshold(ctx, chi.URLParam(r, "id")); err != nil {
+		writeStockError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// alerts: produk yang stoknya sedang di bawah threshold reorder (LOW / DEPLETED).
+func (h *InventoryHandler) alerts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	as, err := h.Stock.LowStock(ctx)
+	if err != nil {
+		writeStockError(w, err)
+		return
+	}
+	if as == nil {
+		as = []orders.StockAlert{}
+	}
+	writeJSON(w, http.StatusOK, as)
+}
+
+// availability: ?sku=A&sku=B atau ?sku=A,B (maks 100 sku unik); ?view=bucket untuk status saja.
+func (h *InventoryHandler) availability(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var skus []string
+	seen := map[string]bool{}
+	for _, raw := range q["sku"] {
+		for _, sku := range strings.Split(raw, ",") {
+			sku = strings.TrimSpace(sku)
+			if sku == "" || seen[sku] {
+				continue
+			}
+			seen[sku] = true
+			skus = append(skus, sku)
+		}
+	}
+	view := q.Get("view")
+
+	var v validation.Validator
+	v.Check(len(skus) > 0, "sku", "must not be empty")
+	v.Check(len(skus) <= maxAvailabilitySKUs, "sku", fmt.Sprintf("must contain at most %d skus", maxAvailabilitySKUs))
+	v.Check(view == "" || view == "exact" || view == "bucket", "view", "must be exact or bucket")
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	found, notFound, err := h.Availability.Lookup(ctx, skus)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	resp := AvailabilityResp{Items: make([]AvailabilityItem, 0, len(found)), NotFound: notFound}
+	if resp.NotFound == nil {
+		resp.NotFound = []string{}
+	}
+	for _, a := range found {
+		it := AvailabilityItem{SKU: a.SKU, ProductID: a.ProductID, Status: a.Bucket()}
+		if view != "bucket" {
+			it.Available, it.Reserved, it.OnHand = &a.Available, &a.Reserved, &a.OnHand
+		}
+		resp.Items = append(resp.Items, it)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func writeStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, orders.ErrProductNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	case errors.Is(err, orders.ErrWarehouseNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, orders.ErrInsufficientStock):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
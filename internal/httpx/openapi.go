@@ -0,0 +1,67 @@
+package httpx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ariefcatur/go-realtime-orders.git/api"
+	"github.com/go-chi/chi/v5"
+)
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(api.OpenAPI)
+}
+
+// CheckRoutes: route router vs spec. Semua route yang terdaftar di chi harus ada di
+// api/openapi.json dan sebaliknya (method + path saja; field request/response dicek
+// TestSchemasMatchTypes). Pattern chi "/orders/{id}" sama formatnya dengan path template
+// OpenAPI, jadi bisa dibandingkan langsung.
+func CheckRoutes(routes chi.Routes, spec []byte) error {
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("parse openapi: %w", err)
+	}
+
+	documented := map[string]bool{}
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options", "trace":
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	registered := map[string]bool{}
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		registered[method+" "+strings.TrimSuffix(route, "/*")] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for k := range registered {
+		if !documented[k] {
+			problems = append(problems, "undocumented route: "+k)
+		}
+	}
+	for k := range documented {
+		if !registered[k] {
+			problems = append(problems, "documented but not registered: "+k)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi route mismatch:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
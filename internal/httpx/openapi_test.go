@@ -0,0 +1,176 @@
+package httpx
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ariefcatur/go-realtime-orders.git/api"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/health"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/settings"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+)
+
+func fullAPI() API {
+	return API{
+		Orders: &OrdersHandler{}, Catalog: &CatalogHandler{}, Inventory: &InventoryHandler{}, Holds: &HoldsHandler{},
+		Promotions: &PromotionsHandler{}, Health: &HealthHandler{}, Settings: &SettingsHandler{}, Flags: &FlagsHandler{},
+	}
+}
+
+// Router yang sama dengan cmd/api harus persis sama dengan api/openapi.json.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	if err := CheckRoutes(fullAPI().Router(), api.OpenAPI); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRoutesReportsMismatch(t *testing.T) {
+	r := fullAPI().Router()
+	r.Get("/not-in-spec", func(http.ResponseWriter, *http.Request) {})
+	err := CheckRoutes(r, api.OpenAPI)
+	if err == nil || !strings.Contains(err.Error(), "undocumented route: GET /not-in-spec") {
+		t.Fatalf("undocumented route not reported: %v", err)
+	}
+
+	err = CheckRoutes(NewRouter(), api.OpenAPI)
+	if err == nil || !strings.Contains(err.Error(), "documented but not registered: POST /orders") {
+		t.Fatalf("missing route not reported: %v", err)
+	}
+}
+
+// schemaTypes: tipe Go yang di-encode / di-decode untuk setiap schema object di api/openapi.json.
+// Schema baru wajib didaftarkan di sini (atau di mapSchemas kalau body-nya map biasa).
+var schemaTypes = map[string]any{
+	"ItemInput":           orders.ItemInput{},
+	"ItemInputSKU":        orders.ItemInputSKU{},
+	"CreateOrderReq":      CreateOrderReq{},
+	"CreateOrderBySKUReq": CreateOrderBySKUReq{},
+	"CreateOrderResp":     CreateOrderResp{},
+	"Product":             orders.Product{},
+	"LegacyProduct":       LegacyProduct{},
+	"FieldError":          validation.FieldError{},
+	"ValidationErrorResp": ValidationErrorResp{},
+	"CreateProductReq":    CreateProductReq{},
+	"UpdateProductReq":    UpdateProductReq{},
+	"ArchiveProductReq":   ArchiveProductReq{},
+	"RestockReq":          RestockReq{},
+	"AdjustStockReq":      AdjustStockReq{},
+	"Movement":            orders.Movement{},
+	"Drift":               orders.Drift{},
+	"ReconcileResp":       ReconcileResp{},
+	"ExtendHoldReq":       ExtendHoldReq{},
+	"ExtendHoldResp":      ExtendHoldResp{},
+	"StockAlert":          orders.StockAlert{},
+	"ReorderThresholdReq": ReorderThresholdReq{},
+	"AvailabilityItem":    AvailabilityItem{},
+	"AvailabilityResp":    AvailabilityResp{},
+	"PricedItem":          orders.ItemPrice{},
+	"Promotion":           orders.Promotion{},
+	"CreatePromotionReq":  CreatePromotionReq{},
+	"UpdatePromotionReq":  UpdatePromotionReq{},
+	"Coupon":              orders.Coupon{},
+	"CreateCouponReq":     CreateCouponReq{},
+	"FeatureFlag":         orders.Flag{},
+	"PutFlagReq":          PutFlagReq{},
+	"FlagEvaluation":      flags.Evaluation{},
+	"TaxLine":             tax.TaxLine{},
+	"PriceChange":         orders.PriceChange{},
+	"PriceChangedResp":    PriceChangedResp{},
+	"CreateQuoteReq":      CreateQuoteReq{},
+	"QuoteItem":           orders.QuoteItem{},
+	"Quote":               orders.Quote{},
+	"HealthCheck":         health.Result{},
+	"HealthReport":        health.Report{},
+	"SettingsSnapshot":    settings.Snapshot{},
+}
+
+// mapSchemas: body ditulis sebagai map[string]string (writeJSON(w, code, map[string]string{...})).
+var mapSchemas = []string{"ErrorResp", "OrderStatusResp"}
+
+// Nama field JSON setiap schema harus sama persis dengan tipe Go-nya, dan field required tidak boleh
+// omitempty (selalu terkirim).
+func TestSchemasMatchTypes(t *testing.T) {
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				Type       string                     `json:"type"`
+				Required   []string                   `json:"required"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(api.OpenAPI, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for name, sc := range doc.Components.Schemas {
+		if sc.Type != "object" || slices.Contains(mapSchemas, name) {
+			continue
+		}
+		v, ok := schemaTypes[name]
+		if !ok {
+			t.Errorf("schema %s has no Go type in schemaTypes", name)
+			continue
+		}
+		fields, optional := jsonFields(reflect.TypeOf(v))
+		var props []string
+		for p := range sc.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		if !slices.Equal(props, fields) {
+			t.Errorf("schema %s properties %v, %T encodes %v", name, props, v, fields)
+		}
+		for _, req := range sc.Required {
+			if optional[req] {
+				t.Errorf("schema %s requires %s but %T marks it omitempty", name, req, v)
+			}
+		}
+	}
+	for name := range schemaTypes {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schemaTypes lists %s, not in openapi.json", name)
+		}
+	}
+}
+
+// jsonFields: nama field JSON (urut) seperti encoding/json, plus field yang omitempty.
+func jsonFields(t reflect.Type) ([]string, map[string]bool) {
+	var names []string
+	optional := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			inner, innerOpt := jsonFields(f.Type)
+			names = append(names, inner...)
+			for k := range innerOpt {
+				optional[k] = true
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+		if strings.Contains(opts, "omitempty") {
+			optional[name] = true
+		}
+	}
+	sort.Strings(names)
+	return names, optional
+}
@@ -3,48 +3,121 @@ package httpx
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
-	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
-	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
-	"github.com/go-chi/chi/v5"
-	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
-	kafkago "github.com/segmentio/kafka-go"
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/ordersvc"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
 )
 
 type CreateOrderBySKUReq struct {
-	ExternalID string                `json:"external_id"`
-	UserID     string                `json:"user_id"`
-	Items      []orders.ItemInputSKU `json:"items"`
+	ExternalID       string                  `json:"external_id"`
+	UserID           string                  `json:"user_id"`
+	Region           string                  `json:"region,omitempty"`
+	Items            []orders.ItemInputSKU   `json:"items"`
+	FulfilmentPolicy orders.FulfilmentPolicy `json:"fulfilment_policy,omitempty"`
+	CouponCode       string                  `json:"coupon_code,omitempty"`
+	Currency         string                  `json:"currency,omitempty"`
+	QuoteID          string                  `json:"quote_id,omitempty"`
 }
 
+// OrdersHandler: adapter HTTP tipis di atas ordersvc.Service (logika yang sama dipakai gRPC).
 type OrdersHandler struct {
-	Repo     *orders.Repo
-	Producer *kafkax.Producer
-	Redis    *redis.Client
-	Service  string
+	Orders       *ordersvc.Service
+	MaxBodyBytes int64
 }
 
 type CreateOrderReq struct {
-	ExternalID string             `json:"external_id"`
-	UserID     string             `json:"user_id"`
-	Items      []orders.ItemInput `json:"items"`
+	ExternalID       string                  `json:"external_id"`
+	UserID           string                  `json:"user_id"`
+	Region           string                  `json:"region,omitempty"`
+	Items            []orders.ItemInput      `json:"items"`
+	FulfilmentPolicy orders.FulfilmentPolicy `json:"fulfilment_policy,omitempty"`
+	CouponCode       string                  `json:"coupon_code,omitempty"`
+	Currency         string                  `json:"currency,omitempty"` // kosong = currency produk
+	QuoteID          string                  `json:"quote_id,omitempty"` // harga dari POST /quotes
+}
+
+type CreateQuoteReq struct {
+	UserID string             `json:"user_id"`
+	Items  []orders.ItemInput `json:"items"`
+}
+
+// PriceChangedResp: body 409 kalau expected_price_cents tidak cocok dengan harga saat order dibuat.
+type PriceChangedResp struct {
+	Error   string               `json:"error"` // selalu PRICE_CHANGED
+	Changes []orders.PriceChange `json:"changes"`
+}
+
+// LegacyProduct: bentuk lama GET /products (nama field Go apa adanya, tanpa tag json). Dibekukan untuk
+// client lama; field baru hanya masuk ke GET /v1/products (orders.Product).
+type LegacyProduct struct {
+	ID         string
+	SKU        string
+	Name       string
+	Stock      int
+	PriceCents int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
 
 type CreateOrderResp struct {
-	OrderID    string `json:"order_id"`
-	TotalCents int    `json:"total_cents"`
-	Idempotent bool   `json:"idempotent"`
+	OrderID       string             `json:"order_id"`
+	Currency      string             `json:"currency"`
+	TotalCents    int                `json:"total_cents"` // setelah diskon, termasuk pajak
+	DiscountCents int                `json:"discount_cents"`
+	TaxCents      int                `json:"tax_cents"`
+	Items         []orders.ItemPrice `json:"items"` // list price, diskon, harga akhir & pajak per line
+	Taxes         []tax.TaxLine      `json:"taxes"`
+	Idempotent    bool               `json:"idempotent"`
+}
+
+func toCreateOrderResp(res ordersvc.PlaceResult) CreateOrderResp {
+	taxes := res.Taxes
+	if taxes == nil {
+		taxes = []tax.TaxLine{}
+	}
+	return CreateOrderResp{
+		OrderID: res.OrderID, Currency: res.Currency, TotalCents: res.TotalCents, DiscountCents: res.DiscountCents,
+		TaxCents: res.TaxCents, Items: res.Items, Taxes: taxes, Idempotent: res.Idempotent,
+	}
+}
+
+// writeOrderError: satu pemetaan error use-case order (ordersvc.Classify) untuk /orders, /orders/sku
+// dan /quotes.
+func writeOrderError(w http.ResponseWriter, err error) {
+	switch ordersvc.Classify(err) {
+	case ordersvc.ClassInvalid:
+		writeValidationError(w, err)
+	case ordersvc.ClassPriceChanged:
+		var pc *orders.PriceChangedError
+		errors.As(err, &pc)
+		writeJSON(w, http.StatusConflict, PriceChangedResp{Error: orders.ErrPriceChanged.Error(), Changes: pc.Changes})
+	case ordersvc.ClassBusy:
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	case ordersvc.ClassDisabled:
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+	case ordersvc.ClassRejected:
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	case ordersvc.ClassNotFound:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
 }
 
 func (h *OrdersHandler) Register(r *chi.Mux) {
 	r.Post("/orders", h.createOrder)
 	r.Post("/orders/sku", h.createOrderBySKU)
 	r.Get("/orders/{id}", h.getOrder)
-	r.Get("/products", h.listProducts)
+	r.Get("/products", h.listProductsLegacy)
+	r.Get("/v1/products", h.listProducts)
+	r.Post("/quotes", h.createQuote)
 }
 
 func writeJSON(w http.ResponseWriter, code int, v any) {
@@ -53,63 +126,64 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func (h *OrdersHandler) createOrderBySKU(w http.ResponseWriter, r *http.Request) {
-	var req CreateOrderBySKUReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+type ValidationErrorResp struct {
+	Error  string                  `json:"error"`
+	Fields []validation.FieldError `json:"fields"`
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, validation.ErrBodyTooLarge):
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+	case errors.Is(err, validation.ErrInvalidJSON):
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+	default:
+		writeValidationError(w, err)
+	}
+}
+
+func writeValidationError(w http.ResponseWriter, err error) {
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		writeJSON(w, http.StatusBadRequest, ValidationErrorResp{Error: "validation failed", Fields: verrs})
 		return
 	}
-	if req.ExternalID == "" || req.UserID == "" || len(req.Items) == 0 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing fields"})
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
+
+func (h *OrdersHandler) createOrderBySKU(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrderBySKUReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	orderID, total, existed, err := h.Repo.CreateOrderBySKU(ctx, req.ExternalID, req.UserID, req.Items)
+	res, err := h.Orders.PlaceOrderBySKU(ctx, ordersvc.PlaceOrderBySKUInput{
+		ExternalID:       req.ExternalID,
+		UserID:           req.UserID,
+		Region:           req.Region,
+		Items:            req.Items,
+		TraceID:          r.Header.Get("X-Request-Id"),
+		FulfilmentPolicy: req.FulfilmentPolicy,
+		CouponCode:       req.CouponCode,
+		Currency:         req.Currency,
+		QuoteID:          req.QuoteID,
+	})
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		writeOrderError(w, err)
 		return
 	}
-
-	// set idempotency & cache status seperti createOrder()
-	idemKey := fmt.Sprintf(redisx.KeyIdemOrderCreate, req.ExternalID)
-	_ = h.Redis.Set(ctx, idemKey, orderID, redisx.TTLIdempotency).Err()
-	statusKey := fmt.Sprintf(redisx.KeyOrderStatus, orderID)
-	_ = h.Redis.Set(ctx, statusKey, `{"status":"CREATED"}`, redisx.TTLStatusCache).Err()
-
-	// publish event sama persis (OrderCreated)
-	ev := orders.Envelope{
-		EventID:       uuid.NewString(),
-		EventType:     orders.EventOrderCreated,
-		EventVersion:  1,
-		OccurredAt:    time.Now().UTC(),
-		Producer:      h.Service,
-		TraceID:       r.Header.Get("X-Request-Id"),
-		CorrelationID: orderID,
-	}
-	// payload items bisa diisi qty + biar ringan, price 0 (downstream bisa re-query)
-	ev.Payload = kafkax.MustMarshal(orders.OrderCreatedPayload{
-		OrderID:    orderID,
-		ExternalID: req.ExternalID,
-		UserID:     req.UserID,
-		Items:      nil, // atau mapping SKU->ID kalau mau lengkap
-		TotalCents: total,
-	})
-	h.Producer.Publish(orders.PartitionKey(orderID), kafkax.MustMarshal(ev),
-		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventOrderCreated)},
-		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
-	)
-
-	writeJSON(w, http.StatusAccepted, CreateOrderResp{OrderID: orderID, TotalCents: total, Idempotent: existed})
+	writeJSON(w, http.StatusAccepted, toCreateOrderResp(res))
 }
 
 func (h *OrdersHandler) listProducts(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	ps, err := h.Repo.ListProducts(ctx)
+	ps, err := h.Orders.ListProducts(ctx)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
@@ -117,76 +191,70 @@ func (h *OrdersHandler) listProducts(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, ps)
 }
 
-func (h *OrdersHandler) createOrder(w http.ResponseWriter, r *http.Request) {
-	var req CreateOrderReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+func (h *OrdersHandler) listProductsLegacy(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	ps, err := h.Orders.ListProducts(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
-	if req.ExternalID == "" || req.UserID == "" || len(req.Items) == 0 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing fields"})
+	out := make([]LegacyProduct, 0, len(ps))
+	for _, p := range ps {
+		out = append(out, LegacyProduct{
+			ID: p.ID, SKU: p.SKU, Name: p.Name, Stock: p.Stock, PriceCents: p.PriceCents,
+			CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (h *OrdersHandler) createOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrderReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	// Fast-path idempotency via Redis (optional, DB tetap jadi kebenaran)
-	idemKey := fmt.Sprintf(redisx.KeyIdemOrderCreate, req.ExternalID)
-	if ok, _ := redisx.Exists(ctx, h.Redis, idemKey); ok {
-		// fall back ke DB buat ambil nilai akurat
-		// (di demo, kita langsung ke DB lewat repo CreateOrderTx yang handle existed)
-	}
-
-	orderID, total, existed, err := h.Repo.CreateOrderTx(ctx, req.ExternalID, req.UserID, req.Items)
+	res, err := h.Orders.PlaceOrder(ctx, ordersvc.PlaceOrderInput{
+		ExternalID:       req.ExternalID,
+		UserID:           req.UserID,
+		Region:           req.Region,
+		Items:            req.Items,
+		TraceID:          r.Header.Get("X-Request-Id"),
+		FulfilmentPolicy: req.FulfilmentPolicy,
+		CouponCode:       req.CouponCode,
+		Currency:         req.Currency,
+		QuoteID:          req.QuoteID,
+	})
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		writeOrderError(w, err)
 		return
 	}
+	writeJSON(w, http.StatusAccepted, toCreateOrderResp(res))
+}
 
-	// Simpan shortcut idempotency di Redis (TTL 24h)
-	_ = h.Redis.Set(ctx, idemKey, orderID, redisx.TTLIdempotency).Err()
-
-	// Cache status (CREATED) agar GET cepat
-	statusKey := fmt.Sprintf(redisx.KeyOrderStatus, orderID)
-	_ = h.Redis.Set(ctx, statusKey, `{"status":"CREATED"}`, redisx.TTLStatusCache).Err()
-
-	// Publish event (envelope v1)
-	ev := orders.Envelope{
-		EventID:       uuid.NewString(),
-		EventType:     orders.EventOrderCreated,
-		EventVersion:  1,
-		OccurredAt:    time.Now().UTC(),
-		Producer:      h.Service,
-		TraceID:       r.Header.Get("X-Request-Id"),
-		CorrelationID: orderID,
-	}
-	payload := orders.OrderCreatedPayload{
-		OrderID:    orderID,
-		ExternalID: req.ExternalID,
-		UserID:     req.UserID,
-		Items:      toItemPrices(req.Items), // price diisi oleh consumer? Di sini cukup qty+id; tapi karena kita sudah hitung total dari DB, kita isi price di payload biar lengkap.
-		TotalCents: total,
+func (h *OrdersHandler) createQuote(w http.ResponseWriter, r *http.Request) {
+	var req CreateQuoteReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
 	}
-	ev.Payload = kafkax.MustMarshal(payload)
-	value := kafkax.MustMarshal(ev)
 
-	h.Producer.Publish(
-		orders.PartitionKey(orderID),
-		value,
-		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventOrderCreated)},
-		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
-	)
-
-	writeJSON(w, http.StatusAccepted, CreateOrderResp{OrderID: orderID, TotalCents: total, Idempotent: existed})
-}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
 
-func toItemPrices(items []orders.ItemInput) []orders.ItemPrice {
-	out := make([]orders.ItemPrice, 0, len(items))
-	for _, it := range items {
-		out = append(out, orders.ItemPrice{ProductID: it.ProductID, Qty: it.Qty, PriceCents: 0})
+	q, err := h.Orders.CreateQuote(ctx, req.UserID, req.Items)
+	if err != nil {
+		writeOrderError(w, err)
+		return
 	}
-	return out
+	q.ExpiresAt = q.ExpiresAt.UTC()
+	writeJSON(w, http.StatusCreated, q)
 }
 
 func (h *OrdersHandler) getOrder(w http.ResponseWriter, r *http.Request) {
@@ -199,23 +267,10 @@ func (h *OrdersHandler) getOrder(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
 	defer cancel()
 
-	// 1) coba cache
-	key := fmt.Sprintf(redisx.KeyOrderStatus, orderID)
-	if s, err := h.Redis.Get(ctx, key).Result(); err == nil && s != "" {
-		writeJSON(w, http.StatusOK, json.RawMessage(s))
-		return
-	}
-
-	// 2) fallback DB
-	status, err := h.Repo.GetOrderStatus(ctx, orderID)
+	v, err := h.Orders.GetOrder(ctx, orderID)
 	if err != nil {
 		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
 		return
 	}
-	body := map[string]any{"status": status}
-	b, _ := json.Marshal(body)
-	_ = h.Redis.Set(ctx, key, b, redisx.TTLStatusCache).Err()
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(b)
+	writeJSON(w, http.StatusOK, map[string]any{"status": v.Status})
 }
@@ -0,0 +1,110 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/ordersvc"
+)
+
+// stubRepo: ordersvc.Repository; err dikembalikan oleh semua method.
+type stubRepo struct {
+	products []orders.Product
+	err      error
+}
+
+func (r *stubRepo) CreateOrderTx(context.Context, string, string, []orders.ItemInput, orders.PricingOptions) (orders.CreatedOrder, error) {
+	return orders.CreatedOrder{}, r.err
+}
+
+func (r *stubRepo) CreateOrderBySKU(context.Context, string, string, []orders.ItemInputSKU, orders.PricingOptions) (orders.CreatedOrder, error) {
+	return orders.CreatedOrder{}, r.err
+}
+
+func (r *stubRepo) GetOrderStatus(context.Context, string) (orders.Status, error) { return "", r.err }
+
+func (r *stubRepo) ListProducts(context.Context) ([]orders.Product, error) { return r.products, r.err }
+
+func (r *stubRepo) CreateQuote(context.Context, string, []orders.ItemInput, time.Duration) (orders.Quote, error) {
+	return orders.Quote{}, r.err
+}
+
+func ordersRouter(repo *stubRepo) http.Handler {
+	r := NewRouter()
+	(&OrdersHandler{Orders: &ordersvc.Service{Repo: repo}}).Register(r)
+	return r
+}
+
+func productKeys(t *testing.T, h http.Handler, path string) []string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body.String())
+	}
+	var out []map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil || len(out) != 1 {
+		t.Fatalf("GET %s: %s (%v)", path, rec.Body.String(), err)
+	}
+	var keys []string
+	for k := range out[0] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GET /products tetap bentuk lama (nama field Go); bentuk snake_case hanya di /v1/products.
+func TestListProductsWireFormats(t *testing.T) {
+	h := ordersRouter(&stubRepo{products: []orders.Product{{
+		ID: "p-1", SKU: "SKU-A", Name: "apel", Stock: 3, PriceCents: 500, Version: 2, Currency: "IDR", TaxCategory: "food",
+	}}})
+
+	legacy := []string{"CreatedAt", "ID", "Name", "PriceCents", "SKU", "Stock", "UpdatedAt"}
+	if got := productKeys(t, h, "/products"); !slices.Equal(got, legacy) {
+		t.Fatalf("GET /products keys %v, want %v", got, legacy)
+	}
+	v1 := []string{"created_at", "currency", "hot", "id", "name", "price_cents", "sku", "stock", "tax_category", "updated_at", "version"}
+	if got := productKeys(t, h, "/v1/products"); !slices.Equal(got, v1) {
+		t.Fatalf("GET /v1/products keys %v, want %v", got, v1)
+	}
+}
+
+// Error repo yang sama dijawab dengan status yang sama oleh /orders, /orders/sku dan /quotes.
+func TestOrderEndpointsClassifyErrorsAlike(t *testing.T) {
+	const user = "7f9c2d4e-0000-4000-8000-0000000000aa"
+	const product = "7f9c2d4e-0000-4000-8000-00000000000a"
+	bodies := map[string]string{
+		"/orders":     `{"external_id":"ext-1","user_id":"` + user + `","items":[{"product_id":"` + product + `","qty":1}]}`,
+		"/orders/sku": `{"external_id":"ext-1","user_id":"` + user + `","items":[{"sku":"SKU-A","qty":1}]}`,
+		"/quotes":     `{"user_id":"` + user + `","items":[{"product_id":"` + product + `","qty":1}]}`,
+	}
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"product not found", fmt.Errorf("%w: sku=SKU-A", orders.ErrProductNotFound), http.StatusUnprocessableEntity},
+		{"archived", fmt.Errorf("%w: sku=SKU-A", orders.ErrProductArchived), http.StatusUnprocessableEntity},
+		{"unknown", errors.New("connection reset"), http.StatusInternalServerError},
+	} {
+		h := ordersRouter(&stubRepo{err: tc.err})
+		for path, body := range bodies {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+			if rec.Code != tc.want {
+				t.Errorf("%s: POST %s = %d %s, want %d", tc.name, path, rec.Code, rec.Body.String(), tc.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// PromotionsHandler: admin API promosi & kupon (dipakai pricing saat order dibuat).
+type PromotionsHandler struct {
+	Promotions   *orders.PromotionRepo
+	MaxBodyBytes int64
+}
+
+// CreatePromotionReq: product_id kosong = diskon seluruh order (PERCENT / FIXED).
+type CreatePromotionReq struct {
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"` // PERCENT | FIXED | BUY_X_GET_Y
+	ProductID   *string    `json:"product_id,omitempty"`
+	Percent     int        `json:"percent,omitempty"`
+	AmountCents int        `json:"amount_cents,omitempty"`
+	BuyQty      int        `json:"buy_qty,omitempty"`
+	GetQty      int        `json:"get_qty,omitempty"`
+	CouponOnly  bool       `json:"coupon_only,omitempty"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+}
+
+type UpdatePromotionReq struct {
+	Active bool `json:"active"`
+}
+
+// CreateCouponReq: max_uses kosong = tanpa batas total; max_uses_per_user default 1.
+type CreateCouponReq struct {
+	Code           string  `json:"code"`
+	PromotionID    string  `json:"promotion_id"`
+	UserID         *string `json:"user_id,omitempty"`
+	MaxUses        *int    `json:"max_uses,omitempty"`
+	MaxUsesPerUser int     `json:"max_uses_per_user,omitempty"`
+}
+
+func (h *PromotionsHandler) Register(r *chi.Mux) {
+	r.Post("/admin/promotions", h.createPromotion)
+	r.Get("/admin/promotions", h.listPromotions)
+	r.Patch("/admin/promotions/{id}", h.updatePromotion)
+	r.Post("/admin/coupons", h.createCoupon)
+}
+
+func (h *PromotionsHandler) createPromotion(w http.ResponseWriter, r *http.Request) {
+	var req CreatePromotionReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	in := orders.Promotion{
+		Name: req.Name, Kind: req.Kind, ProductID: req.ProductID, Percent: req.Percent, AmountCents: req.AmountCents,
+		BuyQty: req.BuyQty, GetQty: req.GetQty, CouponOnly: req.CouponOnly, EndsAt: req.EndsAt,
+	}
+	if req.StartsAt != nil {
+		in.StartsAt = *req.StartsAt
+	}
+	if err := validation.Promotion(in); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	p, err := h.Promotions.CreatePromotion(ctx, in)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (h *PromotionsHandler) listPromotions(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+	ps, err := h.Promotions.ListPromotions(ctx)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	if ps == nil {
+		ps = []orders.Promotion{}
+	}
+	writeJSON(w, http.StatusOK, ps)
+}
+
+func (h *PromotionsHandler) updatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req UpdatePromotionReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var v validation.Validator
+	v.UUID("id", chi.URLParam(r, "id"))
+	if err := v.Err(); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	p, err := h.Promotions.SetActive(ctx, chi.URLParam(r, "id"), req.Active)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (h *PromotionsHandler) createCoupon(w http.ResponseWriter, r *http.Request) {
+	var req CreateCouponReq
+	if err := validation.DecodeJSON(w, r, h.MaxBodyBytes, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	in := orders.Coupon{
+		Code: req.Code, PromotionID: req.PromotionID, UserID: req.UserID, MaxUses: req.MaxUses, MaxUsesPerUser: req.MaxUsesPerUser,
+	}
+	if in.MaxUsesPerUser == 0 {
+		in.MaxUsesPerUser = 1
+	}
+	if err := validation.Coupon(in); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	c, err := h.Promotions.CreateCoupon(ctx, in)
+	if err != nil {
+		writePromotionError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func writePromotionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, orders.ErrPromotionNotFound), errors.Is(err, orders.ErrProductNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, orders.ErrCouponExists):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -1,19 +1,143 @@
 package httpx
 
 import (
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewRouter() *chi.Mux {
+// NewRouter: router dasar + middleware umum; mw tambahan dipasang setelahnya (sebelum route apa pun).
+func NewRouter(mw ...func(http.Handler) http.Handler) *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.RequestID, middleware.RealIP, middleware.Logger)
+	r.Use(middleware.RequestID, middleware.RealIP, traced, logRequests, instrument)
 	r.Use(middleware.Timeout(15 * time.Second))
+	r.Use(mw...)
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
+	r.Get("/openapi.json", serveOpenAPI)
+	r.Method(http.MethodGet, "/metrics", metrics.Handler())
+	return r
+}
+
+// API: semua handler service api. Router = router lengkap, dipakai cmd/api dan test kontrak OpenAPI
+// (handler tidak dipanggil saat registrasi, jadi test cukup memakai handler kosong).
+type API struct {
+	Orders     *OrdersHandler
+	Catalog    *CatalogHandler
+	Inventory  *InventoryHandler
+	Holds      *HoldsHandler
+	Promotions *PromotionsHandler
+	Health     *HealthHandler
+	Settings   *SettingsHandler
+	Flags      *FlagsHandler
+	Admin      *AdminAuth // nil = /admin/* selalu 401
+}
+
+func (a API) Router() *chi.Mux {
+	r := NewRouter(a.Admin.Middleware)
+	a.Orders.Register(r)
+	a.Health.Register(r)
+	a.Settings.Register(r)
+	a.Catalog.Register(r)
+	a.Inventory.Register(r)
+	a.Holds.Register(r)
+	a.Promotions.Register(r)
+	a.Flags.Register(r)
 	return r
 }
+
+// traced: server span per request, parent dari header traceparent (kalau ada). Nama span diisi
+// route pattern setelah routing selesai; context span diteruskan ke handler (pgx, Redis, Kafka).
+func traced(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+				attribute.String("http.request.id", middleware.GetReqID(r.Context())),
+			))
+		defer span.End()
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		if rc := chi.RouteContext(ctx); rc != nil && rc.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rc.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rc.RoutePattern()))
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(code))
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
+	})
+}
+
+// logRequests: logger per request (request_id, method, path) di context handler; satu log akses
+// setelah selesai. 5xx ditulis ERROR (tidak di-sample), sisanya INFO (kena sampling logx).
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ctx, log := logx.With(r.Context(),
+			"request_id", middleware.GetReqID(r.Context()), "method", r.Method, "path", r.URL.Path)
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		route := ""
+		if rc := chi.RouteContext(ctx); rc != nil {
+			route = rc.RoutePattern()
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		lvl := slog.LevelInfo
+		if code >= http.StatusInternalServerError {
+			lvl = slog.LevelError
+		}
+		log.LogAttrs(ctx, lvl, "http request",
+			slog.String("route", route), slog.Int("status", code), slog.Int("bytes", ww.BytesWritten()),
+			slog.String("remote_addr", r.RemoteAddr), slog.Int64("duration_ms", time.Since(start).Milliseconds()))
+	})
+}
+
+// instrument: latency & jumlah request per route pattern chi (bukan path mentah, supaya
+// /orders/{id} tidak jadi satu series per order). Route tidak dikenal dicatat sebagai "unmatched".
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil && rc.RoutePattern() != "" {
+			route = rc.RoutePattern()
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(code)).Inc()
+		metrics.HTTPDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,17 @@
+package httpx
+
+import (
+	"net/http"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/settings"
+	"github.com/go-chi/chi/v5"
+)
+
+// SettingsHandler: setting runtime yang sedang aktif (hasil hot-reload file config).
+type SettingsHandler struct {
+	Settings *settings.Store
+}
+
+func (h *SettingsHandler) Register(r *chi.Mux) {
+	r.Method(http.MethodGet, "/admin/settings", h.Settings.Handler())
+}
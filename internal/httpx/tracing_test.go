@@ -0,0 +1,74 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Request dengan traceparent -> span server (child dari traceparent) -> span publish Kafka dari
+// handler, semuanya satu trace. Sisi consume dites di internal/kafka.
+func TestRequestTraceReachesKafkaPublish(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracing.NewProvider("test", sdktrace.WithSyncer(exp))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+
+	prod := kafkax.NewProducer([]string{"localhost:0"}, "orders.test", 1) // tidak di-Start, pesan tertahan di inbox
+	r := NewRouter()
+	r.Post("/orders/{id}/publish", func(w http.ResponseWriter, r *http.Request) {
+		prod.Publish(r.Context(), []byte("order-1"), []byte(`{}`))
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	const (
+		upstreamTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+		upstreamSpan  = "00f067aa0ba902b7"
+	)
+	req := httptest.NewRequest(http.MethodPost, "/orders/1/publish", nil)
+	req.Header.Set("traceparent", "00-"+upstreamTrace+"-"+upstreamSpan+"-01")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	var server, publish *tracetest.SpanStub
+	spans := exp.GetSpans()
+	for i := range spans {
+		switch spans[i].Name {
+		case "POST /orders/{id}/publish":
+			server = &spans[i]
+		case "publish orders.test":
+			publish = &spans[i]
+		}
+	}
+	if server == nil || publish == nil {
+		t.Fatalf("missing spans (server %v, publish %v) in %d spans", server != nil, publish != nil, len(spans))
+	}
+	if server.SpanKind != trace.SpanKindServer || server.SpanContext.TraceID().String() != upstreamTrace ||
+		server.Parent.SpanID().String() != upstreamSpan {
+		t.Fatalf("server span kind %v trace %v parent %v, want child of traceparent", server.SpanKind,
+			server.SpanContext.TraceID(), server.Parent.SpanID())
+	}
+	if publish.SpanKind != trace.SpanKindProducer || publish.Parent.SpanID() != server.SpanContext.SpanID() ||
+		publish.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Fatalf("publish span kind %v parent %v, want producer child of server span %v", publish.SpanKind,
+			publish.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+}
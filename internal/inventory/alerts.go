@@ -0,0 +1,61 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// StockWatcher: cek threshold reorder setelah stok berubah (reservasi, restock, adjustment)
+// lalu publish StockLow / StockDepleted. Dipakai oleh Service dan StockAdmin; nil = tidak aktif.
+type StockWatcher struct {
+	Repo        *orders.AlertRepo
+	Producer    *kafkax.Producer // publish inventory.stock.alerts
+	ServiceName string
+	Cooldown    time.Duration // jeda minimum antar StockLow untuk produk yang sama
+}
+
+// Check: best-effort, error hanya di-log (alert tidak boleh menggagalkan reservasi).
+func (w *StockWatcher) Check(ctx context.Context, productIDs ...string) {
+	if w == nil {
+		return
+	}
+	changes, err := w.Repo.Evaluate(ctx, productIDs, w.Cooldown)
+	if err != nil {
+		logx.FromContext(ctx).Error("stock watcher failed", "err", err)
+		return
+	}
+	for _, c := range changes {
+		if !c.Notify {
+			continue
+		}
+		w.publish(ctx, c.StockAlert)
+	}
+}
+
+func (w *StockWatcher) publish(ctx context.Context, a orders.StockAlert) {
+	typ := orders.EventStockLow
+	if a.State == orders.AlertDepleted {
+		typ = orders.EventStockDepleted
+	}
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     typ,
+		EventVersion:  1,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      w.ServiceName,
+		CorrelationID: a.ProductID,
+		Payload: kafkax.MustMarshal(orders.StockAlertPayload{
+			ProductID: a.ProductID, SKU: a.SKU, Stock: a.Stock, Threshold: a.Threshold, State: a.State,
+		}),
+	}
+	w.Producer.Publish(ctx, []byte(a.ProductID), kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(typ)},
+		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
+	)
+}
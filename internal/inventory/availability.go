@@ -0,0 +1,170 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/redis/go-redis/v9"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Availability: snapshot stok per SKU untuk client (GET /inventory/availability).
+// Snapshot di-cache di Redis per product_id dan dihapus oleh event reservasi / release /
+// adjustment (HandleEvent); TTL hanya pengaman kalau event telat / snapshot di-set
+// berbarengan dengan invalidasi.
+type Availability struct {
+	Repo  *orders.InventoryRepo
+	Redis *redis.Client
+	TTL   time.Duration // 0 -> redisx.TTLAvailability
+}
+
+// Lookup: hasil urut sesuai skus; sku yang tidak ada / archived masuk notFound.
+// Redis error tidak menggagalkan request (fallback ke Postgres).
+func (a *Availability) Lookup(ctx context.Context, skus []string) (found []orders.Availability, notFound []string, err error) {
+	bySKU, misses := a.fromCache(ctx, skus)
+	if len(misses) > 0 {
+		rows, err := a.Repo.Availability(ctx, misses)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, r := range rows {
+			bySKU[r.SKU] = r
+		}
+		a.store(ctx, rows)
+	}
+
+	for _, sku := range skus {
+		if v, ok := bySKU[sku]; ok {
+			found = append(found, v)
+		} else {
+			notFound = append(notFound, sku)
+		}
+	}
+	return found, notFound, nil
+}
+
+// fromCache: sku -> product_id lewat hash, lalu MGET snapshot. misses = sku yang harus ke DB.
+func (a *Availability) fromCache(ctx context.Context, skus []string) (map[string]orders.Availability, []string) {
+	out := make(map[string]orders.Availability, len(skus))
+	ids, err := a.Redis.HMGet(ctx, redisx.KeyAvailabilitySKU, skus...).Result()
+	if err != nil {
+		logx.FromContext(ctx).Warn("availability cache failed", "err", err)
+		return out, skus
+	}
+
+	var keys, keySKU []string
+	var misses []string
+	for i, v := range ids {
+		id, ok := v.(string)
+		if !ok {
+			misses = append(misses, skus[i])
+			continue
+		}
+		keys = append(keys, fmt.Sprintf(redisx.KeyAvailability, id))
+		keySKU = append(keySKU, skus[i])
+	}
+	if len(keys) == 0 {
+		return out, misses
+	}
+
+	vals, err := a.Redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		logx.FromContext(ctx).Warn("availability cache failed", "err", err)
+		return out, skus
+	}
+	for i, v := range vals {
+		var snap orders.Availability
+		s, ok := v.(string)
+		if !ok || json.Unmarshal([]byte(s), &snap) != nil {
+			misses = append(misses, keySKU[i])
+			continue
+		}
+		out[keySKU[i]] = snap
+	}
+	return out, misses
+}
+
+func (a *Availability) store(ctx context.Context, rows []orders.Availability) {
+	if len(rows) == 0 {
+		return
+	}
+	ttl := a.TTL
+	if ttl <= 0 {
+		ttl = redisx.TTLAvailability.Get()
+	}
+	pipe := a.Redis.Pipeline()
+	for _, r := range rows {
+		b, _ := json.Marshal(r)
+		pipe.HSet(ctx, redisx.KeyAvailabilitySKU, r.SKU, r.ProductID)
+		pipe.Set(ctx, fmt.Sprintf(redisx.KeyAvailability, r.ProductID), b, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logx.FromContext(ctx).Warn("availability cache failed", "err", err)
+	}
+}
+
+// Invalidate: hapus snapshot produk-produk ini; lookup berikutnya baca ulang dari Postgres.
+func (a *Availability) Invalidate(ctx context.Context, productIDs ...string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(productIDs))
+	for _, id := range productIDs {
+		keys = append(keys, fmt.Sprintf(redisx.KeyAvailability, id))
+	}
+	return a.Redis.Del(ctx, keys...).Err()
+}
+
+// HandleEvent: consumer invalidasi untuk topic stok (reserved, released, adjusted) dan catalog
+// (produk archived). Event lain di-skip.
+func (a *Availability) HandleEvent(ctx context.Context, m kafkago.Message) error {
+	var env orders.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil {
+		return err
+	}
+	ids, err := affectedProducts(env)
+	if err != nil {
+		return err
+	}
+	return a.Invalidate(ctx, ids...)
+}
+
+func affectedProducts(env orders.Envelope) ([]string, error) {
+	var ids []string
+	switch env.EventType {
+	case orders.EventStockReserved:
+		var p orders.StockReservedPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		for _, it := range p.Items {
+			ids = append(ids, it.ProductID)
+		}
+	case orders.EventStockReleased:
+		var p orders.StockReleasedPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		for _, al := range p.Allocations {
+			ids = append(ids, al.ProductID)
+		}
+	case orders.EventStockAdjusted:
+		var p orders.StockAdjustedPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		ids = append(ids, p.ProductID)
+	case orders.EventProductArchived:
+		var p orders.ProductArchivedPayload
+		if err := json.Unmarshal(env.Payload, &p); err != nil {
+			return nil, err
+		}
+		ids = append(ids, p.ProductID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Holds: masa berlaku reservasi (hold). Sweeper me-release hold yang expired (hanya order yang belum
+// dibayar; order ikut FAILED) dan publish StockReleased; Extend dipakai selama pembayaran masih berjalan.
+type Holds struct {
+	Repo        *orders.ReservationRepo
+	Producer    *kafkax.Producer // publish order.stock.released
+	ServiceName string
+	MaxHold     time.Duration // batas total hold sejak reserve
+	BatchSize   int
+	Alerts      *StockWatcher // opsional: stok kembali -> state alert dihitung ulang
+}
+
+// Extend: perpanjang hold order; return expires_at baru.
+func (h *Holds) Extend(ctx context.Context, orderID string, by time.Duration) (time.Time, error) {
+	return h.Repo.ExtendHold(ctx, orderID, by, h.MaxHold)
+}
+
+// SweepExpired: release satu batch order yang hold-nya expired. Return jumlah order yang di-release.
+func (h *Holds) SweepExpired(ctx context.Context) (int, error) {
+	ids, err := h.Repo.ExpiredOrders(ctx, h.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		allocs, err := h.Repo.ReleaseExpired(ctx, id)
+		if err != nil {
+			return n, err
+		}
+		if len(allocs) == 0 {
+			continue // sudah diperpanjang / di-release proses lain
+		}
+		h.publishReleased(ctx, id, orders.ReleaseHoldExpired, allocs)
+		ids := make([]string, 0, len(allocs))
+		for _, a := range allocs {
+			ids = append(ids, a.ProductID)
+		}
+		h.Alerts.Check(ctx, ids...)
+		n++
+	}
+	return n, nil
+}
+
+// RunExpirySweeper: sweep berkala; batch penuh langsung diulang supaya backlog cepat habis.
+func (h *Holds) RunExpirySweeper(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			for {
+				n, err := h.SweepExpired(ctx)
+				if err != nil {
+					logx.FromContext(ctx).Error("hold sweep failed", "err", err)
+					break
+				}
+				if n > 0 {
+					logx.FromContext(ctx).Info("hold sweep released expired orders", "count", n)
+				}
+				if n < h.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (h *Holds) publishReleased(ctx context.Context, orderID, reason string, allocs []orders.Allocation) {
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     orders.EventStockReleased,
+		EventVersion:  1,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      h.ServiceName,
+		CorrelationID: orderID,
+		Payload:       kafkax.MustMarshal(orders.StockReleasedPayload{OrderID: orderID, Reason: reason, Allocations: allocs}),
+	}
+	h.Producer.Publish(ctx, orders.PartitionKey(orderID), kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventStockReleased)},
+		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
+	)
+}
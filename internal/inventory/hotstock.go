@@ -0,0 +1,356 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript: cek semua counter cukup lalu kurangi sekaligus (atomik), catat qty di hash inflight.
+// KEYS[1] = inflight, KEYS[2..n+1] = counter; ARGV[1] = ttl inflight (detik),
+// ARGV[2..n+1] = qty, ARGV[n+2..2n+1] = product_id.
+// Return {1} ok, {0, i, available} stok kurang, {-1, i} counter belum ada.
+var takeScript = redis.NewScript(`
+local n = #KEYS - 1
+for i = 1, n do
+  local v = redis.call('GET', KEYS[i+1])
+  if not v then return {-1, i} end
+  if tonumber(v) < tonumber(ARGV[i+1]) then return {0, i, tonumber(v)} end
+end
+for i = 1, n do
+  redis.call('DECRBY', KEYS[i+1], ARGV[i+1])
+  redis.call('HINCRBY', KEYS[1], ARGV[n+i+1], ARGV[i+1])
+end
+redis.call('EXPIRE', KEYS[1], ARGV[1])
+return {1}
+`)
+
+// doneScript: reservasi sudah diproses Postgres -> kurangi inflight; ARGV[1] = "1" kembalikan counter
+// (Postgres menolak / order sudah pernah diproses). Layout KEYS/ARGV sama dengan takeScript.
+var doneScript = redis.NewScript(`
+local n = #KEYS - 1
+for i = 1, n do
+  redis.call('HINCRBY', KEYS[1], ARGV[n+i+1], -tonumber(ARGV[i+1]))
+  if ARGV[1] == '1' and redis.call('EXISTS', KEYS[i+1]) == 1 then
+    redis.call('INCRBY', KEYS[i+1], ARGV[i+1])
+  end
+end
+return 1
+`)
+
+// syncScript: counter = products.stock - inflight. KEYS[1] = counter, KEYS[2] = inflight;
+// ARGV[1] = products.stock, ARGV[2] = product_id. Return {counter lama (-1 = belum ada), counter baru}.
+var syncScript = redis.NewScript(`
+local infl = tonumber(redis.call('HGET', KEYS[2], ARGV[2]) or '0')
+if infl < 0 then
+  redis.call('HDEL', KEYS[2], ARGV[2])
+  infl = 0
+end
+local want = tonumber(ARGV[1]) - infl
+if want < 0 then want = 0 end
+local cur = redis.call('GET', KEYS[1])
+redis.call('SET', KEYS[1], want)
+if cur then return {tonumber(cur), want} end
+return {-1, want}
+`)
+
+// HotStock: front reservasi berbasis Redis untuk produk hot (flash sale).
+//
+// Order ALL_OR_NOTHING yang berisi produk hot dicek dulu ke counter Redis (Lua, atomik): kalau kurang
+// langsung ditolak tanpa menyentuh Postgres. Kalau semua item order hot (kasus flash sale) dan OnWritten
+// dipasang, Reserve langsung kembali setelah counter berhasil diambil (ReserveResult.Pending) dan order
+// ditulis ke Postgres secara async oleh writer per shard (shard = produk hot pertama, jadi order untuk
+// SKU yang sama tidak saling berebut row lock). Order campuran hot + non-hot tetap menunggu Postgres
+// karena bagian non-hot hanya bisa dicek di sana.
+//
+// Postgres tetap sumber kebenaran: hasil order Pending baru diumumkan lewat OnWritten setelah write ke
+// Postgres selesai (reserved, ditolak karena drift, atau replay), jadi tidak ada event StockReserved
+// untuk reservasi yang belum tersimpan. Counter dikembalikan kalau Postgres tidak mencatat reservasi
+// baru, dan disinkronkan berkala ke products.stock - inflight (restock / release / drift). Redis error ->
+// fallback Postgres. Job yang masih antre hilang kalau proses crash: order tetap CREATED di Postgres
+// tanpa event apa pun (terlihat di "ordersctl order stuck", bisa di-reemit) dan counter pulih lewat Sync.
+//
+// Start wajib dipanggil sebelum Reserve; Flush menunggu antrean write async kosong (shutdown / test).
+type HotStock struct {
+	Redis   *redis.Client
+	Repo    *orders.ReservationRepo
+	Stock   *orders.InventoryRepo // sumber daftar produk hot + stok
+	Workers int                   // jumlah shard writer (default 4)
+	// OnWritten: hasil write async ke Postgres untuk order yang di-ack Pending; caller publish hasilnya di
+	// sini. res.Status kosong = ditolak Postgres (lihat res.Shortages); err = tetap gagal setelah
+	// writeAttempts, order tetap CREATED. nil = jalur async tidak dipakai (semua order lewat sync).
+	OnWritten func(ctx context.Context, req orders.ReserveRequest, res orders.ReserveResult, err error)
+
+	mu      sync.RWMutex
+	hot     map[string]bool
+	shards  []chan hotJob
+	pending sync.WaitGroup // job async yang belum selesai ditulis
+}
+
+type hotJob struct {
+	ctx   context.Context
+	req   orders.ReserveRequest
+	items []orders.ItemQty // bagian hot yang sudah diambil dari counter
+	out   chan hotResult   // nil = async (hasil lewat OnWritten)
+}
+
+type hotResult struct {
+	res orders.ReserveResult
+	err error
+}
+
+// errNoCounter: counter produk hot belum di-seed.
+var errNoCounter = errors.New("hotstock counter missing")
+
+// Start: load produk hot + seed counter, lalu jalankan writer. Writer tidak berhenti saat ctx selesai
+// supaya antrean async tetap bisa di-Flush waktu shutdown.
+func (h *HotStock) Start(ctx context.Context) {
+	if err := h.Sync(ctx); err != nil {
+		logx.FromContext(ctx).Error("hotstock sync failed", "err", err)
+	}
+	n := h.Workers
+	if n <= 0 {
+		n = 4
+	}
+	h.shards = make([]chan hotJob, n)
+	for i := range h.shards {
+		ch := make(chan hotJob, 256)
+		h.shards[i] = ch
+		go h.writer(ch)
+	}
+}
+
+// Flush: tunggu semua write async yang sudah di-ack selesai (atau ctx habis).
+func (h *HotStock) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reserve: pengganti ReservationRepo.Reserve untuk service inventory.
+func (h *HotStock) Reserve(ctx context.Context, req orders.ReserveRequest) (orders.ReserveResult, error) {
+	if req.Policy.OrDefault() != orders.FulfilAllOrNothing {
+		return h.Repo.Reserve(ctx, req) // partial/backorder butuh angka persis per gudang
+	}
+	items := h.hotItems(req.Items)
+	if len(items) == 0 {
+		return h.Repo.Reserve(ctx, req)
+	}
+	async := h.OnWritten != nil && len(items) == countDistinct(req.Items)
+	if async {
+		// order yang sudah pernah diproses (event dikirim ulang) lewat jalur sync supaya hasilnya
+		// dibaca ulang dari Postgres; SELECT biasa, tidak menunggu row lock produk
+		st, err := (&orders.Repo{DB: h.Repo.DB}).GetOrderStatus(ctx, req.OrderID)
+		if err != nil {
+			return orders.ReserveResult{}, err
+		}
+		async = st == orders.StatusCreated
+	}
+
+	short, err := h.take(ctx, items)
+	if errors.Is(err, errNoCounter) {
+		if err = h.Sync(ctx); err == nil {
+			short, err = h.take(ctx, items)
+		}
+	}
+	if err != nil {
+		logx.FromContext(ctx).Warn("hotstock fallback to postgres", "err", err)
+		return h.Repo.Reserve(ctx, req)
+	}
+	if short != nil {
+		return orders.ReserveResult{Shortages: []orders.StockRejectedDetail{*short}}, nil
+	}
+
+	job := hotJob{ctx: context.WithoutCancel(ctx), req: req, items: items}
+	if !async {
+		job.out = make(chan hotResult, 1)
+	}
+	h.pending.Add(1)
+	select {
+	case h.shards[shardOf(items[0].ProductID, len(h.shards))] <- job:
+	case <-ctx.Done():
+		h.pending.Done()
+		_ = h.done(context.Background(), items, true)
+		return orders.ReserveResult{}, ctx.Err()
+	}
+	if async {
+		// Allocations belum diketahui (gudang dipilih saat write ke Postgres)
+		return orders.ReserveResult{Status: orders.StatusStockReserved, Reserved: items, Pending: true}, nil
+	}
+	select {
+	case r := <-job.out:
+		return r.res, r.err
+	case <-ctx.Done():
+		return orders.ReserveResult{}, ctx.Err() // writer tetap menyelesaikan job (termasuk done)
+	}
+}
+
+// writeAttempts: berapa kali write async dicoba sebelum order dibiarkan CREATED.
+const writeAttempts = 3
+
+func (h *HotStock) writer(jobs <-chan hotJob) {
+	for j := range jobs {
+		res, err := h.Repo.Reserve(j.ctx, j.req)
+		for attempt := 1; err != nil && j.out == nil && attempt < writeAttempts; attempt++ {
+			time.Sleep(time.Duration(attempt*attempt) * 50 * time.Millisecond)
+			res, err = h.Repo.Reserve(j.ctx, j.req)
+		}
+		// counter dikembalikan kalau tidak ada reservasi baru di Postgres
+		restore := err != nil || res.Status == "" || res.Replayed
+		dctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if derr := h.done(dctx, j.items, restore); derr != nil {
+			logx.FromContext(j.ctx).Error("hotstock done failed", "err", derr)
+		}
+		cancel()
+		if j.out != nil {
+			j.out <- hotResult{res: res, err: err}
+		} else {
+			if err != nil {
+				logx.FromContext(j.ctx).Error("hotstock async write failed, order left CREATED", "order_id", j.req.OrderID, "err", err)
+			} else if res.Status == "" {
+				logx.FromContext(j.ctx).Warn("hotstock async write rejected by postgres", "order_id", j.req.OrderID, "shortages", len(res.Shortages))
+			}
+			h.OnWritten(j.ctx, j.req, res, err)
+		}
+		h.pending.Done()
+	}
+}
+
+// take: ambil qty dari counter. short != nil = stok Redis kurang.
+func (h *HotStock) take(ctx context.Context, items []orders.ItemQty) (*orders.StockRejectedDetail, error) {
+	keys, args := h.scriptArgs(items)
+	args[0] = int(redisx.TTLHotInflight.Get().Seconds())
+	v, err := takeScript.Run(ctx, h.Redis, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	switch v[0] {
+	case 1:
+		return nil, nil
+	case 0:
+		it := items[v[1]-1]
+		return &orders.StockRejectedDetail{ProductID: it.ProductID, Required: it.Qty, Available: int(v[2])}, nil
+	default:
+		return nil, errNoCounter
+	}
+}
+
+func (h *HotStock) done(ctx context.Context, items []orders.ItemQty, restore bool) error {
+	keys, args := h.scriptArgs(items)
+	args[0] = "0"
+	if restore {
+		args[0] = "1"
+	}
+	return doneScript.Run(ctx, h.Redis, keys, args...).Err()
+}
+
+func (h *HotStock) scriptArgs(items []orders.ItemQty) ([]string, []any) {
+	keys := make([]string, 0, len(items)+1)
+	keys = append(keys, redisx.KeyHotInflight)
+	args := make([]any, 1, 2*len(items)+1)
+	for _, it := range items {
+		keys = append(keys, fmt.Sprintf(redisx.KeyHotStock, it.ProductID))
+		args = append(args, it.Qty)
+	}
+	for _, it := range items {
+		args = append(args, it.ProductID)
+	}
+	return keys, args
+}
+
+// Sync: reload daftar produk hot dan samakan counter dengan products.stock - inflight.
+// Counter produk yang tidak lagi hot dihapus.
+func (h *HotStock) Sync(ctx context.Context) error {
+	stock, err := h.Stock.HotProducts(ctx)
+	if err != nil {
+		return err
+	}
+	hot := make(map[string]bool, len(stock))
+	for id, st := range stock {
+		hot[id] = true
+		v, err := syncScript.Run(ctx, h.Redis,
+			[]string{fmt.Sprintf(redisx.KeyHotStock, id), redisx.KeyHotInflight}, st, id).Int64Slice()
+		if err != nil {
+			return err
+		}
+		if v[0] >= 0 && v[0] != v[1] {
+			logx.FromContext(ctx).Warn("hotstock drift", "product_id", id, "redis", v[0], "want", v[1])
+		}
+	}
+
+	h.mu.Lock()
+	prev := h.hot
+	h.hot = hot
+	h.mu.Unlock()
+	for id := range prev {
+		if !hot[id] {
+			_ = h.Redis.Del(ctx, fmt.Sprintf(redisx.KeyHotStock, id)).Err()
+		}
+	}
+	return nil
+}
+
+// RunReconciler: Sync berkala.
+func (h *HotStock) RunReconciler(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := h.Sync(ctx); err != nil {
+				logx.FromContext(ctx).Error("hotstock sync failed", "err", err)
+			}
+		}
+	}
+}
+
+// hotItems: item produk hot (qty digabung per product_id), urut product_id.
+func (h *HotStock) hotItems(items []orders.ItemQty) []orders.ItemQty {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	qty := map[string]int{}
+	for _, it := range items {
+		if h.hot[it.ProductID] {
+			qty[it.ProductID] += it.Qty
+		}
+	}
+	out := make([]orders.ItemQty, 0, len(qty))
+	for id, q := range qty {
+		out = append(out, orders.ItemQty{ProductID: id, Qty: q})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProductID < out[j].ProductID })
+	return out
+}
+
+func countDistinct(items []orders.ItemQty) int {
+	seen := make(map[string]bool, len(items))
+	for _, it := range items {
+		seen[it.ProductID] = true
+	}
+	return len(seen)
+}
+
+func shardOf(key string, n int) int {
+	f := fnv.New32a()
+	_, _ = f.Write([]byte(key))
+	return int(f.Sum32() % uint32(n))
+}
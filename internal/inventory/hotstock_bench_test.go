@@ -0,0 +1,116 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/postgres"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BenchmarkReserve: reservasi satu SKU panas lewat Postgres langsung vs HotStock (Redis + write async).
+// Butuh DB yang sudah di-migrate + seed (warehouse) dan Redis:
+//
+//	BENCH_POSTGRES_DSN=postgres://... BENCH_REDIS_ADDR=localhost:6379 go test -run x -bench Reserve ./internal/inventory
+//
+// Waktu jalur redis termasuk Flush (semua order sudah tertulis di Postgres); latensi ack saja
+// dilaporkan sebagai ack-ns/op.
+func BenchmarkReserve(b *testing.B) {
+	dsn, addr := os.Getenv("BENCH_POSTGRES_DSN"), os.Getenv("BENCH_REDIS_ADDR")
+	if dsn == "" || addr == "" {
+		b.Skip("BENCH_POSTGRES_DSN / BENCH_REDIS_ADDR not set")
+	}
+	cfg := config.Default()
+	cfg.Postgres.DSN = dsn
+	cfg.Redis.Addr = addr
+	ctx := context.Background()
+	db, err := postgres.Connect(ctx, cfg.Postgres)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	rdb := redisx.New(cfg.Redis)
+	defer rdb.Close()
+
+	b.Run("postgres", func(b *testing.B) {
+		repo := &orders.ReservationRepo{DB: db, HoldTTL: time.Hour}
+		reqs := benchOrders(b, db, false)
+		benchReserve(b, reqs, repo.Reserve, nil)
+	})
+	b.Run("redis", func(b *testing.B) {
+		hs := &HotStock{Redis: rdb, Repo: &orders.ReservationRepo{DB: db, HoldTTL: time.Hour}, Stock: &orders.InventoryRepo{DB: db}}
+		hs.OnWritten = func(_ context.Context, req orders.ReserveRequest, res orders.ReserveResult, err error) {
+			if err != nil || res.Status == "" {
+				b.Errorf("order %s not written: status=%q err=%v", req.OrderID, res.Status, err)
+			}
+		}
+		reqs := benchOrders(b, db, true)
+		hs.Start(ctx)
+		benchReserve(b, reqs, hs.Reserve, hs.Flush)
+	})
+}
+
+// benchOrders: produk baru dengan stok b.N + b.N order berisi produk itu saja (di luar timer).
+func benchOrders(b *testing.B, db *pgxpool.Pool, hot bool) []orders.ReserveRequest {
+	b.Helper()
+	ctx := context.Background()
+	cat := &orders.CatalogRepo{DB: db}
+	tag := uuid.NewString()[:8]
+	p, err := cat.CreateProduct(ctx, orders.NewProduct{SKU: "BENCH-" + tag, Name: "bench", PriceCents: 100, Stock: b.N}, "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	if hot {
+		on := true
+		if _, err := cat.UpdateProduct(ctx, p.ID, p.Version, orders.ProductPatch{Hot: &on}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	repo := &orders.Repo{DB: db}
+	reqs := make([]orders.ReserveRequest, b.N)
+	for i := range reqs {
+		created, err := repo.CreateOrderTx(ctx, fmt.Sprintf("BENCH-%s-%d", tag, i), uuid.NewString(),
+			[]orders.ItemInput{{ProductID: p.ID, Qty: 1}}, orders.PricingOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		reqs[i] = orders.ReserveRequest{OrderID: created.OrderID, Items: []orders.ItemQty{{ProductID: p.ID, Qty: 1}}}
+	}
+	return reqs
+}
+
+func benchReserve(b *testing.B, reqs []orders.ReserveRequest,
+	reserve func(context.Context, orders.ReserveRequest) (orders.ReserveResult, error), flush func(context.Context) error) {
+	ctx := context.Background()
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := reqs[next.Add(1)-1]
+			res, err := reserve(ctx, req)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			if res.Status == "" {
+				b.Errorf("order %s rejected", req.OrderID)
+				return
+			}
+		}
+	})
+	if flush != nil {
+		ack := b.Elapsed()
+		if err := flush(ctx); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(ack.Nanoseconds())/float64(b.N), "ack-ns/op")
+	}
+}
@@ -0,0 +1,31 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+// RunReconciler: cek berkala products.stock vs ledger, log setiap produk yang drift.
+func RunReconciler(ctx context.Context, repo *orders.InventoryRepo, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			drifts, err := repo.Reconcile(ctx)
+			if err != nil {
+				logx.FromContext(ctx).Error("reconcile failed", "err", err)
+				continue
+			}
+			for _, d := range drifts {
+				logx.FromContext(ctx).Warn("stock drift",
+					"sku", d.SKU, "product_id", d.ProductID, "stock", d.Stock, "ledger", d.LedgerStock, "diff", d.Diff)
+			}
+		}
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
 	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
 	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	kafkago "github.com/segmentio/kafka-go"
+	"strconv"
 	"time"
 )
 
@@ -19,6 +23,13 @@ type Service struct {
 	ProducerOK     *kafkax.Producer // publish stock.reserved
 	ProducerReject *kafkax.Producer // publish stock.rejected
 	ServiceName    string
+	Hot            *HotStock     // opsional: front Redis untuk produk hot (nil = langsung Postgres)
+	HotEnabled     func() bool   // opsional: toggle live untuk Hot (nil = selalu aktif kalau Hot != nil)
+	Alerts         *StockWatcher // opsional: StockLow / StockDepleted setelah reservasi
+	// opsional: order yang lolos flag flags.AllocationRollout direservasi dengan RolloutStrategy
+	// (sisanya tetap strategy repo). Keduanya harus diisi supaya aktif.
+	Flags           *flags.Client
+	RolloutStrategy orders.AllocationStrategy
 }
 
 // HandleOrderCreated: dipasang sebagai handler consumer.
@@ -38,13 +49,14 @@ func (s *Service) HandleOrderCreated(ctx context.Context, m kafkago.Message) err
 	if exists {
 		return nil
 	}
-	_ = s.Redis.Set(ctx, dkey, "1", redisx.TTLDedup).Err()
+	_ = s.Redis.Set(ctx, dkey, "1", redisx.TTLDedup.Get()).Err()
 
 	// 3) decode payload
 	var p orders.OrderCreatedPayload
 	if err := json.Unmarshal(env.Payload, &p); err != nil {
 		return err
 	}
+	ctx, log := logx.With(ctx, "order_id", p.OrderID)
 
 	// Siapkan daftar item qty (abaikan price)
 	items := make([]orders.ItemQty, 0, len(p.Items))
@@ -53,44 +65,172 @@ func (s *Service) HandleOrderCreated(ctx context.Context, m kafkago.Message) err
 	}
 
 	// 4) idempotent short-circuit: kalau sudah di-reserve sebelumnya
-	if ok, _ := s.Repo.SudahReserved(ctx, p.OrderID, len(items)); ok {
+	if ok, _ := s.Repo.SudahReserved(ctx, p.OrderID, countProducts(items)); ok {
 		// publish reserved lagi (event ulang tidak masalah)
-		return s.publishReserved(ctx, p.OrderID, items, env.TraceID)
+		allocs, err := s.Repo.Allocations(ctx, p.OrderID)
+		if err != nil {
+			return err
+		}
+		return s.publishReserved(ctx, orders.StockReservedPayload{OrderID: p.OrderID, Items: items, Allocations: allocs}, env.TraceID)
 	}
 
-	// 5) coba reserve atomik
-	ok, details, err := s.Repo.ReserveAll(ctx, p.OrderID, items)
+	// 5) coba reserve (gudang dipilih allocation strategy repo / rollout; stok kurang ditangani sesuai policy)
+	req := orders.ReserveRequest{OrderID: p.OrderID, Region: p.Region, Policy: p.FulfilmentPolicy, Items: items, TraceID: env.TraceID}
+	if s.Flags != nil && s.RolloutStrategy != nil &&
+		s.Flags.Enabled(ctx, flags.AllocationRollout, flags.Subject{UserID: p.UserID, ExternalID: p.ExternalID}, false) {
+		req.Strategy = s.RolloutStrategy
+		log.Debug("allocation rollout", "strategy", s.RolloutStrategy.Name())
+	}
+	res, err := s.reserve(ctx, req)
 	if err != nil {
 		return err
 	}
+	if res.Pending {
+		log.Info("order accepted by hotstock, waiting for postgres write")
+		return nil // hasil dipublish HotWritten setelah tersimpan di Postgres
+	}
+	return s.publishResult(ctx, req, res)
+}
 
-	if ok {
-		return s.publishReserved(ctx, p.OrderID, items, env.TraceID)
+// publishResult: StockReserved / StockRejected sesuai hasil reservasi yang sudah tersimpan di Postgres.
+func (s *Service) publishResult(ctx context.Context, req orders.ReserveRequest, res orders.ReserveResult) error {
+	log := logx.FromContext(ctx)
+	if res.Status == "" {
+		// gagal stok → publish rejected (+details)
+		log.Info("order rejected: out of stock", "shortages", len(res.Shortages))
+		return s.publishRejected(ctx, req.OrderID, res.Shortages, req.TraceID)
+	}
+	if res.Replayed && res.Status != orders.StatusStockReserved && res.Status != orders.StatusPartiallyReserved {
+		return nil // order sudah lanjut (PAID / FAILED / ...), tidak perlu publish ulang
 	}
-	// gagal stok → publish rejected (+details)
-	return s.publishRejected(ctx, p.OrderID, details, env.TraceID)
+	if !res.Replayed {
+		s.Alerts.Check(ctx, productIDs(res.Reserved)...)
+	}
+	out := orders.StockReservedPayload{OrderID: req.OrderID, Items: res.Reserved, Allocations: res.Allocations}
+	if res.Status == orders.StatusPartiallyReserved {
+		out.Status, out.Backordered, out.Cancelled, out.TotalCents = res.Status, res.Backordered, res.Cancelled, res.TotalCents
+		out.Currency = res.Currency
+	}
+	log.Info("order reserved", "status", string(res.Status), "replayed", res.Replayed)
+	return s.publishReserved(ctx, out, req.TraceID)
 }
 
-func (s *Service) publishReserved(ctx context.Context, orderID string, items []orders.ItemQty, trace string) error {
+// HotWritten: dipasang ke HotStock.OnWritten. Order Pending belum dapat event apa pun; hasilnya baru
+// dipublish di sini setelah write ke Postgres. Error tulis: order tetap CREATED tanpa event dan ditangani
+// operator (ordersctl order stuck / reemit).
+func (s *Service) HotWritten(ctx context.Context, req orders.ReserveRequest, res orders.ReserveResult, err error) {
+	if err != nil {
+		return
+	}
+	_ = s.publishResult(ctx, req, res)
+}
+
+func (s *Service) reserve(ctx context.Context, req orders.ReserveRequest) (orders.ReserveResult, error) {
+	if s.Hot != nil && (s.HotEnabled == nil || s.HotEnabled()) {
+		return s.Hot.Reserve(ctx, req)
+	}
+	return s.Repo.Reserve(ctx, req)
+}
+
+// HandleStockAdjusted: restock (delta > 0) -> isi backorder produk tsb; setiap order yang terisi
+// dapat StockReserved baru (Status STOCK_RESERVED kalau backorder-nya sudah habis).
+func (s *Service) HandleStockAdjusted(ctx context.Context, m kafkago.Message) error {
+	var env orders.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil {
+		return err
+	}
+	if env.EventType != orders.EventStockAdjusted {
+		return nil
+	}
+	var p orders.StockAdjustedPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return err
+	}
+	if p.Delta <= 0 {
+		return nil
+	}
+	return s.fillBackorders(ctx, p.ProductID, env.TraceID)
+}
+
+// HandleStockReleased: reservasi yang dilepas (hold expired, release manual) juga mengembalikan stok,
+// jadi backorder produk-produknya diisi seperti restock.
+func (s *Service) HandleStockReleased(ctx context.Context, m kafkago.Message) error {
+	var env orders.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil {
+		return err
+	}
+	if env.EventType != orders.EventStockReleased {
+		return nil
+	}
+	var p orders.StockReleasedPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, a := range p.Allocations {
+		if a.Qty <= 0 || seen[a.ProductID] {
+			continue
+		}
+		seen[a.ProductID] = true
+		if err := s.fillBackorders(ctx, a.ProductID, env.TraceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillBackorders: isi backorder satu produk dari stok yang ada lalu publish StockReserved per order.
+func (s *Service) fillBackorders(ctx context.Context, productID, trace string) error {
+	fills, err := s.Repo.FillBackorders(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if len(fills) > 0 {
+		s.Alerts.Check(ctx, productID)
+	}
+	for _, f := range fills {
+		st := orders.StatusPartiallyReserved
+		if f.Complete {
+			st = orders.StatusStockReserved
+		}
+		logx.FromContext(ctx).Info("backorder filled", "order_id", f.OrderID, "product_id", productID, "status", string(st))
+		payload := orders.StockReservedPayload{OrderID: f.OrderID, Allocations: f.Allocations, Status: st}
+		for _, a := range f.Allocations {
+			payload.Items = append(payload.Items, orders.ItemQty{ProductID: a.ProductID, Qty: a.Qty})
+		}
+		if err := s.publishReserved(ctx, payload, trace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) publishReserved(ctx context.Context, payload orders.StockReservedPayload, trace string) error {
+	status := payload.Status
+	if status == "" {
+		status = orders.StatusStockReserved
+	}
+	metrics.OrdersReserved.WithLabelValues(string(status)).Inc()
 	ev := orders.Envelope{
 		EventID:       uuid.NewString(),
 		EventType:     orders.EventStockReserved,
-		EventVersion:  1,
+		EventVersion:  orders.VersionStockReserved,
 		OccurredAt:    time.Now().UTC(),
 		Producer:      s.ServiceName,
 		TraceID:       trace,
-		CorrelationID: orderID,
-		Payload:       kafkax.MustMarshal(orders.StockReservedPayload{OrderID: orderID, Items: items}),
+		CorrelationID: payload.OrderID,
+		Payload:       kafkax.MustMarshal(payload),
 	}
 	b := kafkax.MustMarshal(ev)
-	s.ProducerOK.Publish(orders.PartitionKey(orderID), b,
+	s.ProducerOK.Publish(ctx, orders.PartitionKey(payload.OrderID), b,
 		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventStockReserved)},
-		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
+		kafkago.Header{Key: "x-event-version", Value: []byte(strconv.Itoa(orders.VersionStockReserved))},
 	)
 	return nil
 }
 
 func (s *Service) publishRejected(ctx context.Context, orderID string, details []orders.StockRejectedDetail, trace string) error {
+	metrics.OrdersRejected.Inc()
 	ev := orders.Envelope{
 		EventID:       uuid.NewString(),
 		EventType:     orders.EventStockRejected,
@@ -104,9 +244,25 @@ func (s *Service) publishRejected(ctx context.Context, orderID string, details [
 		}),
 	}
 	b := kafkax.MustMarshal(ev)
-	s.ProducerReject.Publish(orders.PartitionKey(orderID), b,
+	s.ProducerReject.Publish(ctx, orders.PartitionKey(orderID), b,
 		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventStockRejected)},
 		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
 	)
 	return nil
 }
+
+func productIDs(items []orders.ItemQty) []string {
+	ids := make([]string, 0, len(items))
+	for _, it := range items {
+		ids = append(ids, it.ProductID)
+	}
+	return ids
+}
+
+func countProducts(items []orders.ItemQty) int {
+	seen := make(map[string]bool, len(items))
+	for _, it := range items {
+		seen[it.ProductID] = true
+	}
+	return len(seen)
+}
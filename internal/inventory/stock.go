@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// StockAdmin: restock / adjustment manual lewat admin API. Setiap perubahan tercatat di
+// inventory_movements (InventoryRepo) lalu di-publish sebagai StockAdjusted.
+type StockAdmin struct {
+	Repo        *orders.InventoryRepo
+	Producer    *kafkax.Producer // publish inventory.stock.adjusted
+	ServiceName string
+	Alerts      *StockWatcher // threshold reorder + StockLow / StockDepleted
+}
+
+func (s *StockAdmin) Restock(ctx context.Context, productID, warehouseID string, qty int, reason, actor, trace string) (orders.Movement, error) {
+	m, err := s.Repo.Restock(ctx, productID, warehouseID, qty, reason, actor)
+	if err != nil {
+		return orders.Movement{}, err
+	}
+	s.publishAdjusted(ctx, m, trace)
+	s.Alerts.Check(ctx, productID)
+	return m, nil
+}
+
+func (s *StockAdmin) Adjust(ctx context.Context, productID, warehouseID string, delta int, kind, reason, actor, trace string) (orders.Movement, error) {
+	m, err := s.Repo.Adjust(ctx, productID, warehouseID, delta, kind, reason, actor)
+	if err != nil {
+		return orders.Movement{}, err
+	}
+	s.publishAdjusted(ctx, m, trace)
+	s.Alerts.Check(ctx, productID)
+	return m, nil
+}
+
+func (s *StockAdmin) Movements(ctx context.Context, productID string, limit int) ([]orders.Movement, error) {
+	return s.Repo.Movements(ctx, productID, limit)
+}
+
+func (s *StockAdmin) Reconcile(ctx context.Context) ([]orders.Drift, error) {
+	return s.Repo.Reconcile(ctx)
+}
+
+// SetThreshold: set threshold reorder lalu langsung evaluasi (bisa langsung LOW).
+func (s *StockAdmin) SetThreshold(ctx context.Context, productID string, threshold int) (orders.StockAlert, error) {
+	if err := s.Alerts.Repo.SetThreshold(ctx, productID, threshold); err != nil {
+		return orders.StockAlert{}, err
+	}
+	s.Alerts.Check(ctx, productID)
+	return s.Alerts.Repo.Get(ctx, productID)
+}
+
+func (s *StockAdmin) RemoveThreshold(ctx context.Context, productID string) error {
+	return s.Alerts.Repo.RemoveThreshold(ctx, productID)
+}
+
+// LowStock: produk yang sedang LOW / DEPLETED.
+func (s *StockAdmin) LowStock(ctx context.Context) ([]orders.StockAlert, error) {
+	return s.Alerts.Repo.Active(ctx)
+}
+
+func (s *StockAdmin) publishAdjusted(ctx context.Context, m orders.Movement, trace string) {
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     orders.EventStockAdjusted,
+		EventVersion:  1,
+		OccurredAt:    time.Now().UTC(),
+		Producer:      s.ServiceName,
+		TraceID:       trace,
+		CorrelationID: m.ProductID,
+		Payload: kafkax.MustMarshal(orders.StockAdjustedPayload{
+			MovementID: m.ID, ProductID: m.ProductID, WarehouseID: deref(m.WarehouseID), Kind: m.Kind, Delta: m.Delta,
+			StockAfter: m.StockAfter, Reason: m.Reason, Actor: m.Actor,
+		}),
+	}
+	s.Producer.Publish(ctx, []byte(m.ProductID), kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventStockAdjusted)},
+		kafkago.Header{Key: "x-event-version", Value: []byte("1")},
+	)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -2,17 +2,35 @@ package kafka
 
 import (
 	"context"
-	"github.com/segmentio/kafka-go"
-	"log"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"sync"
 	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handler harus return nil hanya jika proses sukses & boleh commit offset.
 type Handler func(ctx context.Context, m kafka.Message) error
 
 type Consumer struct {
-	r       *kafka.Reader
-	workers int
+	r            *kafka.Reader
+	workers      int
+	topic, group string // label metrics
+
+	mu  sync.Mutex
+	lag map[int]int64 // partition -> lag terakhir (dibaca health check)
+
+	poolMu sync.Mutex
+	quits  []chan struct{}            // satu per worker aktif
+	spawn  func(quit <-chan struct{}) // diisi Start; nil = belum jalan
 }
 
 func NewConsumer(brokers []string, group, topic string, workers int) *Consumer {
@@ -27,30 +45,146 @@ func NewConsumer(brokers []string, group, topic string, workers int) *Consumer {
 	if workers <= 0 {
 		workers = 1
 	}
-	return &Consumer{r: r, workers: workers}
+	return &Consumer{r: r, workers: workers, topic: topic, group: group, lag: map[int]int64{}}
+}
+
+// Lag: total lag semua partition yang pernah dibaca consumer ini.
+func (c *Consumer) Lag() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for _, l := range c.lag {
+		n += l
+	}
+	return n
+}
+
+// startSpan: span consumer sebagai child dari traceparent di header pesan (kalau ada).
+func (c *Consumer) startSpan(ctx context.Context, m kafka.Message) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(tracing.Extract(ctx, m.Headers), "process "+c.topic,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(c.topic),
+			semconv.MessagingConsumerGroupName(c.group),
+			semconv.MessagingDestinationPartitionID(strconv.Itoa(m.Partition)),
+			semconv.MessagingKafkaOffset(int(m.Offset)),
+		))
+}
+
+// withLogger: logger per pesan (topic, group, partition, offset, key, event_type, event_id) di
+// context handler. event_id diambil dari envelope; pesan yang bukan envelope tetap diproses.
+func (c *Consumer) withLogger(ctx context.Context, m kafka.Message) (context.Context, *slog.Logger) {
+	args := []any{
+		"topic", c.topic, "group", c.group, "partition", m.Partition, "offset", m.Offset, "key", string(m.Key),
+	}
+	for _, h := range m.Headers {
+		if h.Key == "x-event-type" {
+			args = append(args, "event_type", string(h.Value))
+		}
+	}
+	var env struct {
+		EventID string `json:"event_id"`
+	}
+	if json.Unmarshal(m.Value, &env) == nil && env.EventID != "" {
+		args = append(args, "event_id", env.EventID)
+	}
+	return logx.With(ctx, args...)
+}
+
+// SetWorkers: ubah jumlah worker saat consumer berjalan (hot-reload, lihat internal/settings).
+// Worker yang dihentikan menyelesaikan pesan yang sedang diproses dulu. Sebelum Start cukup
+// mengganti jumlah awal.
+func (c *Consumer) SetWorkers(n int) {
+	if n <= 0 {
+		n = 1
+	}
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	c.workers = n
+	if c.spawn == nil {
+		return
+	}
+	for len(c.quits) < n {
+		q := make(chan struct{})
+		c.quits = append(c.quits, q)
+		c.spawn(q)
+	}
+	for len(c.quits) > n {
+		close(c.quits[len(c.quits)-1])
+		c.quits = c.quits[:len(c.quits)-1]
+	}
+}
+
+func (c *Consumer) Workers() int {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+	return c.workers
 }
 
 func (c *Consumer) Start(ctx context.Context, h Handler) error {
 	defer c.r.Close()
 
 	jobs := make(chan kafka.Message, 1024)
-	errs := make(chan error, c.workers)
-
-	// workers
-	for i := 0; i < c.workers; i++ {
-		go func(id int) {
-			for m := range jobs {
-				if err := h(ctx, m); err != nil {
-					errs <- err
-					continue
-				}
-				// commit on success
-				if err := c.r.CommitMessages(ctx, m); err != nil {
-					errs <- err
+	errs := make(chan error, 1) // sinyal backoff untuk dispatcher, error-nya sudah di-log worker
+
+	duration := metrics.KafkaHandlerDuration.WithLabelValues(c.topic, c.group)
+	handlerErrs := metrics.KafkaHandlerErrors.WithLabelValues(c.topic, c.group, "handler")
+	commitErrs := metrics.KafkaHandlerErrors.WithLabelValues(c.topic, c.group, "commit")
+	fail := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+
+	process := func(m kafka.Message) {
+		start := time.Now()
+		mctx, span := c.startSpan(ctx, m)
+		mctx, log := c.withLogger(mctx, m)
+		err := h(mctx, m)
+		elapsed := time.Since(start)
+		duration.Observe(elapsed.Seconds())
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		if err != nil {
+			handlerErrs.Inc()
+			log.Error("kafka handler failed", "err", err, "duration_ms", elapsed.Milliseconds())
+			fail(err)
+			return
+		}
+		// commit on success
+		if err := c.r.CommitMessages(ctx, m); err != nil {
+			commitErrs.Inc()
+			log.Error("kafka commit failed", "err", err)
+			fail(err)
+			return
+		}
+		log.Info("kafka message processed", "duration_ms", elapsed.Milliseconds())
+	}
+
+	// workers (jumlahnya bisa diubah lewat SetWorkers)
+	c.poolMu.Lock()
+	c.spawn = func(quit <-chan struct{}) {
+		go func() {
+			for {
+				select {
+				case <-quit:
+					return
+				case m, ok := <-jobs:
+					if !ok {
+						return
+					}
+					process(m)
 				}
 			}
-		}(i)
+		}()
 	}
+	c.poolMu.Unlock()
+	c.SetWorkers(c.Workers())
 
 	// dispatcher loop
 	for {
@@ -65,6 +199,12 @@ func (c *Consumer) Start(ctx context.Context, h Handler) error {
 				return err
 			}
 		}
+		// lag dari sisi reader: pesan yang sudah ada di partition tapi belum dibaca
+		lag := m.HighWaterMark - m.Offset - 1
+		metrics.KafkaConsumerLag.WithLabelValues(c.topic, c.group, strconv.Itoa(m.Partition)).Set(float64(lag))
+		c.mu.Lock()
+		c.lag[m.Partition] = lag
+		c.mu.Unlock()
 		select {
 		case jobs <- m:
 		case <-ctx.Done():
@@ -74,8 +214,7 @@ func (c *Consumer) Start(ctx context.Context, h Handler) error {
 
 		// non-blocking drain error agar tidak deadlock
 		select {
-		case e := <-errs:
-			log.Printf("worker error: %v", e)
+		case <-errs:
 			time.Sleep(200 * time.Millisecond) // backoff ringan
 		default:
 		}
@@ -2,14 +2,24 @@ package kafka
 
 import (
 	"context"
-	"github.com/segmentio/kafka-go"
+	"log/slog"
+	"sync"
 	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Producer struct {
-	w       *kafka.Writer
-	inbox   chan kafka.Message
-	closeCh chan struct{}
+	w         *kafka.Writer
+	inbox     chan kafka.Message
+	closeOnce sync.Once
+	closeCh   chan struct{}
+	depth     prometheus.Gauge // isi inbox saat ini (metrics.KafkaInboxDepth)
 }
 
 func NewProducer(brokers []string, topic string, buf int) *Producer {
@@ -19,47 +29,117 @@ func NewProducer(brokers []string, topic string, buf int) *Producer {
 			Topic:        topic,
 			Balancer:     &kafka.Hash{},
 			RequiredAcks: kafka.RequireAll,
-			Async:        true, // fire-and-forget untuk throughput; log error di loop
+			Async:        true, // fire-and-forget untuk throughput; hasil tulis dicatat di completion
+			Completion:   completion(topic),
 		},
 		inbox:   make(chan kafka.Message, buf),
 		closeCh: make(chan struct{}),
+		depth:   metrics.KafkaInboxDepth.WithLabelValues(topic),
+	}
+}
+
+// completion: dipanggil writer async setelah satu batch selesai ditulis (sukses atau gagal).
+func completion(topic string) func([]kafka.Message, error) {
+	published := metrics.KafkaPublished.WithLabelValues(topic)
+	failed := metrics.KafkaPublishErrors.WithLabelValues(topic)
+	latency := metrics.KafkaBatchLatency.WithLabelValues(topic)
+	return func(msgs []kafka.Message, err error) {
+		if len(msgs) == 0 {
+			return
+		}
+		oldest := msgs[0].Time
+		for _, m := range msgs[1:] {
+			if m.Time.Before(oldest) {
+				oldest = m.Time
+			}
+		}
+		latency.Observe(time.Since(oldest).Seconds())
+		if err != nil {
+			failed.Add(float64(len(msgs)))
+			slog.Error("kafka publish failed, messages dropped", "topic", topic, "count", len(msgs), "err", err)
+			return
+		}
+		published.Add(float64(len(msgs)))
 	}
 }
 
 func (p *Producer) Start(ctx context.Context) {
 	go func() {
+		defer close(p.closeCh)
 		for {
 			select {
 			case <-ctx.Done():
-				close(p.inbox)
+				p.Close()
 				for m := range p.inbox {
 					_ = p.w.WriteMessages(context.Background(), m)
 				}
 				_ = p.w.Close()
-				close(p.closeCh)
 				return
 			case m, ok := <-p.inbox:
 				if !ok {
 					_ = p.w.Close()
 					return
 				}
-				_ = p.w.WriteMessages(context.Background(), m) // TODO: handle/log error
+				p.depth.Set(float64(len(p.inbox)))
+				_ = p.w.WriteMessages(context.Background(), m) // async: error dilaporkan lewat completion
 			}
 		}
 	}()
 }
 
-func (p *Producer) Publish(key, value []byte, headers ...kafka.Header) {
+// Publish: span producer dari ctx di-inject ke header (traceparent) supaya consumer melanjutkan trace
+// yang sama. Span selesai saat pesan masuk inbox; hasil tulis ke broker ada di metrics.
+func (p *Producer) Publish(ctx context.Context, key, value []byte, headers ...kafka.Header) {
+	ctx, span := tracing.Tracer().Start(ctx, "publish "+p.w.Topic,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(p.w.Topic),
+			semconv.MessagingKafkaMessageKey(string(key)),
+		))
+	defer span.End()
+	headers = tracing.Inject(ctx, headers)
+
 	p.inbox <- kafka.Message{
 		Key:     key,
 		Value:   value,
 		Time:    time.Now(),
 		Headers: headers,
 	}
+	p.depth.Set(float64(len(p.inbox)))
+}
+
+// Send: tulis sinkron satu pesan (tunggu ack semua replica) lewat writer sekali pakai. Untuk tool
+// sekali jalan (cmd/ordersctl) yang perlu tahu pesan benar-benar sampai ke broker; service tetap
+// pakai Producer.
+func Send(ctx context.Context, brokers []string, topic string, key, value []byte, headers ...kafka.Header) error {
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer w.Close()
+	headers = tracing.Inject(ctx, headers)
+	if err := w.WriteMessages(ctx, kafka.Message{Key: key, Value: value, Time: time.Now(), Headers: headers}); err != nil {
+		metrics.KafkaPublishErrors.WithLabelValues(topic).Inc()
+		return err
+	}
+	metrics.KafkaPublished.WithLabelValues(topic).Inc()
+	return nil
+}
+
+// Saturation: isi inbox / kapasitas (0..1); mendekati 1 berarti Publish sebentar lagi nge-block.
+func (p *Producer) Saturation() float64 {
+	if cap(p.inbox) == 0 {
+		return 0
+	}
+	return float64(len(p.inbox)) / float64(cap(p.inbox))
 }
 
-// Tutup channel supaya goroutine nge-flush sisa pesan lalu exit rapi.
-func (p *Producer) Close() { close(p.inbox) }
+// Tutup channel supaya goroutine nge-flush sisa pesan lalu exit rapi. Aman dipanggil berulang
+// (Start juga menutup inbox saat ctx selesai).
+func (p *Producer) Close() { p.closeOnce.Do(func() { close(p.inbox) }) }
 
 // Tunggu sampai goroutine selesai.
 func (p *Producer) WaitClosed() { <-p.closeCh }
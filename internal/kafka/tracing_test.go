@@ -0,0 +1,75 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func inMemoryTracing(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracing.NewProvider("test", sdktrace.WithSyncer(exp))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+	})
+	return exp
+}
+
+func spanNamed(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not recorded (got %d spans)", name, len(spans))
+	return tracetest.SpanStub{}
+}
+
+// Publish menulis traceparent ke header; span consume memakai header itu sebagai parent, jadi
+// request -> publish -> consume ada di satu trace.
+func TestTraceparentFromPublishToConsume(t *testing.T) {
+	exp := inMemoryTracing(t)
+
+	p := NewProducer([]string{"localhost:0"}, "orders.test", 1) // tidak di-Start: pesan tertahan di inbox
+	ctx, parent := tracing.Tracer().Start(context.Background(), "POST /orders", trace.WithSpanKind(trace.SpanKindServer))
+	p.Publish(ctx, []byte("order-1"), []byte(`{"event_id":"e1"}`))
+	parent.End()
+	m := <-p.inbox
+
+	if (tracing.HeaderCarrier{Headers: &m.Headers}).Get("traceparent") == "" {
+		t.Fatal("traceparent header not injected")
+	}
+
+	c := &Consumer{topic: "orders.test", group: "test", lag: map[int]int64{}}
+	_, span := c.startSpan(context.Background(), m)
+	span.End()
+
+	spans := exp.GetSpans()
+	server := spanNamed(t, spans, "POST /orders")
+	publish := spanNamed(t, spans, "publish orders.test")
+	consume := spanNamed(t, spans, "process orders.test")
+
+	if publish.SpanKind != trace.SpanKindProducer || publish.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatalf("publish span kind %v parent %v, want producer child of %v", publish.SpanKind, publish.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+	if consume.SpanKind != trace.SpanKindConsumer || !consume.Parent.IsRemote() {
+		t.Fatalf("consume span kind %v remote parent %v, want consumer with remote parent", consume.SpanKind, consume.Parent.IsRemote())
+	}
+	if consume.Parent.SpanID() != publish.SpanContext.SpanID() || consume.SpanContext.TraceID() != server.SpanContext.TraceID() {
+		t.Fatalf("consume span parent %v trace %v, want parent %v trace %v", consume.Parent.SpanID(), consume.SpanContext.TraceID(),
+			publish.SpanContext.SpanID(), server.SpanContext.TraceID())
+	}
+}
@@ -0,0 +1,115 @@
+package logx
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config: level debug | info | warn | error, format json | text. Sampling hanya untuk record di
+// bawah WARN: per pesan per detik, SampleInitial pertama ditulis lalu 1 dari SampleThereafter.
+// SampleThereafter <= 0 = sampling mati.
+type Config struct {
+	Service          string // atribut "service" di setiap record
+	Level            string
+	Format           string
+	SampleInitial    int
+	SampleThereafter int
+}
+
+// level: dipakai bersama semua logger dari New supaya bisa diubah live (SetLevel).
+var level = new(slog.LevelVar)
+
+// New: logger dengan handler JSON/text + trace_id/span_id dari context + sampling.
+func New(w io.Writer, cfg Config) *slog.Logger {
+	level.Set(ParseLevel(cfg.Level))
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if strings.EqualFold(cfg.Format, FormatText) {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	h = traceHandler{h}
+	if cfg.SampleThereafter > 0 {
+		h = samplingHandler{Handler: h, s: newSampler(cfg.SampleInitial, cfg.SampleThereafter)}
+	}
+	l := slog.New(h)
+	if cfg.Service != "" {
+		l = l.With("service", cfg.Service)
+	}
+	return l
+}
+
+// Setup: New ke stderr lalu jadikan slog.Default (package log ikut lewat handler ini).
+func Setup(cfg Config) *slog.Logger {
+	l := New(os.Stderr, cfg)
+	slog.SetDefault(l)
+	return l
+}
+
+// SetLevel: ubah level semua logger dari New tanpa restart.
+func SetLevel(s string) { level.Set(ParseLevel(s)) }
+
+// ParseLevel: nilai tidak dikenal -> info.
+func ParseLevel(s string) slog.Level {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo
+	}
+	return lvl
+}
+
+type ctxKey struct{}
+
+// WithLogger: simpan logger di context.
+func WithLogger(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext: logger dari context, fallback slog.Default().
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// With: logger context ditambah atribut (order_id, event_id, ...); context baru membawa logger itu.
+func With(ctx context.Context, args ...any) (context.Context, *slog.Logger) {
+	l := FromContext(ctx).With(args...)
+	return WithLogger(ctx, l), l
+}
+
+// Fatal: log level ERROR lalu exit(1), pengganti log.Fatalf di cmd/*.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// traceHandler: tambah trace_id & span_id kalau context membawa span (lihat internal/tracing).
+type traceHandler struct{ slog.Handler }
+
+func (h traceHandler) Handle(ctx context.Context, r slog.Record) error {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
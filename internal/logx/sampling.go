@@ -0,0 +1,62 @@
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// sampler: hitungan per pesan dalam window 1 detik (mirip sampler zap). Map di-reset tiap window,
+// jadi jumlah key dibatasi jumlah pesan berbeda per detik.
+type sampler struct {
+	initial, thereafter int
+
+	mu     sync.Mutex
+	window int64
+	counts map[string]int
+}
+
+func newSampler(initial, thereafter int) *sampler {
+	if initial < 0 {
+		initial = 0
+	}
+	return &sampler{initial: initial, thereafter: thereafter, counts: map[string]int{}}
+}
+
+func (s *sampler) allow(msg string, t time.Time) bool {
+	sec := t.Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sec != s.window {
+		s.window = sec
+		clear(s.counts)
+	}
+	s.counts[msg]++
+	n := s.counts[msg]
+	if n <= s.initial {
+		return true
+	}
+	return (n-s.initial)%s.thereafter == 0
+}
+
+// samplingHandler: WARN ke atas selalu ditulis; sisanya lewat sampler.
+type samplingHandler struct {
+	slog.Handler
+	s *sampler
+}
+
+func (h samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < slog.LevelWarn && !h.s.allow(r.Message, r.Time) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithAttrs(attrs), s: h.s}
+}
+
+func (h samplingHandler) WithGroup(name string) slog.Handler {
+	return samplingHandler{Handler: h.Handler.WithGroup(name), s: h.s}
+}
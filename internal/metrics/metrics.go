@@ -0,0 +1,102 @@
+// Package metrics: metric Prometheus bersama (registry default) untuk cmd/api & cmd/inventory.
+// Label sengaja dibatasi (route pattern, topic, command) supaya kardinalitas tetap kecil.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// HTTP (label route = pattern chi, mis. /orders/{id})
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP request per route pattern, method dan status code.",
+	}, []string{"method", "route", "code"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency HTTP request per route pattern.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// Kafka producer (kafkax.Producer)
+	KafkaInboxDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_producer_inbox_depth",
+		Help: "Jumlah pesan di inbox producer yang belum diserahkan ke writer.",
+	}, []string{"topic"})
+	KafkaPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_producer_messages_total",
+		Help: "Pesan yang berhasil ditulis ke broker.",
+	}, []string{"topic"})
+	KafkaPublishErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_producer_errors_total",
+		Help: "Pesan yang gagal ditulis ke broker (hilang, producer fire-and-forget).",
+	}, []string{"topic"})
+	KafkaBatchLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_producer_batch_latency_seconds",
+		Help:    "Waktu dari Publish sampai batch selesai ditulis (pesan tertua di batch).",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"topic"})
+
+	// Kafka consumer (kafkax.Consumer)
+	KafkaHandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consumer_handler_duration_seconds",
+		Help:    "Durasi handler per pesan.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"topic", "group"})
+	KafkaHandlerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_errors_total",
+		Help: "Handler atau commit offset yang gagal.",
+	}, []string{"topic", "group", "stage"}) // stage: handler | commit
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "High watermark - offset pesan terakhir yang dibaca, per partition.",
+	}, []string{"topic", "group", "partition"})
+
+	// Redis
+	RedisDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_command_duration_seconds",
+		Help:    "Latency command Redis (pipeline dihitung sebagai satu).",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_command_errors_total",
+		Help: "Command Redis yang gagal (redis.Nil tidak dihitung).",
+	}, []string{"command"})
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Lookup cache per hasil (hit | miss | error); hit ratio = hit / total.",
+	}, []string{"cache", "result"})
+
+	// Feature flag (internal/flags); label flag = key flag, jumlahnya kecil & diatur admin
+	FlagEvaluations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "feature_flag_evaluations_total",
+		Help: "Evaluasi feature flag per hasil (on | off).",
+	}, []string{"flag", "result"})
+
+	// Bisnis
+	OrdersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "orders_created_total",
+		Help: "Order baru (request idempotent yang mengembalikan order lama tidak dihitung).",
+	})
+	OrdersReserved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_reserved_total",
+		Help: "Event StockReserved yang dipublish, per status (STOCK_RESERVED | PARTIALLY_RESERVED).",
+	}, []string{"status"})
+	OrdersRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "orders_rejected_total",
+		Help: "Event StockRejected yang dipublish.",
+	})
+	OrdersFinalized = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_finalized_total",
+		Help: "Event OrderFinalized yang diterima, per final_status.",
+	}, []string{"status"})
+)
+
+// Handler: endpoint /metrics (registry default, termasuk metric Go runtime & process).
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
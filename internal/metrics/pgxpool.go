@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector: baca pgxpool.Stat() saat scrape (tidak ada goroutine polling).
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired, idle, total, constructing, max *prometheus.Desc
+	acquires, emptyAcquires, canceled        *prometheus.Desc
+	acquireSeconds                           *prometheus.Desc
+}
+
+// RegisterPgxPool: daftarkan stats pool ke registry default. name dipakai sebagai label pool.
+func RegisterPgxPool(name string, pool *pgxpool.Pool) {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc("pgxpool_"+metric, help, nil, labels)
+	}
+	prometheus.MustRegister(&poolCollector{
+		pool:           pool,
+		acquired:       desc("acquired_conns", "Koneksi yang sedang dipakai."),
+		idle:           desc("idle_conns", "Koneksi idle."),
+		total:          desc("total_conns", "Total koneksi (acquired + idle + constructing)."),
+		constructing:   desc("constructing_conns", "Koneksi yang sedang dibuat."),
+		max:            desc("max_conns", "Batas koneksi pool."),
+		acquires:       desc("acquire_total", "Acquire yang berhasil."),
+		emptyAcquires:  desc("empty_acquire_total", "Acquire yang harus menunggu karena pool kosong (tanda pool saturasi)."),
+		canceled:       desc("canceled_acquire_total", "Acquire yang batal karena context."),
+		acquireSeconds: desc("acquire_duration_seconds_total", "Total waktu menunggu acquire."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{c.acquired, c.idle, c.total, c.constructing, c.max,
+		c.acquires, c.emptyAcquires, c.canceled, c.acquireSeconds} {
+		ch <- d
+	}
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.constructing, float64(s.ConstructingConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	counter(c.acquires, float64(s.AcquireCount()))
+	counter(c.emptyAcquires, float64(s.EmptyAcquireCount()))
+	counter(c.canceled, float64(s.CanceledAcquireCount()))
+	counter(c.acquireSeconds, s.AcquireDuration().Seconds())
+}
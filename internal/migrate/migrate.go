@@ -0,0 +1,391 @@
+// Package migrate: runner migration SQL yang di-embed (package db). Versi yang sudah jalan dicatat
+// di schema_migrations beserta checksum; semua perubahan (up/down/seed) dijalankan di bawah
+// Postgres advisory lock supaya beberapa replika yang start bersamaan tidak balapan.
+//
+// Database yang dulu di-migrate lewat Makefile (psql) belum punya schema_migrations; up pertama
+// menjalankan ulang semua file dan aman karena migration lama idempotent (IF NOT EXISTS dst).
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	KindSchema = "schema"
+	KindSeed   = "seed"
+
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // file berubah setelah dijalankan (checksum beda)
+	StateMissing  = "missing"  // tercatat di database tapi tidak ada di binary ini
+
+	// key pg_advisory_lock, sama untuk semua proses yang menjalankan migration
+	lockKey int64 = 0x6f72646572730001
+
+	defaultLockTimeout = time.Minute
+)
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was modified")
+	ErrNoDown           = errors.New("migration has no down file")
+)
+
+// Migration: satu file up (+ down opsional). Checksum = sha256 isi file up.
+type Migration struct {
+	Kind     string
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+func (m Migration) String() string { return fmt.Sprintf("%s %03d_%s", m.Kind, m.Version, m.Name) }
+
+var fileRe = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+?)(\.down)?\.sql$`)
+
+// Parse: semua NNN_nama.sql & NNN_nama.down.sql di dir, urut versi.
+func Parse(fsys fs.FS, dir, kind string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	downs := map[int]string{}
+	for _, e := range entries {
+		mm := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || mm == nil {
+			continue
+		}
+		v, _ := strconv.Atoi(mm[1])
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if mm[3] != "" {
+			downs[v] = string(b)
+			continue
+		}
+		if prev, ok := byVersion[v]; ok {
+			return nil, fmt.Errorf("%s: duplicate version %03d (%s, %s)", dir, v, prev.Name, mm[2])
+		}
+		sum := sha256.Sum256(b)
+		byVersion[v] = &Migration{Kind: kind, Version: v, Name: mm[2], Up: string(b), Checksum: hex.EncodeToString(sum[:])}
+	}
+	out := make([]Migration, 0, len(byVersion))
+	for v, down := range downs {
+		m, ok := byVersion[v]
+		if !ok {
+			return nil, fmt.Errorf("%s: down file for version %03d without up file", dir, v)
+		}
+		m.Down = down
+	}
+	for _, m := range byVersion {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// Migrator: Schema & Seeds biasanya dari New (file embed).
+type Migrator struct {
+	DB          *pgxpool.Pool
+	Schema      []Migration
+	Seeds       []Migration
+	LockTimeout time.Duration // tunggu lock dari proses lain; 0 -> 1m
+}
+
+// New: migrator dengan file db/migrations & db/seeds yang di-embed.
+func New(pool *pgxpool.Pool) (*Migrator, error) {
+	schema, err := Parse(db.Migrations, "migrations", KindSchema)
+	if err != nil {
+		return nil, err
+	}
+	seeds, err := Parse(db.Seeds, "seeds", KindSeed)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: pool, Schema: schema, Seeds: seeds}, nil
+}
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    kind TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    checksum TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (kind, version)
+)`
+
+type record struct {
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// withLock: satu koneksi memegang advisory lock selama fn; lock lepas otomatis kalau koneksi putus.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	c, err := m.DB.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Release()
+
+	timeout := m.LockTimeout
+	if timeout <= 0 {
+		timeout = defaultLockTimeout
+	}
+	lctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Exec(lctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("migration lock: %w", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		slog.Info("migration lock acquired", "waited_ms", waited.Milliseconds())
+	}
+	defer func() {
+		// ctx request bisa sudah selesai; unlock tetap harus terkirim
+		uctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, _ = c.Exec(uctx, `SELECT pg_advisory_unlock($1)`, lockKey)
+	}()
+
+	if _, err := c.Exec(ctx, createTable); err != nil {
+		return err
+	}
+	return fn(c.Conn())
+}
+
+func applied(ctx context.Context, q interface {
+	Query(context.Context, string, ...any) (pgx.Rows, error)
+}, kind string) (map[int]record, error) {
+	rows, err := q.Query(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations WHERE kind = $1`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int]record{}
+	for rows.Next() {
+		var v int
+		var r record
+		if err := rows.Scan(&v, &r.Name, &r.Checksum, &r.AppliedAt); err != nil {
+			return nil, err
+		}
+		out[v] = r
+	}
+	return out, rows.Err()
+}
+
+// run: satu migration (up / down) + catatannya dalam satu tx.
+func run(ctx context.Context, conn *pgx.Conn, mg Migration, sql string, record func(tx pgx.Tx, ms int64) error) error {
+	start := time.Now()
+	err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+		return record(tx, time.Since(start).Milliseconds())
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", mg, err)
+	}
+	return nil
+}
+
+func recordApplied(ctx context.Context, mg Migration) func(pgx.Tx, int64) error {
+	return func(tx pgx.Tx, ms int64) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO schema_migrations(kind, version, name, checksum, duration_ms) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (kind, version) DO UPDATE SET
+				name = EXCLUDED.name, checksum = EXCLUDED.checksum, applied_at = now(), duration_ms = EXCLUDED.duration_ms`,
+			mg.Kind, mg.Version, mg.Name, mg.Checksum, ms)
+		return err
+	}
+}
+
+// Up: jalankan migration schema yang belum tercatat, sampai versi to (0 = semua). Migration yang
+// sudah jalan lalu filenya diubah -> ErrChecksumMismatch, tidak ada yang dijalankan.
+func (m *Migrator) Up(ctx context.Context, to int) (done []Migration, err error) {
+	err = m.withLock(ctx, func(conn *pgx.Conn) error {
+		recs, err := applied(ctx, conn, KindSchema)
+		if err != nil {
+			return err
+		}
+		known := map[int]bool{}
+		latest := -1
+		for _, mg := range m.Schema {
+			known[mg.Version] = true
+			if r, ok := recs[mg.Version]; ok {
+				if r.Checksum != mg.Checksum {
+					return fmt.Errorf("%w: %s", ErrChecksumMismatch, mg)
+				}
+				latest = max(latest, mg.Version)
+			}
+		}
+		for v, r := range recs {
+			if !known[v] {
+				// biasanya binary lama saat rolling deploy; schema lebih baru tidak disentuh
+				slog.Warn("database has migration unknown to this binary", "version", v, "name", r.Name)
+			}
+		}
+		for _, mg := range m.Schema {
+			if _, ok := recs[mg.Version]; ok || (to > 0 && mg.Version > to) {
+				continue
+			}
+			if mg.Version < latest {
+				slog.Warn("applying migration older than latest applied", "migration", mg.String(), "latest", latest)
+			}
+			start := time.Now()
+			if err := run(ctx, conn, mg, mg.Up, recordApplied(ctx, mg)); err != nil {
+				return err
+			}
+			slog.Info("migration applied", "migration", mg.String(), "duration_ms", time.Since(start).Milliseconds())
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down: rollback steps migration schema terakhir (urut versi turun) memakai file .down.sql.
+func (m *Migrator) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	byVersion := map[int]Migration{}
+	for _, mg := range m.Schema {
+		byVersion[mg.Version] = mg
+	}
+	err = m.withLock(ctx, func(conn *pgx.Conn) error {
+		recs, err := applied(ctx, conn, KindSchema)
+		if err != nil {
+			return err
+		}
+		versions := make([]int, 0, len(recs))
+		for v := range recs {
+			versions = append(versions, v)
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+		for i, v := range versions {
+			if i >= steps {
+				break
+			}
+			mg, ok := byVersion[v]
+			if !ok {
+				return fmt.Errorf("%w: version %03d (%s) not in this binary", ErrNoDown, v, recs[v].Name)
+			}
+			if mg.Down == "" {
+				return fmt.Errorf("%w: %s", ErrNoDown, mg)
+			}
+			start := time.Now()
+			err := run(ctx, conn, mg, mg.Down, func(tx pgx.Tx, _ int64) error {
+				_, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE kind = $1 AND version = $2`, KindSchema, v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			slog.Info("migration rolled back", "migration", mg.String(), "duration_ms", time.Since(start).Milliseconds())
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Seed: jalankan seed yang belum pernah jalan atau isinya berubah (seed harus idempotent).
+func (m *Migrator) Seed(ctx context.Context) (done []Migration, err error) {
+	err = m.withLock(ctx, func(conn *pgx.Conn) error {
+		recs, err := applied(ctx, conn, KindSeed)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.Seeds {
+			if r, ok := recs[mg.Version]; ok && r.Checksum == mg.Checksum {
+				continue
+			}
+			start := time.Now()
+			if err := run(ctx, conn, mg, mg.Up, recordApplied(ctx, mg)); err != nil {
+				return err
+			}
+			slog.Info("seed applied", "seed", mg.String(), "duration_ms", time.Since(start).Milliseconds())
+			done = append(done, mg)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status: satu baris per migration / seed (file maupun yang hanya tercatat di database).
+type Status struct {
+	Kind      string     `json:"kind"`
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Status: tanpa lock (hanya baca).
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if _, err := m.DB.Exec(ctx, createTable); err != nil {
+		return nil, err
+	}
+	var out []Status
+	for _, set := range []struct {
+		kind  string
+		files []Migration
+	}{{KindSchema, m.Schema}, {KindSeed, m.Seeds}} {
+		recs, err := applied(ctx, m.DB, set.kind)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([]Status, 0, len(set.files)+len(recs))
+		for _, mg := range set.files {
+			st := Status{Kind: set.kind, Version: mg.Version, Name: mg.Name, State: StatePending}
+			if r, ok := recs[mg.Version]; ok {
+				at := r.AppliedAt
+				st.AppliedAt, st.State = &at, StateApplied
+				if r.Checksum != mg.Checksum {
+					st.State = StateModified
+				}
+				delete(recs, mg.Version)
+			}
+			rows = append(rows, st)
+		}
+		for v, r := range recs {
+			at := r.AppliedAt
+			rows = append(rows, Status{Kind: set.kind, Version: v, Name: r.Name, State: StateMissing, AppliedAt: &at})
+		}
+		sort.Slice(rows, func(i, j int) bool { return rows[i].Version < rows[j].Version })
+		out = append(out, rows...)
+	}
+	return out, nil
+}
+
+// Auto: New + Up semua; dipakai main saat postgres.auto_migrate aktif.
+func Auto(ctx context.Context, pool *pgxpool.Pool) error {
+	m, err := New(pool)
+	if err != nil {
+		return err
+	}
+	done, err := m.Up(ctx, 0)
+	if err != nil {
+		return err
+	}
+	slog.Info("database migrated", "applied", len(done))
+	return nil
+}
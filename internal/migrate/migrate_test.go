@@ -0,0 +1,183 @@
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ariefcatur/go-realtime-orders.git/db"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func file(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestParseOrderAndChecksum(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/10_later.sql":     file("SELECT 10;"),
+		"m/9_nine.sql":       file("SELECT 9;"),
+		"m/9_nine.down.sql":  file("SELECT -9;"),
+		"m/001_first.sql":    file("SELECT 1;"),
+		"m/README.md":        file("bukan migration"),
+		"m/002_no-dash.sql":  file("nama tidak valid, diabaikan"),
+		"m/003_sub/skip.sql": file("direktori diabaikan"),
+	}
+	got, err := Parse(fsys, "m", KindSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range got {
+		names = append(names, fmt.Sprintf("%d_%s", m.Version, m.Name))
+	}
+	if want := "[1_first 9_nine 10_later]"; fmt.Sprint(names) != want {
+		t.Fatalf("order %v, want %s (numeric, not lexical)", names, want)
+	}
+	nine := got[1]
+	if nine.Kind != KindSchema || nine.Up != "SELECT 9;" || nine.Down != "SELECT -9;" {
+		t.Errorf("9_nine: %+v", nine)
+	}
+	if nine.Checksum != checksum("SELECT 9;") {
+		t.Errorf("checksum %s, want sha256 of up file", nine.Checksum)
+	}
+
+	// checksum hanya dari file up: down boleh diubah tanpa dianggap modified
+	fsys["m/9_nine.down.sql"] = file("SELECT -99;")
+	again, err := Parse(fsys, "m", KindSchema)
+	if err != nil || again[1].Checksum != nine.Checksum {
+		t.Errorf("down change altered checksum: %v %v", again, err)
+	}
+	fsys["m/9_nine.sql"] = file("SELECT 9; -- diubah")
+	again, err = Parse(fsys, "m", KindSchema)
+	if err != nil || again[1].Checksum == nine.Checksum {
+		t.Errorf("up change kept checksum: %v %v", again, err)
+	}
+}
+
+func TestParseRejectsBadSets(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"duplicate version": {"m/001_a.sql": file("SELECT 1;"), "m/1_b.sql": file("SELECT 1;")},
+		"down without up":   {"m/001_a.sql": file("SELECT 1;"), "m/002_b.down.sql": file("SELECT 2;")},
+	} {
+		if _, err := Parse(fsys, "m", KindSchema); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+// File yang di-embed harus selalu bisa di-parse, versi unik & naik, dan setiap schema punya down.
+func TestEmbeddedMigrations(t *testing.T) {
+	schema, err := Parse(db.Migrations, "migrations", KindSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema) == 0 {
+		t.Fatal("no schema migrations embedded")
+	}
+	for i, m := range schema {
+		if i > 0 && m.Version <= schema[i-1].Version {
+			t.Errorf("%s not after %s", m, schema[i-1])
+		}
+		if m.Down == "" {
+			t.Errorf("%s has no down file", m)
+		}
+	}
+	if _, err := Parse(db.Seeds, "seeds", KindSeed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testMigrator: Postgres (TEST_POSTGRES_DSN) dengan search_path ke schema baru yang dibuang setelah
+// test, jadi schema_migrations & tabel test tidak menyentuh database aslinya.
+func testMigrator(t *testing.T, schema ...Migration) *Migrator {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	ctx := context.Background()
+	name := "migrate_test_" + uuid.NewString()[:8]
+	admin, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(admin.Close)
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+name); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = admin.Exec(context.Background(), `DROP SCHEMA `+name+` CASCADE`) })
+
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = name
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return &Migrator{DB: pool, Schema: schema}
+}
+
+func mig(version int, name, up string) Migration {
+	return Migration{Kind: KindSchema, Version: version, Name: name, Up: up, Down: "SELECT 1", Checksum: checksum(up)}
+}
+
+func TestUpOrderAndChecksum(t *testing.T) {
+	m := testMigrator(t,
+		mig(1, "create", `CREATE TABLE steps (n INT PRIMARY KEY)`),
+		mig(2, "insert", `INSERT INTO steps VALUES (2)`),
+		mig(3, "insert_more", `INSERT INTO steps SELECT max(n) + 1 FROM steps`),
+	)
+	ctx := context.Background()
+
+	done, err := m.Up(ctx, 2)
+	if err != nil || len(done) != 2 {
+		t.Fatalf("up to 2: %v %v", done, err)
+	}
+	done, err = m.Up(ctx, 0)
+	if err != nil || len(done) != 1 || done[0].Version != 3 {
+		t.Fatalf("up rest: %v %v", done, err)
+	}
+	var maxN int
+	if err := m.DB.QueryRow(ctx, `SELECT max(n) FROM steps`).Scan(&maxN); err != nil || maxN != 3 {
+		t.Fatalf("max(n) %d %v, want 3 (migrations ran in order)", maxN, err)
+	}
+	if done, err := m.Up(ctx, 0); err != nil || len(done) != 0 {
+		t.Fatalf("second up: %v %v", done, err)
+	}
+
+	// file yang sudah jalan diubah: Up ditolak dan migration baru ikut tidak jalan
+	m.Schema[1] = mig(2, "insert", `INSERT INTO steps VALUES (20)`)
+	m.Schema = append(m.Schema, mig(4, "never", `INSERT INTO steps VALUES (4)`))
+	if _, err := m.Up(ctx, 0); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("got %v, want ErrChecksumMismatch", err)
+	}
+	var n4 int
+	if err := m.DB.QueryRow(ctx, `SELECT count(*) FROM steps WHERE n = 4`).Scan(&n4); err != nil || n4 != 0 {
+		t.Fatalf("migration 4 ran despite checksum mismatch (%d, %v)", n4, err)
+	}
+
+	st, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var states []string
+	for _, s := range st {
+		states = append(states, fmt.Sprintf("%d:%s", s.Version, s.State))
+	}
+	if want := "[1:applied 2:modified 3:applied 4:pending]"; fmt.Sprint(states) != want {
+		t.Fatalf("status %v, want %s", states, want)
+	}
+}
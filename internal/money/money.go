@@ -0,0 +1,62 @@
+// Package money: jumlah uang dalam minor unit (cents) + kode currency ISO 4217.
+// Operasi antar currency berbeda selalu ditolak (ErrCurrencyMismatch), tidak ada konversi kurs.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency: hanya cek format kode (3 huruf kapital), bukan daftar ISO lengkap.
+func ValidCurrency(code string) bool { return currencyPattern.MatchString(code) }
+
+type Money struct {
+	Cents    int    `json:"cents"`
+	Currency string `json:"currency"`
+}
+
+func New(cents int, currency string) Money { return Money{Cents: cents, Currency: currency} }
+
+func Zero(currency string) Money { return Money{Currency: currency} }
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Cents: m.Cents + o.Cents, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Cents: m.Cents - o.Cents, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(n int) Money { return Money{Cents: m.Cents * n, Currency: m.Currency} }
+
+// Sum: semua harus ber-currency sama; list kosong = Zero(currency).
+func Sum(currency string, ms ...Money) (Money, error) {
+	total := Zero(currency)
+	for _, m := range ms {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// String: "12.34 USD" (selalu 2 digit desimal; cukup untuk log / pesan error).
+func (m Money) String() string {
+	sign, c := "", m.Cents
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, c/100, c%100, m.Currency)
+}
@@ -0,0 +1,45 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCurrencyMismatch(t *testing.T) {
+	idr, usd := New(1000, "IDR"), New(250, "USD")
+	if _, err := idr.Add(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add: %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := idr.Sub(usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sub: %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := Sum("IDR", idr, usd); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum: %v, want ErrCurrencyMismatch", err)
+	}
+	if _, err := Sum("USD", idr); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Sum with other currency: %v, want ErrCurrencyMismatch", err)
+	}
+
+	got, err := Sum("IDR", idr, New(-300, "IDR"), idr.Mul(2))
+	if err != nil || got != New(2700, "IDR") {
+		t.Errorf("Sum: %v %v, want 27.00 IDR", got, err)
+	}
+	if got, err := Sum("USD"); err != nil || got != Zero("USD") {
+		t.Errorf("empty Sum: %v %v", got, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, tc := range []struct {
+		m    Money
+		want string
+	}{
+		{New(1234, "USD"), "12.34 USD"},
+		{New(5, "USD"), "0.05 USD"},
+		{New(-1205, "IDR"), "-12.05 IDR"},
+	} {
+		if got := tc.m.String(); got != tc.want {
+			t.Errorf("%+v: %q, want %q", tc.m, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package orders
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WarehouseStock: stok satu produk di satu gudang (sudah di-lock di tx reservasi).
+type WarehouseStock struct {
+	WarehouseID string
+	Code        string
+	Region      string
+	Priority    int // makin kecil makin diutamakan
+	ProductID   string
+	Available   int
+}
+
+// Allocation: qty satu produk yang diambil dari satu gudang.
+type Allocation struct {
+	ProductID     string `json:"product_id"`
+	WarehouseID   string `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	Qty           int    `json:"qty"`
+}
+
+type AllocationRequest struct {
+	Region string    // region user (boleh kosong)
+	Items  []ItemQty // sudah unik per product_id
+	// Stock: kandidat gudang per product_id, urut priority lalu code
+	Stock map[string][]WarehouseStock
+}
+
+// AllocationStrategy memilih gudang sumber untuk setiap item. Kalau ada item yang tidak bisa
+// dipenuhi, kembalikan shortages (tanpa allocation) dan reservasi di-rollback.
+type AllocationStrategy interface {
+	Name() string
+	Allocate(req AllocationRequest) ([]Allocation, []StockRejectedDetail)
+}
+
+// StrategyByName: dipakai config (ALLOCATION_STRATEGY). Kosong = single.
+func StrategyByName(name string) (AllocationStrategy, error) {
+	switch strings.ToLower(name) {
+	case "", "single":
+		return SingleWarehouseStrategy{}, nil
+	case "closest":
+		return ClosestRegionStrategy{}, nil
+	case "split":
+		return SplitStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q (single|closest|split)", name)
+	}
+}
+
+// SingleWarehouseStrategy: utamakan satu gudang yang bisa memenuhi semua item (satu paket kiriman);
+// kalau tidak ada, fallback ke split.
+type SingleWarehouseStrategy struct{}
+
+func (SingleWarehouseStrategy) Name() string { return "single" }
+
+func (SingleWarehouseStrategy) Allocate(req AllocationRequest) ([]Allocation, []StockRejectedDetail) {
+	if wh, ok := singleSource(req, nil); ok {
+		return allFrom(req, wh), nil
+	}
+	return SplitStrategy{}.Allocate(req)
+}
+
+// ClosestRegionStrategy: gudang di region user dulu (satu gudang kalau bisa), lalu sisanya by priority.
+type ClosestRegionStrategy struct{}
+
+func (ClosestRegionStrategy) Name() string { return "closest" }
+
+func (ClosestRegionStrategy) Allocate(req AllocationRequest) ([]Allocation, []StockRejectedDetail) {
+	sameRegion := func(w WarehouseStock) bool { return req.Region != "" && w.Region == req.Region }
+	if wh, ok := singleSource(req, sameRegion); ok {
+		return allFrom(req, wh), nil
+	}
+	return greedy(req, func(a, b WarehouseStock) bool {
+		if sameRegion(a) != sameRegion(b) {
+			return sameRegion(a)
+		}
+		return byPriority(a, b)
+	})
+}
+
+// SplitStrategy: isi tiap item dari gudang berurutan priority sampai qty terpenuhi.
+type SplitStrategy struct{}
+
+func (SplitStrategy) Name() string { return "split" }
+
+func (SplitStrategy) Allocate(req AllocationRequest) ([]Allocation, []StockRejectedDetail) {
+	return greedy(req, byPriority)
+}
+
+func byPriority(a, b WarehouseStock) bool {
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.Code < b.Code
+}
+
+// singleSource: cari satu gudang (filter opsional) yang cukup untuk semua item, urut priority.
+func singleSource(req AllocationRequest, filter func(WarehouseStock) bool) (WarehouseStock, bool) {
+	type agg struct {
+		wh    WarehouseStock
+		items int
+	}
+	byWH := map[string]*agg{}
+	for _, it := range req.Items {
+		for _, w := range req.Stock[it.ProductID] {
+			if w.Available < it.Qty || (filter != nil && !filter(w)) {
+				continue
+			}
+			a, ok := byWH[w.WarehouseID]
+			if !ok {
+				a = &agg{wh: w}
+				byWH[w.WarehouseID] = a
+			}
+			a.items++
+		}
+	}
+	var best *agg
+	for _, a := range byWH {
+		if a.items != len(req.Items) {
+			continue
+		}
+		if best == nil || byPriority(a.wh, best.wh) {
+			best = a
+		}
+	}
+	if best == nil {
+		return WarehouseStock{}, false
+	}
+	return best.wh, true
+}
+
+func allFrom(req AllocationRequest, wh WarehouseStock) []Allocation {
+	out := make([]Allocation, 0, len(req.Items))
+	for _, it := range req.Items {
+		out = append(out, Allocation{ProductID: it.ProductID, WarehouseID: wh.WarehouseID, WarehouseCode: wh.Code, Qty: it.Qty})
+	}
+	return out
+}
+
+func greedy(req AllocationRequest, less func(a, b WarehouseStock) bool) ([]Allocation, []StockRejectedDetail) {
+	var out []Allocation
+	var short []StockRejectedDetail
+	for _, it := range req.Items {
+		cands := append([]WarehouseStock(nil), req.Stock[it.ProductID]...)
+		sort.SliceStable(cands, func(i, j int) bool { return less(cands[i], cands[j]) })
+
+		need, total := it.Qty, 0
+		var picked []Allocation
+		for _, w := range cands {
+			total += w.Available
+			if need == 0 || w.Available <= 0 {
+				continue
+			}
+			q := min(need, w.Available)
+			picked = append(picked, Allocation{ProductID: it.ProductID, WarehouseID: w.WarehouseID, WarehouseCode: w.Code, Qty: q})
+			need -= q
+		}
+		if need > 0 {
+			short = append(short, StockRejectedDetail{ProductID: it.ProductID, Required: it.Qty, Available: total})
+			continue
+		}
+		out = append(out, picked...)
+	}
+	if len(short) > 0 {
+		return nil, short
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package orders
+
+import (
+	"reflect"
+	"testing"
+)
+
+func wh(code, region string, prio int, productID string, avail int) WarehouseStock {
+	return WarehouseStock{WarehouseID: "wh-" + code, Code: code, Region: region, Priority: prio, ProductID: productID, Available: avail}
+}
+
+func alloc(productID, code string, qty int) Allocation {
+	return Allocation{ProductID: productID, WarehouseID: "wh-" + code, WarehouseCode: code, Qty: qty}
+}
+
+// stockOf: kandidat gudang per produk, urutan sesuai argumen.
+func stockOf(ws ...WarehouseStock) map[string][]WarehouseStock {
+	out := map[string][]WarehouseStock{}
+	for _, w := range ws {
+		out[w.ProductID] = append(out[w.ProductID], w)
+	}
+	return out
+}
+
+func TestAllocationStrategies(t *testing.T) {
+	single, closest, split := SingleWarehouseStrategy{}, ClosestRegionStrategy{}, SplitStrategy{}
+	two := []ItemQty{{ProductID: "p1", Qty: 2}, {ProductID: "p2", Qty: 1}}
+
+	for _, tc := range []struct {
+		name     string
+		strategy AllocationStrategy
+		region   string
+		items    []ItemQty
+		stock    map[string][]WarehouseStock
+		want     []Allocation
+		short    []StockRejectedDetail
+	}{
+		{
+			name: "single: gudang priority teratas yang punya semua item", strategy: single, items: two,
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 5), wh("SBY", "jawa", 2, "p1", 5), wh("SBY", "jawa", 2, "p2", 5), wh("JKT", "jawa", 1, "p2", 5)),
+			want:  []Allocation{alloc("p1", "JKT", 2), alloc("p2", "JKT", 1)},
+		},
+		{
+			name: "single: priority sama, code terkecil menang", strategy: single, items: two,
+			stock: stockOf(wh("B", "jawa", 1, "p1", 5), wh("A", "jawa", 1, "p1", 5), wh("B", "jawa", 1, "p2", 5), wh("A", "jawa", 1, "p2", 5)),
+			want:  []Allocation{alloc("p1", "A", 2), alloc("p2", "A", 1)},
+		},
+		{
+			name: "single: gudang prioritas kurang untuk satu item, pakai gudang lain yang lengkap", strategy: single, items: two,
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 1), wh("SBY", "jawa", 2, "p1", 2), wh("JKT", "jawa", 1, "p2", 5), wh("SBY", "jawa", 2, "p2", 1)),
+			want:  []Allocation{alloc("p1", "SBY", 2), alloc("p2", "SBY", 1)},
+		},
+		{
+			name: "single: tidak ada satu gudang lengkap, fallback split", strategy: single, items: two,
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 1), wh("SBY", "jawa", 2, "p1", 1), wh("SBY", "jawa", 2, "p2", 1)),
+			want:  []Allocation{alloc("p1", "JKT", 1), alloc("p1", "SBY", 1), alloc("p2", "SBY", 1)},
+		},
+		{
+			name: "single: stok total kurang", strategy: single, items: []ItemQty{{ProductID: "p1", Qty: 5}},
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 2), wh("SBY", "jawa", 2, "p1", 1)),
+			short: []StockRejectedDetail{{ProductID: "p1", Required: 5, Available: 3}},
+		},
+		{
+			name: "closest: gudang di region user walau priority lebih rendah", strategy: closest, region: "sumatra", items: two,
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 5), wh("MDN", "sumatra", 3, "p1", 5), wh("JKT", "jawa", 1, "p2", 5), wh("MDN", "sumatra", 3, "p2", 5)),
+			want:  []Allocation{alloc("p1", "MDN", 2), alloc("p2", "MDN", 1)},
+		},
+		{
+			name: "closest: region user tidak cukup, sisanya by priority", strategy: closest, region: "sumatra",
+			items: []ItemQty{{ProductID: "p1", Qty: 5}},
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 10), wh("SBY", "jawa", 2, "p1", 10), wh("MDN", "sumatra", 3, "p1", 2)),
+			want:  []Allocation{alloc("p1", "MDN", 2), alloc("p1", "JKT", 3)},
+		},
+		{
+			name: "closest: tanpa region sama dengan single", strategy: closest, items: two,
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 5), wh("MDN", "sumatra", 3, "p1", 5), wh("JKT", "jawa", 1, "p2", 5), wh("MDN", "sumatra", 3, "p2", 5)),
+			want:  []Allocation{alloc("p1", "JKT", 2), alloc("p2", "JKT", 1)},
+		},
+		{
+			name: "closest: stok total kurang", strategy: closest, region: "sumatra", items: []ItemQty{{ProductID: "p1", Qty: 4}},
+			stock: stockOf(wh("JKT", "jawa", 1, "p1", 1), wh("MDN", "sumatra", 3, "p1", 2)),
+			short: []StockRejectedDetail{{ProductID: "p1", Required: 4, Available: 3}},
+		},
+		{
+			name: "split: sisa diambil dari gudang berikutnya, gudang kosong dilewati", strategy: split,
+			items: []ItemQty{{ProductID: "p1", Qty: 7}},
+			stock: stockOf(wh("A", "jawa", 1, "p1", 3), wh("B", "jawa", 2, "p1", 0), wh("C", "jawa", 3, "p1", 10)),
+			want:  []Allocation{alloc("p1", "A", 3), alloc("p1", "C", 4)},
+		},
+		{
+			name: "split: tetap split walau ada satu gudang yang cukup", strategy: split,
+			items: []ItemQty{{ProductID: "p1", Qty: 2}},
+			stock: stockOf(wh("A", "jawa", 1, "p1", 1), wh("B", "jawa", 2, "p1", 5)),
+			want:  []Allocation{alloc("p1", "A", 1), alloc("p1", "B", 1)},
+		},
+		{
+			name: "split: priority sama diurut code", strategy: split,
+			items: []ItemQty{{ProductID: "p1", Qty: 3}},
+			stock: stockOf(wh("B", "jawa", 1, "p1", 2), wh("A", "jawa", 1, "p1", 2)),
+			want:  []Allocation{alloc("p1", "A", 2), alloc("p1", "B", 1)},
+		},
+		{
+			name: "split: satu item kurang, tidak ada allocation sama sekali", strategy: split,
+			items: []ItemQty{{ProductID: "p1", Qty: 1}, {ProductID: "p2", Qty: 3}, {ProductID: "p3", Qty: 1}},
+			stock: stockOf(wh("A", "jawa", 1, "p1", 5), wh("A", "jawa", 1, "p2", 1), wh("B", "jawa", 2, "p2", 1)),
+			short: []StockRejectedDetail{{ProductID: "p2", Required: 3, Available: 2}, {ProductID: "p3", Required: 1, Available: 0}},
+		},
+	} {
+		got, short := tc.strategy.Allocate(AllocationRequest{Region: tc.region, Items: tc.items, Stock: tc.stock})
+		if !reflect.DeepEqual(got, tc.want) || !reflect.DeepEqual(short, tc.short) {
+			t.Errorf("%s:\n got %+v %+v\nwant %+v %+v", tc.name, got, short, tc.want, tc.short)
+		}
+	}
+}
+
+func TestStrategyByName(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", "single"}, {"single", "single"}, {"Closest", "closest"}, {"SPLIT", "split"},
+	} {
+		s, err := StrategyByName(tc.in)
+		if err != nil || s.Name() != tc.want {
+			t.Errorf("%q: %v %v, want %s", tc.in, s, err, tc.want)
+		}
+	}
+	if _, err := StrategyByName("nearest"); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+}
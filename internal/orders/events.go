@@ -3,21 +3,43 @@ package orders
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/pricing"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
 )
 
 const (
 	EventOrderCreated      = "OrderCreated"
 	EventStockReserved     = "StockReserved"
 	EventStockRejected     = "StockRejected"
+	EventStockReleased     = "StockReleased"
 	EventPaymentAuthorized = "PaymentAuthorized"
 	EventPaymentFailed     = "PaymentFailed"
 	EventOrderFinalized    = "OrderFinalized"
+
+	EventProductCreated  = "ProductCreated"
+	EventProductUpdated  = "ProductUpdated"
+	EventProductArchived = "ProductArchived"
+
+	EventStockAdjusted = "StockAdjusted"
+	EventStockLow      = "StockLow"
+	EventStockDepleted = "StockDepleted"
+)
+
+// Versi payload yang di-publish per event type (Envelope.EventVersion + header x-event-version).
+// Naik kalau payload berubah arti / bertambah field wajib; consumer tetap harus bisa baca versi lama
+// (field baru kosong = nilai default: currency kosong dianggap currency default, tax 0).
+const (
+	VersionDefault       = 1
+	VersionOrderCreated  = 2 // v2: currency, tax_cents, taxes, tax_cents per item
+	VersionStockReserved = 2 // v2: currency (menyertai total_cents)
+	VersionProduct       = 2 // v2: currency, tax_category (ProductCreated / ProductUpdated)
 )
 
 type Envelope struct {
 	EventID       string          `json:"event_id"`      // uuid
 	EventType     string          `json:"event_type"`    // salah satu const di atas
-	EventVersion  int             `json:"event_version"` // 1
+	EventVersion  int             `json:"event_version"` // lihat Version* di atas
 	OccurredAt    time.Time       `json:"occurred_at"`   // RFC3339
 	Producer      string          `json:"producer"`      // e.g., "order-api"
 	TraceID       string          `json:"trace_id,omitempty"`
@@ -32,23 +54,46 @@ type ItemQty struct {
 	Qty       int    `json:"qty"`
 }
 
+// ItemPrice: PriceCents = list price per unit; DiscountCents/FinalCents/TaxCents = total line
+// (FinalCents belum termasuk pajak).
 type ItemPrice struct {
-	ProductID  string `json:"product_id"`
-	Qty        int    `json:"qty"`
-	PriceCents int    `json:"price_cents"`
+	ProductID     string `json:"product_id"`
+	Qty           int    `json:"qty"`
+	PriceCents    int    `json:"price_cents"`
+	DiscountCents int    `json:"discount_cents"`
+	FinalCents    int    `json:"final_cents"`
+	TaxCents      int    `json:"tax_cents"`
 }
 
 type OrderCreatedPayload struct {
-	OrderID    string      `json:"order_id"`
-	ExternalID string      `json:"external_id"`
-	UserID     string      `json:"user_id"`
-	Items      []ItemPrice `json:"items"`
-	TotalCents int         `json:"total_cents"`
+	OrderID          string           `json:"order_id"`
+	ExternalID       string           `json:"external_id"`
+	UserID           string           `json:"user_id"`
+	Region           string           `json:"region,omitempty"` // region user, dipakai allocation strategy "closest"
+	Items            []ItemPrice      `json:"items"`
+	TotalCents       int              `json:"total_cents"`                 // setelah diskon, termasuk pajak
+	FulfilmentPolicy FulfilmentPolicy `json:"fulfilment_policy,omitempty"` // kosong = ALL_OR_NOTHING
+	// Pricing: total diskon, kupon yang di-redeem dan promosi yang dipakai
+	DiscountCents int               `json:"discount_cents,omitempty"`
+	CouponCode    string            `json:"coupon_code,omitempty"`
+	Discounts     []pricing.Applied `json:"discounts,omitempty"`
+	QuoteID       string            `json:"quote_id,omitempty"`
+	// v2
+	Currency string        `json:"currency"`
+	TaxCents int           `json:"tax_cents"`
+	Taxes    []tax.TaxLine `json:"taxes,omitempty"`
 }
 
 type StockReservedPayload struct {
-	OrderID string    `json:"order_id"`
-	Items   []ItemQty `json:"items"`
+	OrderID     string       `json:"order_id"`
+	Items       []ItemQty    `json:"items"`                 // qty yang ter-reserve
+	Allocations []Allocation `json:"allocations,omitempty"` // gudang sumber per item (bisa >1 per item kalau split)
+	// Field di bawah hanya terisi untuk fulfilment PARTIAL / BACKORDER.
+	Status      Status    `json:"status,omitempty"`      // STOCK_RESERVED | PARTIALLY_RESERVED
+	Backordered []ItemQty `json:"backordered,omitempty"` // menunggu restock
+	Cancelled   []ItemQty `json:"cancelled,omitempty"`   // tidak dikirim (PARTIAL)
+	TotalCents  int       `json:"total_cents,omitempty"` // total order setelah dihitung ulang
+	Currency    string    `json:"currency,omitempty"`    // v2, terisi bersama total_cents
 }
 
 type StockRejectedDetail struct {
@@ -63,6 +108,13 @@ type StockRejectedPayload struct {
 	Details []StockRejectedDetail `json:"details,omitempty"`
 }
 
+// StockReleased: reservasi order dilepas dan stok dikembalikan ke gudang.
+type StockReleasedPayload struct {
+	OrderID     string       `json:"order_id"`
+	Reason      string       `json:"reason"` // e.g., HOLD_EXPIRED
+	Allocations []Allocation `json:"allocations"`
+}
+
 type PaymentAuthorizedPayload struct {
 	OrderID     string `json:"order_id"`
 	PaymentRef  string `json:"payment_ref"`
@@ -79,3 +131,57 @@ type OrderFinalizedPayload struct {
 	FinalStatus string   `json:"final_status"`      // COMPLETED | FAILED
 	Reasons     []string `json:"reasons,omitempty"` // jika FAILED
 }
+
+// ---- Catalog (topic catalog.products, partition key = product_id) ----
+
+type ProductCreatedPayload struct {
+	ProductID   string `json:"product_id"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	PriceCents  int    `json:"price_cents"`
+	Stock       int    `json:"stock"`
+	Version     int    `json:"version"`
+	Currency    string `json:"currency"`     // v2
+	TaxCategory string `json:"tax_category"` // v2
+}
+
+type ProductUpdatedPayload struct {
+	ProductID   string   `json:"product_id"`
+	SKU         string   `json:"sku"`
+	Name        string   `json:"name"`
+	PriceCents  int      `json:"price_cents"`
+	Version     int      `json:"version"`
+	Hot         bool     `json:"hot"`
+	Currency    string   `json:"currency"`     // v2
+	TaxCategory string   `json:"tax_category"` // v2
+	Changed     []string `json:"changed"`      // e.g. ["name","price_cents"]
+}
+
+type ProductArchivedPayload struct {
+	ProductID  string    `json:"product_id"`
+	SKU        string    `json:"sku"`
+	Version    int       `json:"version"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// StockAdjusted: perubahan stok di luar alur order (restock, adjustment, shrinkage).
+type StockAdjustedPayload struct {
+	MovementID  string `json:"movement_id"`
+	ProductID   string `json:"product_id"`
+	WarehouseID string `json:"warehouse_id,omitempty"`
+	Kind        string `json:"kind"` // RESTOCK | ADJUSTMENT | SHRINKAGE
+	Delta       int    `json:"delta"`
+	StockAfter  int    `json:"stock_after"`
+	Reason      string `json:"reason,omitempty"`
+	Actor       string `json:"actor,omitempty"`
+}
+
+// StockLow / StockDepleted: stok melewati threshold reorder (topic inventory.stock.alerts,
+// partition key = product_id). Di-debounce, lihat AlertRepo.Evaluate.
+type StockAlertPayload struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Stock     int    `json:"stock"`
+	Threshold int    `json:"threshold"`
+	State     string `json:"state"` // LOW | DEPLETED
+}
@@ -0,0 +1,50 @@
+package orders
+
+// FulfilmentPolicy: apa yang dilakukan inventory kalau stok sebagian item kurang.
+type FulfilmentPolicy string
+
+const (
+	// FulfilAllOrNothing: satu item kurang -> seluruh order ditolak (OUT_OF_STOCK). Default.
+	FulfilAllOrNothing FulfilmentPolicy = "ALL_OR_NOTHING"
+	// FulfilPartial: reserve yang tersedia, sisanya dibatalkan; total order dihitung ulang.
+	FulfilPartial FulfilmentPolicy = "PARTIAL"
+	// FulfilBackorder: reserve yang tersedia, sisanya antre di backorders dan di-reserve saat restock.
+	FulfilBackorder FulfilmentPolicy = "BACKORDER"
+)
+
+// OrDefault: kosong = ALL_OR_NOTHING (payload lama tidak membawa policy).
+func (p FulfilmentPolicy) OrDefault() FulfilmentPolicy {
+	if p == "" {
+		return FulfilAllOrNothing
+	}
+	return p
+}
+
+func (p FulfilmentPolicy) Valid() bool {
+	switch p {
+	case FulfilAllOrNothing, FulfilPartial, FulfilBackorder:
+		return true
+	}
+	return false
+}
+
+// fitToAvailable: potong qty item yang kurang menjadi stok tersedia (dari shortages).
+// fit = qty yang masih bisa di-reserve (item qty 0 dibuang), short = sisa yang tidak terpenuhi.
+func fitToAvailable(items []ItemQty, shortages []StockRejectedDetail) (fit, short []ItemQty) {
+	avail := make(map[string]int, len(shortages))
+	for _, s := range shortages {
+		avail[s.ProductID] = s.Available
+	}
+	for _, it := range items {
+		a, isShort := avail[it.ProductID]
+		if !isShort || a >= it.Qty {
+			fit = append(fit, it)
+			continue
+		}
+		if a > 0 {
+			fit = append(fit, ItemQty{ProductID: it.ProductID, Qty: a})
+		}
+		short = append(short, ItemQty{ProductID: it.ProductID, Qty: it.Qty - max(a, 0)})
+	}
+	return fit, short
+}
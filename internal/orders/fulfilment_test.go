@@ -0,0 +1,51 @@
+package orders
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFitToAvailable(t *testing.T) {
+	items := []ItemQty{{ProductID: "a", Qty: 2}, {ProductID: "b", Qty: 5}, {ProductID: "c", Qty: 3}}
+	for _, tc := range []struct {
+		name      string
+		shortages []StockRejectedDetail
+		fit       []ItemQty
+		short     []ItemQty
+	}{
+		{
+			name: "tidak ada shortage",
+			fit:  items,
+		},
+		{
+			name:      "qty dipotong ke stok tersedia",
+			shortages: []StockRejectedDetail{{ProductID: "b", Required: 5, Available: 2}},
+			fit:       []ItemQty{{ProductID: "a", Qty: 2}, {ProductID: "b", Qty: 2}, {ProductID: "c", Qty: 3}},
+			short:     []ItemQty{{ProductID: "b", Qty: 3}},
+		},
+		{
+			name:      "stok 0 dan negatif: item dibuang dari fit",
+			shortages: []StockRejectedDetail{{ProductID: "a", Required: 2, Available: 0}, {ProductID: "c", Required: 3, Available: -1}},
+			fit:       []ItemQty{{ProductID: "b", Qty: 5}},
+			short:     []ItemQty{{ProductID: "a", Qty: 2}, {ProductID: "c", Qty: 3}},
+		},
+		{
+			// total cukup tapi tidak bisa dialokasikan strategy: item tetap utuh di fit
+			name:      "available cukup tetap utuh",
+			shortages: []StockRejectedDetail{{ProductID: "a", Required: 2, Available: 2}},
+			fit:       items,
+		},
+		{
+			name: "semua kurang",
+			shortages: []StockRejectedDetail{
+				{ProductID: "a", Required: 2, Available: 0}, {ProductID: "b", Required: 5, Available: 0}, {ProductID: "c", Required: 3, Available: 0},
+			},
+			short: items,
+		},
+	} {
+		fit, short := fitToAvailable(items, tc.shortages)
+		if !reflect.DeepEqual(fit, tc.fit) || !reflect.DeepEqual(short, tc.short) {
+			t.Errorf("%s: fit %v short %v, want %v %v", tc.name, fit, short, tc.fit, tc.short)
+		}
+	}
+}
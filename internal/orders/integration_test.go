@@ -0,0 +1,58 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Test integrasi butuh Postgres yang sudah di-migrate + seed (warehouse):
+//
+//	TEST_POSTGRES_DSN=postgres://... go test ./internal/orders
+func testDB(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	db, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// testProduct: produk baru (sku unik) dengan stok awal stock.
+func testProduct(t testing.TB, db *pgxpool.Pool, stock int) Product {
+	t.Helper()
+	p, err := (&CatalogRepo{DB: db}).CreateProduct(context.Background(),
+		NewProduct{SKU: "TEST-" + uuid.NewString()[:8], Name: "test", PriceCents: 100, Stock: stock}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testOrder(t testing.TB, db *pgxpool.Pool, items ...ItemInput) string {
+	t.Helper()
+	created, err := (&Repo{DB: db}).CreateOrderTx(context.Background(), fmt.Sprintf("TEST-%s", uuid.NewString()),
+		uuid.NewString(), items, PricingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created.OrderID
+}
+
+func productStock(t testing.TB, db *pgxpool.Pool, id string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(context.Background(), `SELECT stock FROM products WHERE id=$1`, id).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
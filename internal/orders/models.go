@@ -2,39 +2,58 @@ package orders
 
 import "time"
 
+// Product: tag json = kontrak publik (schema Product di api/openapi.json: GET /v1/products & admin
+// katalog). GET /products tetap bentuk lama, lihat httpx.LegacyProduct.
 type Product struct {
-	ID         string
-	SKU        string
-	Name       string
-	Stock      int
-	PriceCents int
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID          string     `json:"id"`
+	SKU         string     `json:"sku"`
+	Name        string     `json:"name"`
+	Stock       int        `json:"stock"`
+	PriceCents  int        `json:"price_cents"`
+	Version     int        `json:"version"`      // optimistic concurrency (lihat CatalogRepo)
+	Hot         bool       `json:"hot"`          // flash sale: reservasi lewat counter Redis
+	Currency    string     `json:"currency"`     // ISO 4217, berlaku untuk price_cents
+	TaxCategory string     `json:"tax_category"` // dipakai rule pajak (tax_rules.category)
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 type Order struct {
-	ID         string
-	ExternalID string
-	UserID     string
-	Status     Status // lihat status.go
-	TotalCents int
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID            string    `json:"id"`
+	ExternalID    string    `json:"external_id"`
+	UserID        string    `json:"user_id"`
+	Status        Status    `json:"status"` // lihat status.go
+	TotalCents    int       `json:"total_cents"`
+	DiscountCents int       `json:"discount_cents"`
+	TaxCents      int       `json:"tax_cents"`
+	Currency      string    `json:"currency"`
+	CouponCode    string    `json:"coupon_code,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// OrderItem: FulfilledQty nil = belum diproses inventory.
 type OrderItem struct {
-	ID         string
-	OrderID    string
-	ProductID  string
-	Qty        int
-	PriceCents int
+	ID            string `json:"id"`
+	OrderID       string `json:"order_id"`
+	ProductID     string `json:"product_id"`
+	SKU           string `json:"sku"`
+	Qty           int    `json:"qty"`
+	FulfilledQty  *int   `json:"fulfilled_qty,omitempty"`
+	PriceCents    int    `json:"price_cents"`
+	DiscountCents int    `json:"discount_cents"`
+	FinalCents    int    `json:"final_cents"`
+	TaxCents      int    `json:"tax_cents"`
 }
 
 type Reservation struct {
-	ID        string
-	OrderID   string
-	ProductID string
-	Qty       int
-	Status    string // RESERVED | RELEASED
-	CreatedAt time.Time
+	ID            string     `json:"id"`
+	OrderID       string     `json:"order_id"`
+	ProductID     string     `json:"product_id"`
+	WarehouseCode string     `json:"warehouse_code"`
+	Qty           int        `json:"qty"`
+	Status        string     `json:"status"` // RESERVED | RELEASED | EXPIRED
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 }
@@ -4,114 +4,162 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/google/uuid"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/pricing"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ItemInput: ExpectedPriceCents opsional = list price per unit yang dilihat customer; kalau beda
+// dengan harga saat order dibuat, order ditolak dengan ErrPriceChanged.
 type ItemInput struct {
-	ProductID string `json:"product_id"`
-	Qty       int    `json:"qty"`
+	ProductID          string `json:"product_id"`
+	Qty                int    `json:"qty"`
+	ExpectedPriceCents *int   `json:"expected_price_cents,omitempty"`
 }
 
 type ItemInputSKU struct {
-	SKU string `json:"sku"`
-	Qty int    `json:"qty"`
+	SKU                string `json:"sku"`
+	Qty                int    `json:"qty"`
+	ExpectedPriceCents *int   `json:"expected_price_cents,omitempty"`
 }
 
-type Repo struct{ DB *pgxpool.Pool }
-
-var ErrAlreadyExists = errors.New("order already exists")
+// Repo: Tax nil = order tanpa pajak.
+type Repo struct {
+	DB  *pgxpool.Pool
+	Tax tax.Calculator
+}
 
-// CreateOrderTx: idempotent via external_id.
-// - jika external_id sudah ada -> return existing order_id + total (existed=true).
-func (r *Repo) CreateOrderTx(ctx context.Context, externalID, userID string, items []ItemInput) (orderID string, total int, existed bool, err error) {
-	// cek existing by external_id
-	row := r.DB.QueryRow(ctx, `SELECT id, total_cents FROM orders WHERE external_id=$1`, externalID)
-	if err = row.Scan(&orderID, &total); err == nil {
-		return orderID, total, true, nil
-	} else if !errors.Is(err, pgx.ErrNoRows) {
-		return "", 0, false, err
-	}
+var (
+	ErrAlreadyExists = errors.New("order already exists")
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrProductsBusy: produk order terus berubah selama createAttempts dan client tidak mengirim
+	// expected_price_cents / quote; aman diulang.
+	ErrProductsBusy = errors.New("products changed while creating order, retry")
+)
 
-	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return "", 0, false, err
-	}
-	defer func() { _ = tx.Rollback(ctx) }()
+// CreatedOrder: hasil CreateOrderTx / CreateOrderBySKU.
+type CreatedOrder struct {
+	OrderID       string
+	Currency      string
+	TotalCents    int // setelah diskon, termasuk pajak
+	DiscountCents int
+	TaxCents      int
+	CouponCode    string
+	QuoteID       string
+	Existed       bool              // external_id sudah pernah dipakai
+	Items         []ItemPrice       // product_id + list price dari tabel products saat order dibuat + diskon & pajak per line
+	Taxes         []tax.TaxLine     // pajak per line (order_taxes)
+	Discounts     []pricing.Applied // promosi yang dipakai (kosong kalau Existed)
+}
 
-	// hitung total berdasarkan price dari table products (hindari trust dari client)
-	type priced struct {
-		id    string
-		price int
-	}
-	productIDs := make([]any, 0, len(items))
-	params := ""
-	for i, it := range items {
-		if i > 0 {
-			params += ","
+// existingOrder: cek by external_id; found=false kalau belum ada.
+func (r *Repo) existingOrder(ctx context.Context, externalID string) (out CreatedOrder, found bool, err error) {
+	row := r.DB.QueryRow(ctx, `SELECT id, currency, total_cents, discount_cents, tax_cents, COALESCE(coupon_code, ''), COALESCE(quote_id::text, '')
+	                           FROM orders WHERE external_id=$1`, externalID)
+	if err := row.Scan(&out.OrderID, &out.Currency, &out.TotalCents, &out.DiscountCents, &out.TaxCents, &out.CouponCode, &out.QuoteID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CreatedOrder{}, false, nil
 		}
-		params += fmt.Sprintf("$%d", i+1)
-		productIDs = append(productIDs, it.ProductID)
+		return CreatedOrder{}, false, err
 	}
-	rows, err := tx.Query(ctx, `SELECT id, price_cents FROM products WHERE id IN (`+params+`)`, productIDs...)
+	out.Existed = true
+	out.Items, err = r.orderItems(ctx, out.OrderID)
 	if err != nil {
-		return "", 0, false, err
+		return CreatedOrder{}, false, err
 	}
-	prices := map[string]int{}
-	for rows.Next() {
-		var p priced
-		if err := rows.Scan(&p.id, &p.price); err != nil {
-			return "", 0, false, err
-		}
-		prices[p.id] = p.price
+	out.Taxes, err = r.orderTaxes(ctx, out.OrderID)
+	if err != nil {
+		return CreatedOrder{}, false, err
 	}
-	if err := rows.Err(); err != nil {
-		return "", 0, false, err
+	return out, true, nil
+}
+
+func (r *Repo) orderTaxes(ctx context.Context, orderID string) ([]tax.TaxLine, error) {
+	rows, err := r.DB.Query(ctx, `SELECT product_id, name, rate_bp, taxable_cents, tax_cents FROM order_taxes WHERE order_id=$1`, orderID)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	for _, it := range items {
-		price, ok := prices[it.ProductID]
-		if !ok {
-			return "", 0, false, fmt.Errorf("product not found: %s", it.ProductID)
-		}
-		if it.Qty <= 0 {
-			return "", 0, false, fmt.Errorf("invalid qty for product %s", it.ProductID)
+	var out []tax.TaxLine
+	for rows.Next() {
+		var t tax.TaxLine
+		if err := rows.Scan(&t.ProductID, &t.Name, &t.RateBP, &t.TaxableCents, &t.TaxCents); err != nil {
+			return nil, err
 		}
-		total += price * it.Qty
+		out = append(out, t)
 	}
+	return out, rows.Err()
+}
 
-	orderID = uuid.NewString()
-	_, err = tx.Exec(ctx, `
-		INSERT INTO orders(id, external_id, user_id, status, total_cents)
-		VALUES ($1, $2, $3, 'CREATED', $4)
-	`, orderID, externalID, userID, total)
+func (r *Repo) orderItems(ctx context.Context, orderID string) ([]ItemPrice, error) {
+	rows, err := r.DB.Query(ctx, `SELECT product_id, qty, price_cents, discount_cents, final_cents, tax_cents FROM order_items WHERE order_id=$1`, orderID)
 	if err != nil {
-		return "", 0, false, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	// insert items
-	for _, it := range items {
-		price := prices[it.ProductID]
-		_, err = tx.Exec(ctx, `
-			INSERT INTO order_items(order_id, product_id, qty, price_cents)
-			VALUES ($1, $2, $3, $4)`,
-			orderID, it.ProductID, it.Qty, price,
-		)
-		if err != nil {
-			return "", 0, false, err
+	var out []ItemPrice
+	for rows.Next() {
+		var it ItemPrice
+		if err := rows.Scan(&it.ProductID, &it.Qty, &it.PriceCents, &it.DiscountCents, &it.FinalCents, &it.TaxCents); err != nil {
+			return nil, err
 		}
+		out = append(out, it)
 	}
+	return out, rows.Err()
+}
 
-	if err := tx.Commit(ctx); err != nil {
-		return "", 0, false, err
+// CreateOrderTx: idempotent via external_id.
+//   - jika external_id sudah ada -> return order yang sudah ada (Existed=true).
+//   - harga: list price products (di-lock baru sebelum commit, lihat createOrder) -> quote -> promosi / kupon ->
+//     pajak (lihat insertOrder).
+func (r *Repo) CreateOrderTx(ctx context.Context, externalID, userID string, items []ItemInput, opts PricingOptions) (CreatedOrder, error) {
+	// cek existing by external_id
+	if ex, found, err := r.existingOrder(ctx, externalID); err != nil || found {
+		return ex, err
+	}
+
+	// hitung total berdasarkan price dari table products (hindari trust dari client)
+	productIDs := make([]any, 0, len(items))
+	params := ""
+	for i, it := range items {
+		if i > 0 {
+			params += ","
+		}
+		params += fmt.Sprintf("$%d", i+1)
+		productIDs = append(productIDs, it.ProductID)
 	}
-	return orderID, total, false, nil
+	return r.createOrder(ctx, externalID, userID, opts, func(tx pgx.Tx) ([]orderLine, error) {
+		byID, err := loadProducts(ctx, tx, `id IN (`+params+`)`, productIDs, func(p listedProduct) string { return p.id })
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]orderLine, 0, len(items))
+		for _, it := range items {
+			p, ok := byID[it.ProductID]
+			if !ok {
+				return nil, fmt.Errorf("%w: product_id=%s", ErrProductNotFound, it.ProductID)
+			}
+			if p.archived {
+				return nil, fmt.Errorf("%w: product_id=%s", ErrProductArchived, it.ProductID)
+			}
+			if it.Qty <= 0 {
+				return nil, fmt.Errorf("invalid qty for product %s", it.ProductID)
+			}
+			lines = append(lines, p.line(it.Qty, it.ExpectedPriceCents))
+		}
+		return lines, nil
+	})
 }
 
 func (r *Repo) GetOrderStatus(ctx context.Context, orderID string) (Status, error) {
 	var s string
 	err := r.DB.QueryRow(ctx, `SELECT status FROM orders WHERE id=$1`, orderID).Scan(&s)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrOrderNotFound
+	}
 	if err != nil {
 		return "", err
 	}
@@ -119,8 +167,8 @@ func (r *Repo) GetOrderStatus(ctx context.Context, orderID string) (Status, erro
 }
 
 func (r *Repo) ListProducts(ctx context.Context) ([]Product, error) {
-	rows, err := r.DB.Query(ctx, `SELECT id, sku, name, stock, price_cents, created_at, updated_at
-                                FROM products ORDER BY sku`)
+	rows, err := r.DB.Query(ctx, `SELECT id, sku, name, stock, price_cents, version, hot, currency, tax_category, created_at, updated_at
+                                FROM products WHERE archived_at IS NULL ORDER BY sku`)
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +177,8 @@ func (r *Repo) ListProducts(ctx context.Context) ([]Product, error) {
 	var out []Product
 	for rows.Next() {
 		var p Product
-		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Stock, &p.PriceCents, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.SKU, &p.Name, &p.Stock, &p.PriceCents, &p.Version, &p.Hot, &p.Currency, &p.TaxCategory,
+			&p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
 		out = append(out, p)
@@ -137,18 +186,12 @@ func (r *Repo) ListProducts(ctx context.Context) ([]Product, error) {
 	return out, rows.Err()
 }
 
-func (r *Repo) CreateOrderBySKU(ctx context.Context, externalID, userID string, items []ItemInputSKU) (orderID string, total int, existed bool, err error) {
+// CreateOrderBySKU: sama seperti CreateOrderTx, sku di-resolve ke product_id + harga.
+func (r *Repo) CreateOrderBySKU(ctx context.Context, externalID, userID string, items []ItemInputSKU, opts PricingOptions) (CreatedOrder, error) {
 	// cek existing
-	row := r.DB.QueryRow(ctx, `SELECT id, total_cents FROM orders WHERE external_id=$1`, externalID)
-	if err = row.Scan(&orderID, &total); err == nil {
-		return orderID, total, true, nil
-	}
-
-	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
-	if err != nil {
-		return "", 0, false, err
+	if ex, found, err := r.existingOrder(ctx, externalID); err != nil || found {
+		return ex, err
 	}
-	defer func() { _ = tx.Rollback(ctx) }()
 
 	// ambil id & price dari sku
 	skus := make([]any, 0, len(items))
@@ -160,51 +203,156 @@ func (r *Repo) CreateOrderBySKU(ctx context.Context, externalID, userID string,
 		params += fmt.Sprintf("$%d", i+1)
 		skus = append(skus, it.SKU)
 	}
-	rows, err := tx.Query(ctx, `SELECT id, sku, price_cents FROM products WHERE sku IN (`+params+`)`, skus...)
+	return r.createOrder(ctx, externalID, userID, opts, func(tx pgx.Tx) ([]orderLine, error) {
+		bySKU, err := loadProducts(ctx, tx, `sku IN (`+params+`)`, skus, func(p listedProduct) string { return p.sku })
+		if err != nil {
+			return nil, err
+		}
+		lines := make([]orderLine, 0, len(items))
+		for _, it := range items {
+			p, ok := bySKU[it.SKU]
+			if !ok {
+				return nil, fmt.Errorf("%w: sku=%s", ErrProductNotFound, it.SKU)
+			}
+			if p.archived {
+				return nil, fmt.Errorf("%w: sku=%s", ErrProductArchived, it.SKU)
+			}
+			if it.Qty <= 0 {
+				return nil, fmt.Errorf("invalid qty for sku=%s", it.SKU)
+			}
+			lines = append(lines, p.line(it.Qty, it.ExpectedPriceCents))
+		}
+		return lines, nil
+	})
+}
+
+// createAttempts: berapa kali order dihitung ulang kalau produknya berubah di tengah tx.
+const createAttempts = 3
+
+// createOrder: load (list price dari products) + insertOrder dalam satu tx. Row products tidak di-lock
+// saat load (FOR SHARE menahan moveStock / update katalog selama pricing, kupon & pajak); sebagai gantinya
+// checkProductVersions me-lock (FOR SHARE) dan mengecek ulang version produk tepat sebelum commit. Kalau
+// ada yang berubah, tx di-rollback dan order dihitung ulang dengan harga baru.
+// Masih berubah setelah createAttempts: PriceChangedError hanya kalau client memang mengunci harga
+// (expected_price_cents / quote), selain itu ErrProductsBusy.
+func (r *Repo) createOrder(ctx context.Context, externalID, userID string, opts PricingOptions, load func(tx pgx.Tx) ([]orderLine, error)) (CreatedOrder, error) {
+	var err error
+	var pc *PriceChangedError
+	for attempt := 0; attempt < createAttempts; attempt++ {
+		var created CreatedOrder
+		created, err = r.createOrderOnce(ctx, externalID, userID, opts, load)
+		if !isRetryable(err) && (!errors.As(err, &pc) || !pc.concurrent) {
+			return created, err
+		}
+	}
+	if errors.As(err, &pc) && (opts.QuoteID != "" || pc.expected) {
+		return CreatedOrder{}, err
+	}
+	return CreatedOrder{}, fmt.Errorf("%w: %v", ErrProductsBusy, err)
+}
+
+func (r *Repo) createOrderOnce(ctx context.Context, externalID, userID string, opts PricingOptions, load func(tx pgx.Tx) ([]orderLine, error)) (CreatedOrder, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
-		return "", 0, false, err
+		return CreatedOrder{}, err
 	}
-	type pp struct {
-		id, sku string
-		price   int
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	lines, err := load(tx)
+	if err != nil {
+		return CreatedOrder{}, err
 	}
-	bySKU := map[string]pp{}
-	for rows.Next() {
-		var p pp
-		if err := rows.Scan(&p.id, &p.sku, &p.price); err != nil {
-			return "", 0, false, err
-		}
-		bySKU[p.sku] = p
+	seen := make([]orderLine, len(lines)) // insertOrder bisa mengganti PriceCents (quote)
+	copy(seen, lines)
+
+	created, err := r.insertOrder(ctx, tx, externalID, userID, lines, opts)
+	if err != nil {
+		return CreatedOrder{}, err
 	}
-	if err := rows.Err(); err != nil {
-		return "", 0, false, err
+	if err := checkProductVersions(ctx, tx, seen); err != nil {
+		return CreatedOrder{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return CreatedOrder{}, err
 	}
+	return created, nil
+}
 
-	for _, it := range items {
-		p, ok := bySKU[it.SKU]
-		if !ok {
-			return "", 0, false, fmt.Errorf("product not found: sku=%s", it.SKU)
-		}
-		if it.Qty <= 0 {
-			return "", 0, false, fmt.Errorf("invalid qty for sku=%s", it.SKU)
+// listedProduct: kolom products yang dibutuhkan untuk membuat order.
+type listedProduct struct {
+	id, sku            string
+	price, version     int
+	archived           bool
+	currency, category string
+}
+
+func (p listedProduct) line(qty int, expected *int) orderLine {
+	return orderLine{
+		ItemPrice: ItemPrice{ProductID: p.id, Qty: qty, PriceCents: p.price},
+		SKU:       p.sku, Currency: p.currency, TaxCategory: p.category, ExpectedCents: expected, Version: p.version,
+	}
+}
+
+func loadProducts(ctx context.Context, tx pgx.Tx, where string, args []any, key func(listedProduct) string) (map[string]listedProduct, error) {
+	rows, err := tx.Query(ctx, `SELECT id, sku, price_cents, version, archived_at IS NOT NULL, currency, tax_category
+	                            FROM products WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[string]listedProduct{}
+	for rows.Next() {
+		var p listedProduct
+		if err := rows.Scan(&p.id, &p.sku, &p.price, &p.version, &p.archived, &p.currency, &p.category); err != nil {
+			return nil, err
 		}
-		total += p.price * it.Qty
+		out[key(p)] = p
 	}
+	return out, rows.Err()
+}
 
-	orderID = uuid.NewString()
-	if _, err = tx.Exec(ctx, `INSERT INTO orders(id, external_id, user_id, status, total_cents)
-	                          VALUES ($1,$2,$3,'CREATED',$4)`, orderID, externalID, userID, total); err != nil {
-		return "", 0, false, err
+// checkProductVersions: produk order tidak berubah (harga / currency / pajak / archive) sejak dibaca.
+// Row di-lock FOR SHARE (urut id, sama dengan moveStock) sampai commit, jadi tidak bisa berubah lagi
+// setelah dicek.
+func checkProductVersions(ctx context.Context, tx pgx.Tx, lines []orderLine) error {
+	ids := make([]string, len(lines))
+	for i, l := range lines {
+		ids[i] = l.ProductID
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT id, price_cents, version FROM products
+		WHERE id = ANY($1::uuid[])
+		ORDER BY id
+		FOR SHARE`, ids)
+	if err != nil {
+		return err
 	}
-	for _, it := range items {
-		p := bySKU[it.SKU]
-		if _, err = tx.Exec(ctx, `INSERT INTO order_items(order_id, product_id, qty, price_cents)
-		                          VALUES ($1,$2,$3,$4)`, orderID, p.id, it.Qty, p.price); err != nil {
-			return "", 0, false, err
+	defer rows.Close()
+	pc := &PriceChangedError{concurrent: true}
+	for rows.Next() {
+		var id string
+		var price, version int
+		if err := rows.Scan(&id, &price, &version); err != nil {
+			return err
+		}
+		for _, l := range lines {
+			if l.ProductID != id || l.Version == version {
+				continue
+			}
+			expected := l.PriceCents
+			if l.ExpectedCents != nil {
+				expected = *l.ExpectedCents
+				pc.expected = true
+			}
+			pc.Changes = append(pc.Changes, PriceChange{ProductID: id, SKU: l.SKU, ExpectedCents: expected, ActualCents: price})
+			break
 		}
 	}
-	if err := tx.Commit(ctx); err != nil {
-		return "", 0, false, err
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(pc.Changes) > 0 {
+		return pc
 	}
-	return orderID, total, false, nil
+	return nil
 }
@@ -0,0 +1,180 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// State stok terhadap threshold reorder (stock_alerts.state).
+const (
+	AlertOK       = "OK"
+	AlertLow      = "LOW"
+	AlertDepleted = "DEPLETED"
+)
+
+// StockAlert: produk dengan threshold + state alert terakhir.
+type StockAlert struct {
+	ProductID  string    `json:"product_id"`
+	SKU        string    `json:"sku"`
+	Stock      int       `json:"stock"`
+	Threshold  int       `json:"threshold"`
+	State      string    `json:"state"`
+	StateSince time.Time `json:"state_since"`
+}
+
+// AlertChange: hasil Evaluate untuk produk yang state-nya berubah.
+type AlertChange struct {
+	StockAlert
+	Prev   string
+	Notify bool // perlu publish StockLow / StockDepleted (sudah lewat debounce)
+}
+
+// AlertRepo: threshold reorder + state alert per produk.
+type AlertRepo struct{ DB *pgxpool.Pool }
+
+// SetThreshold: set / ubah threshold; state dihitung ulang di Evaluate berikutnya.
+func (r *AlertRepo) SetThreshold(ctx context.Context, productID string, threshold int) error {
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO stock_alerts(product_id, threshold) VALUES ($1, $2)
+		ON CONFLICT (product_id) DO UPDATE SET threshold = EXCLUDED.threshold, updated_at = now()`, productID, threshold)
+	if isFKViolation(err) {
+		return ErrProductNotFound
+	}
+	return err
+}
+
+func (r *AlertRepo) Get(ctx context.Context, productID string) (StockAlert, error) {
+	var a StockAlert
+	err := r.DB.QueryRow(ctx, `
+		SELECT a.product_id, p.sku, p.stock, a.threshold, a.state, a.state_since
+		FROM stock_alerts a JOIN products p ON p.id = a.product_id
+		WHERE a.product_id = $1`, productID).Scan(&a.ProductID, &a.SKU, &a.Stock, &a.Threshold, &a.State, &a.StateSince)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return StockAlert{}, ErrProductNotFound
+	}
+	return a, err
+}
+
+// RemoveThreshold: produk tidak lagi dipantau.
+func (r *AlertRepo) RemoveThreshold(ctx context.Context, productID string) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM stock_alerts WHERE product_id = $1`, productID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// Active: produk yang sedang LOW / DEPLETED, stok terkecil dulu.
+func (r *AlertRepo) Active(ctx context.Context) ([]StockAlert, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT a.product_id, p.sku, p.stock, a.threshold, a.state, a.state_since
+		FROM stock_alerts a JOIN products p ON p.id = a.product_id
+		WHERE a.state <> 'OK' AND p.archived_at IS NULL
+		ORDER BY p.stock, p.sku`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []StockAlert
+	for rows.Next() {
+		var a StockAlert
+		if err := rows.Scan(&a.ProductID, &a.SKU, &a.Stock, &a.Threshold, &a.State, &a.StateSince); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// Evaluate: hitung ulang state alert produk-produk ini dari products.stock (row stock_alerts di-lock
+// supaya dua evaluasi paralel tidak publish dobel). Hanya perubahan state yang dikembalikan.
+// StockLow tidak di-publish ulang kalau publish terakhir masih dalam cooldown.
+func (r *AlertRepo) Evaluate(ctx context.Context, productIDs []string, cooldown time.Duration) (changes []AlertChange, err error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+	err = pgx.BeginTxFunc(ctx, r.DB, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		changes = nil
+		rows, err := tx.Query(ctx, `
+			SELECT a.product_id, p.sku, p.stock, a.threshold, a.state, a.state_since, a.last_notified_at
+			FROM stock_alerts a JOIN products p ON p.id = a.product_id
+			WHERE a.product_id = ANY($1::uuid[])
+			ORDER BY a.product_id
+			FOR UPDATE OF a`, productIDs)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for rows.Next() {
+			var a StockAlert
+			var notified *time.Time
+			if err := rows.Scan(&a.ProductID, &a.SKU, &a.Stock, &a.Threshold, &a.State, &a.StateSince, &notified); err != nil {
+				rows.Close()
+				return err
+			}
+			next := NextAlertState(a.State, a.Stock, a.Threshold)
+			if next == a.State {
+				continue
+			}
+			c := AlertChange{StockAlert: a, Prev: a.State}
+			c.State, c.StateSince = next, now
+			c.Notify = alertNotify(c.Prev, next, notified, now, cooldown)
+			changes = append(changes, c)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, c := range changes {
+			if _, err := tx.Exec(ctx, `
+				UPDATE stock_alerts SET state = $2, state_since = $3, updated_at = $3,
+					last_notified_at = CASE WHEN $4 THEN $3 ELSE last_notified_at END
+				WHERE product_id = $1`, c.ProductID, c.State, c.StateSince, c.Notify); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// NextAlertState: LOW kalau stock <= threshold, DEPLETED kalau habis. Kembali OK baru setelah stok
+// naik melewati threshold + 10% (min 1) supaya stok yang naik-turun di sekitar threshold tidak spam.
+// DEPLETED -> LOW (restock sebagian) tetap dicatat tapi tidak di-notify.
+func NextAlertState(cur string, stock, threshold int) string {
+	hyst := max(threshold/10, 1)
+	switch {
+	case stock <= 0:
+		return AlertDepleted
+	case stock <= threshold:
+		return AlertLow
+	case cur != AlertOK && stock <= threshold+hyst:
+		if cur == AlertDepleted {
+			return AlertLow
+		}
+		return cur
+	default:
+		return AlertOK
+	}
+}
+
+// alertNotify: DEPLETED selalu di-notify; OK -> LOW hanya kalau notify terakhir sudah lewat cooldown.
+// Perubahan lain (DEPLETED -> LOW, kembali OK) hanya dicatat.
+func alertNotify(prev, next string, notified *time.Time, now time.Time, cooldown time.Duration) bool {
+	switch {
+	case next == AlertDepleted:
+		return true
+	case next == AlertLow && prev == AlertOK:
+		return notified == nil || now.Sub(*notified) >= cooldown
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package orders
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextAlertState(t *testing.T) {
+	for _, tc := range []struct {
+		cur              string
+		stock, threshold int
+		want             string
+	}{
+		{AlertOK, 50, 20, AlertOK},
+		{AlertOK, 20, 20, AlertLow}, // tepat di threshold sudah LOW
+		{AlertOK, 0, 20, AlertDepleted},
+		{AlertOK, -3, 20, AlertDepleted},
+		{AlertLow, 5, 20, AlertLow},
+		{AlertLow, 0, 20, AlertDepleted},
+		// hysteresis: threshold 20 -> harus > 22 untuk kembali OK
+		{AlertLow, 21, 20, AlertLow},
+		{AlertLow, 22, 20, AlertLow},
+		{AlertLow, 23, 20, AlertOK},
+		{AlertOK, 21, 20, AlertOK}, // dari OK tidak ada hysteresis
+		// DEPLETED: restock sebagian ke LOW, di zona hysteresis juga LOW, di atasnya OK
+		{AlertDepleted, 10, 20, AlertLow},
+		{AlertDepleted, 22, 20, AlertLow},
+		{AlertDepleted, 23, 20, AlertOK},
+		// threshold kecil: hysteresis minimal 1
+		{AlertLow, 5, 5, AlertLow},
+		{AlertLow, 6, 5, AlertLow},
+		{AlertLow, 7, 5, AlertOK},
+		{AlertDepleted, 1, 0, AlertLow},
+		{AlertDepleted, 2, 0, AlertOK},
+	} {
+		if got := NextAlertState(tc.cur, tc.stock, tc.threshold); got != tc.want {
+			t.Errorf("NextAlertState(%s, stock %d, threshold %d) = %s, want %s", tc.cur, tc.stock, tc.threshold, got, tc.want)
+		}
+	}
+}
+
+func TestAlertNotifyCooldown(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time { t := now.Add(-d); return &t }
+	const cooldown = time.Hour
+
+	for _, tc := range []struct {
+		name       string
+		prev, next string
+		notified   *time.Time
+		want       bool
+	}{
+		{"low pertama kali", AlertOK, AlertLow, nil, true},
+		{"low masih cooldown", AlertOK, AlertLow, ago(10 * time.Minute), false},
+		{"low tepat habis cooldown", AlertOK, AlertLow, ago(cooldown), true},
+		{"low setelah cooldown", AlertOK, AlertLow, ago(2 * time.Hour), true},
+		{"depleted tanpa cooldown", AlertLow, AlertDepleted, ago(time.Minute), true},
+		{"depleted dari ok", AlertOK, AlertDepleted, ago(time.Minute), true},
+		{"restock sebagian ke low", AlertDepleted, AlertLow, nil, false},
+		{"kembali ok", AlertLow, AlertOK, nil, false},
+	} {
+		if got := alertNotify(tc.prev, tc.next, tc.notified, now, cooldown); got != tc.want {
+			t.Errorf("%s: notify %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
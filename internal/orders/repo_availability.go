@@ -0,0 +1,60 @@
+package orders
+
+import "context"
+
+// Bucket availability kasar (untuk client yang tidak boleh lihat angka stok).
+const (
+	AvailInStock = "IN_STOCK"
+	AvailLow     = "LOW"
+	AvailOut     = "OUT"
+)
+
+// Availability: stok per SKU. OnHand = Available + Reserved (reservasi yang masih ditahan).
+type Availability struct {
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Available int    `json:"available"`
+	Reserved  int    `json:"reserved"`
+	OnHand    int    `json:"on_hand"`
+	Threshold int    `json:"threshold"` // threshold reorder, 0 = tidak di-set
+}
+
+// Bucket: LOW mengikuti threshold reorder (stock_alerts); tanpa threshold hanya IN_STOCK / OUT.
+func (a Availability) Bucket() string {
+	switch {
+	case a.Available <= 0:
+		return AvailOut
+	case a.Available <= a.Threshold:
+		return AvailLow
+	default:
+		return AvailInStock
+	}
+}
+
+// Availability: produk aktif untuk sku-sku ini; sku yang tidak ada / archived tidak dikembalikan.
+func (r *InventoryRepo) Availability(ctx context.Context, skus []string) ([]Availability, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.id, p.sku, p.stock, COALESCE(rs.qty, 0), COALESCE(a.threshold, 0)
+		FROM products p
+		LEFT JOIN LATERAL (
+			SELECT SUM(qty)::int AS qty FROM reservations
+			WHERE product_id = p.id AND status = 'RESERVED'
+		) rs ON true
+		LEFT JOIN stock_alerts a ON a.product_id = p.id
+		WHERE p.sku = ANY($1::text[]) AND p.archived_at IS NULL`, skus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Availability
+	for rows.Next() {
+		var a Availability
+		if err := rows.Scan(&a.ProductID, &a.SKU, &a.Available, &a.Reserved, &a.Threshold); err != nil {
+			return nil, err
+		}
+		a.OnHand = a.Available + a.Reserved
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,143 @@
+package orders
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// BackorderFill: hasil auto-reserve backorder untuk satu order.
+type BackorderFill struct {
+	OrderID     string
+	Allocations []Allocation
+	Complete    bool // tidak ada backorder PENDING tersisa -> status STOCK_RESERVED
+}
+
+// FillBackorders: reserve backorder PENDING satu produk secara FIFO (created_at) dari stok yang ada
+// sekarang; backorder yang hanya terisi sebagian tetap PENDING dengan qty sisa. Dipanggil saat restock
+// dan saat reservasi lain dilepas (StockReleased).
+func (r *ReservationRepo) FillBackorders(ctx context.Context, productID string) (fills []BackorderFill, err error) {
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		fills = nil
+
+		type pending struct {
+			id, orderID, region string
+			qty                 int
+		}
+		// hanya order yang masih menunggu backorder; order yang sudah FAILED / hold-nya expired tidak
+		// boleh mengambil stok lagi. Order ikut di-lock (FOR UPDATE, bukan SHARE: status-nya di-update di
+		// akhir tx, upgrade lock dari SHARE bisa deadlock dengan fill produk lain) supaya status tidak
+		// berubah di tengah tx.
+		rows, err := tx.Query(ctx, `
+			SELECT b.id, b.order_id, b.region, b.qty
+			FROM backorders b JOIN orders o ON o.id = b.order_id
+			WHERE b.product_id = $1 AND b.status = 'PENDING' AND o.status = 'PARTIALLY_RESERVED'
+			ORDER BY b.created_at, b.id
+			FOR UPDATE OF b, o`, productID)
+		if err != nil {
+			return err
+		}
+		var queue []pending
+		for rows.Next() {
+			var b pending
+			if err := rows.Scan(&b.id, &b.orderID, &b.region, &b.qty); err != nil {
+				rows.Close()
+				return err
+			}
+			queue = append(queue, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(queue) == 0 {
+			return nil
+		}
+
+		stock, err := lockWarehouseStock(ctx, tx, []string{productID})
+		if err != nil {
+			return err
+		}
+		available := func() int {
+			n := 0
+			for _, w := range stock[productID] {
+				n += w.Available
+			}
+			return n
+		}
+
+		byOrder := map[string]int{} // order_id -> index di fills
+		var touched []string
+		for _, b := range queue {
+			avail := available()
+			if avail <= 0 {
+				break
+			}
+			q := min(b.qty, avail)
+			allocs, short := r.strategy().Allocate(AllocationRequest{
+				Region: b.region, Items: []ItemQty{{ProductID: productID, Qty: q}}, Stock: stock,
+			})
+			if len(short) > 0 {
+				continue
+			}
+			// kurangi stok in-memory supaya backorder berikutnya melihat sisa yang benar
+			for _, a := range allocs {
+				for i := range stock[productID] {
+					if stock[productID][i].WarehouseID == a.WarehouseID {
+						stock[productID][i].Available -= a.Qty
+					}
+				}
+			}
+
+			if err := moveStock(ctx, tx, allocs, -1, MoveReserve, "backorder filled", b.orderID); err != nil {
+				return err
+			}
+			if err := insertReservations(ctx, tx, b.orderID, allocs, r.holdInterval()); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE backorders SET qty = qty - $2,
+					status = CASE WHEN qty = $2 THEN 'FILLED' ELSE status END,
+					filled_at = CASE WHEN qty = $2 THEN now() ELSE filled_at END
+				WHERE id = $1`, b.id, q); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(ctx, `
+				UPDATE order_items SET fulfilled_qty = COALESCE(fulfilled_qty, 0) + $3
+				WHERE order_id = $1 AND product_id = $2`, b.orderID, productID, q); err != nil {
+				return err
+			}
+
+			i, ok := byOrder[b.orderID]
+			if !ok {
+				i = len(fills)
+				byOrder[b.orderID] = i
+				fills = append(fills, BackorderFill{OrderID: b.orderID})
+				touched = append(touched, b.orderID)
+			}
+			fills[i].Allocations = append(fills[i].Allocations, allocs...)
+		}
+		if len(touched) == 0 {
+			return nil
+		}
+
+		rows, err = tx.Query(ctx, `
+			UPDATE orders SET status = 'STOCK_RESERVED'
+			WHERE id = ANY($1::uuid[]) AND status = 'PARTIALLY_RESERVED'
+			  AND NOT EXISTS (SELECT 1 FROM backorders b WHERE b.order_id = orders.id AND b.status = 'PENDING')
+			RETURNING id`, touched)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			fills[byOrder[id]].Complete = true
+		}
+		return rows.Err()
+	})
+	return fills, err
+}
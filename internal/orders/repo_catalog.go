@@ -0,0 +1,143 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrProductArchived = errors.New("product archived")
+	ErrSKUExists       = errors.New("sku already exists")
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// CatalogRepo: CRUD produk untuk admin API. Update/archive pakai optimistic concurrency
+// (WHERE version = expected), version naik 1 setiap perubahan.
+type CatalogRepo struct{ DB *pgxpool.Pool }
+
+// NewProduct: Currency / TaxCategory kosong = default kolom (USD / STANDARD).
+type NewProduct struct {
+	SKU         string
+	Name        string
+	PriceCents  int
+	Stock       int
+	Currency    string
+	TaxCategory string
+}
+
+// ProductPatch: field nil = tidak diubah. Currency sengaja tidak bisa diubah (harga lama di
+// order & promosi FIXED terikat ke currency produk).
+type ProductPatch struct {
+	Name        *string
+	PriceCents  *int
+	Hot         *bool
+	TaxCategory *string
+}
+
+const productCols = `id, sku, name, stock, price_cents, version, hot, currency, tax_category, archived_at, created_at, updated_at`
+
+func scanProduct(row pgx.Row) (Product, error) {
+	var p Product
+	err := row.Scan(&p.ID, &p.SKU, &p.Name, &p.Stock, &p.PriceCents, &p.Version, &p.Hot, &p.Currency, &p.TaxCategory,
+		&p.ArchivedAt, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+// CreateProduct: insert produk + movement INITIAL untuk stok awal (satu tx).
+func (r *CatalogRepo) CreateProduct(ctx context.Context, in NewProduct, actor string) (Product, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Product{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	p, err := scanProduct(tx.QueryRow(ctx, `
+		INSERT INTO products(sku, name, stock, price_cents, currency, tax_category)
+		VALUES ($1,$2,$3,$4, COALESCE(NULLIF($5, ''), 'USD'), COALESCE(NULLIF($6, ''), 'STANDARD'))
+		RETURNING `+productCols, in.SKU, in.Name, in.Stock, in.PriceCents, in.Currency, in.TaxCategory))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation (sku)
+		return Product{}, fmt.Errorf("%w: %s", ErrSKUExists, in.SKU)
+	}
+	if err != nil {
+		return Product{}, err
+	}
+	// stok awal masuk gudang default
+	whID, err := DefaultWarehouse(ctx, tx)
+	if err != nil {
+		return Product{}, err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO warehouse_stock(warehouse_id, product_id, stock) VALUES ($1,$2,$3)`,
+		whID, p.ID, p.Stock); err != nil {
+		return Product{}, err
+	}
+	if _, err := recordMovement(ctx, tx, Movement{
+		ProductID: p.ID, WarehouseID: &whID, Delta: p.Stock, Kind: MoveInitial, Reason: "product created", Actor: actor, StockAfter: p.Stock,
+	}); err != nil {
+		return Product{}, err
+	}
+	return p, tx.Commit(ctx)
+}
+
+func (r *CatalogRepo) GetProductBySKU(ctx context.Context, sku string) (Product, error) {
+	p, err := scanProduct(r.DB.QueryRow(ctx, `SELECT `+productCols+` FROM products WHERE sku=$1`, sku))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Product{}, ErrProductNotFound
+	}
+	return p, err
+}
+
+func (r *CatalogRepo) GetProduct(ctx context.Context, id string) (Product, error) {
+	p, err := scanProduct(r.DB.QueryRow(ctx, `SELECT `+productCols+` FROM products WHERE id=$1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Product{}, ErrProductNotFound
+	}
+	return p, err
+}
+
+// UpdateProduct: gagal dengan ErrVersionConflict kalau version sudah berubah sejak dibaca client.
+func (r *CatalogRepo) UpdateProduct(ctx context.Context, id string, expectedVersion int, patch ProductPatch) (Product, error) {
+	p, err := scanProduct(r.DB.QueryRow(ctx, `
+		UPDATE products
+		SET name = COALESCE($3, name),
+		    price_cents = COALESCE($4, price_cents),
+		    hot = COALESCE($5, hot),
+		    tax_category = COALESCE($6, tax_category),
+		    version = version + 1
+		WHERE id=$1 AND version=$2 AND archived_at IS NULL
+		RETURNING `+productCols, id, expectedVersion, patch.Name, patch.PriceCents, patch.Hot, patch.TaxCategory))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Product{}, r.whyNotUpdated(ctx, id)
+	}
+	return p, err
+}
+
+func (r *CatalogRepo) ArchiveProduct(ctx context.Context, id string, expectedVersion int) (Product, error) {
+	p, err := scanProduct(r.DB.QueryRow(ctx, `
+		UPDATE products
+		SET archived_at = now(), version = version + 1
+		WHERE id=$1 AND version=$2 AND archived_at IS NULL
+		RETURNING `+productCols, id, expectedVersion))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Product{}, r.whyNotUpdated(ctx, id)
+	}
+	return p, err
+}
+
+// whyNotUpdated: bedakan not found / archived / version conflict setelah UPDATE 0 rows.
+func (r *CatalogRepo) whyNotUpdated(ctx context.Context, id string) error {
+	p, err := r.GetProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+	if p.ArchivedAt != nil {
+		return fmt.Errorf("%w: %s", ErrProductArchived, p.SKU)
+	}
+	return fmt.Errorf("%w: current version is %d", ErrVersionConflict, p.Version)
+}
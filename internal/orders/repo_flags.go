@@ -0,0 +1,91 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var ErrFlagNotFound = errors.New("feature flag not found")
+
+const (
+	FlagBoolean    = "boolean"
+	FlagPercentage = "percentage"
+
+	FlagTargetUserID     = "user_id"
+	FlagTargetExternalID = "external_id"
+)
+
+// Flag: definisi feature flag (tabel feature_flags). Percent & TargetBy hanya dipakai kind percentage.
+type Flag struct {
+	Key         string    `json:"key"`
+	Description string    `json:"description"`
+	Kind        string    `json:"kind"` // boolean | percentage
+	Enabled     bool      `json:"enabled"`
+	Percent     int       `json:"percent"`
+	TargetBy    string    `json:"target_by"` // user_id | external_id
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type FlagRepo struct {
+	DB *pgxpool.Pool
+}
+
+const flagCols = `key, description, kind, enabled, percent, target_by, updated_at`
+
+func scanFlag(row pgx.Row) (Flag, error) {
+	var f Flag
+	err := row.Scan(&f.Key, &f.Description, &f.Kind, &f.Enabled, &f.Percent, &f.TargetBy, &f.UpdatedAt)
+	return f, err
+}
+
+func (r *FlagRepo) List(ctx context.Context) ([]Flag, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+flagCols+` FROM feature_flags ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Flag
+	for rows.Next() {
+		f, err := scanFlag(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func (r *FlagRepo) Get(ctx context.Context, key string) (Flag, error) {
+	f, err := scanFlag(r.DB.QueryRow(ctx, `SELECT `+flagCols+` FROM feature_flags WHERE key = $1`, key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Flag{}, ErrFlagNotFound
+	}
+	return f, err
+}
+
+// Upsert: buat atau ganti definisi flag (semua field kecuali created_at).
+func (r *FlagRepo) Upsert(ctx context.Context, in Flag) (Flag, error) {
+	return scanFlag(r.DB.QueryRow(ctx, `
+		INSERT INTO feature_flags(key, description, kind, enabled, percent, target_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET
+			description = EXCLUDED.description, kind = EXCLUDED.kind, enabled = EXCLUDED.enabled,
+			percent = EXCLUDED.percent, target_by = EXCLUDED.target_by, updated_at = now()
+		RETURNING `+flagCols,
+		in.Key, in.Description, in.Kind, in.Enabled, in.Percent, in.TargetBy))
+}
+
+func (r *FlagRepo) Delete(ctx context.Context, key string) error {
+	tag, err := r.DB.Exec(ctx, `DELETE FROM feature_flags WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrFlagNotFound
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Jenis perubahan stok di inventory_movements.
+const (
+	MoveInitial    = "INITIAL"
+	MoveReserve    = "RESERVE"
+	MoveRelease    = "RELEASE"
+	MoveRestock    = "RESTOCK"
+	MoveAdjustment = "ADJUSTMENT"
+	MoveShrinkage  = "SHRINKAGE"
+)
+
+var (
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrWarehouseNotFound = errors.New("warehouse not found")
+)
+
+type Movement struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"product_id"`
+	WarehouseID *string   `json:"warehouse_id,omitempty"`
+	Delta       int       `json:"delta"`
+	Kind        string    `json:"kind"`
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	OrderID     *string   `json:"order_id,omitempty"`
+	StockAfter  int       `json:"stock_after"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Drift: products.stock tidak sama dengan SUM(delta) di ledger.
+type Drift struct {
+	ProductID   string `json:"product_id"`
+	SKU         string `json:"sku"`
+	Stock       int    `json:"stock"`
+	LedgerStock int    `json:"ledger_stock"`
+	Diff        int    `json:"diff"` // stock - ledger_stock
+}
+
+// recordMovement: dipanggil di dalam tx yang sama dengan UPDATE products supaya ledger selalu konsisten.
+func recordMovement(ctx context.Context, tx pgx.Tx, m Movement) (Movement, error) {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO inventory_movements(product_id, warehouse_id, delta, kind, reason, actor, order_id, stock_after)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+		RETURNING id, created_at`,
+		m.ProductID, m.WarehouseID, m.Delta, m.Kind, m.Reason, m.Actor, m.OrderID, m.StockAfter,
+	).Scan(&m.ID, &m.CreatedAt)
+	return m, err
+}
+
+// InventoryRepo: perubahan stok manual (restock / adjustment / shrinkage) + reconcile ledger.
+type InventoryRepo struct{ DB *pgxpool.Pool }
+
+// Restock menambah stok (qty > 0) ke gudang warehouseID ("" = gudang default).
+func (r *InventoryRepo) Restock(ctx context.Context, productID, warehouseID string, qty int, reason, actor string) (Movement, error) {
+	if qty <= 0 {
+		return Movement{}, fmt.Errorf("restock qty must be > 0")
+	}
+	return r.apply(ctx, warehouseID, Movement{ProductID: productID, Delta: qty, Kind: MoveRestock, Reason: reason, Actor: actor})
+}
+
+// Adjust: koreksi manual (ADJUSTMENT, delta boleh +/-) atau SHRINKAGE (barang rusak/hilang, delta < 0).
+func (r *InventoryRepo) Adjust(ctx context.Context, productID, warehouseID string, delta int, kind, reason, actor string) (Movement, error) {
+	switch {
+	case kind != MoveAdjustment && kind != MoveShrinkage:
+		return Movement{}, fmt.Errorf("invalid adjustment kind: %s", kind)
+	case delta == 0:
+		return Movement{}, fmt.Errorf("delta must not be 0")
+	case kind == MoveShrinkage && delta > 0:
+		return Movement{}, fmt.Errorf("shrinkage delta must be negative")
+	}
+	return r.apply(ctx, warehouseID, Movement{ProductID: productID, Delta: delta, Kind: kind, Reason: reason, Actor: actor})
+}
+
+// DefaultWarehouse: gudang aktif dengan priority terkecil.
+func DefaultWarehouse(ctx context.Context, q pgx.Tx) (string, error) {
+	var id string
+	err := q.QueryRow(ctx, `SELECT id FROM warehouses WHERE active ORDER BY priority, code LIMIT 1`).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrWarehouseNotFound
+	}
+	return id, err
+}
+
+func (r *InventoryRepo) apply(ctx context.Context, warehouseID string, m Movement) (Movement, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Movement{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if warehouseID == "" {
+		if warehouseID, err = DefaultWarehouse(ctx, tx); err != nil {
+			return Movement{}, err
+		}
+	}
+
+	// urutan lock sama dengan ReserveAll: warehouse_stock dulu, baru products
+	var whStock int
+	err = tx.QueryRow(ctx, `
+		SELECT ws.stock FROM warehouse_stock ws
+		WHERE ws.warehouse_id=$1 AND ws.product_id=$2 FOR UPDATE`, warehouseID, m.ProductID).Scan(&whStock)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Movement{}, err
+	}
+	var stock int
+	if err := tx.QueryRow(ctx, `SELECT stock FROM products WHERE id=$1 FOR UPDATE`, m.ProductID).Scan(&stock); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Movement{}, ErrProductNotFound
+		}
+		return Movement{}, err
+	}
+	if whStock+m.Delta < 0 {
+		return Movement{}, fmt.Errorf("%w: warehouse stock=%d delta=%d", ErrInsufficientStock, whStock, m.Delta)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO warehouse_stock(warehouse_id, product_id, stock) VALUES ($1,$2,$3)
+		ON CONFLICT (warehouse_id, product_id) DO UPDATE
+		SET stock = warehouse_stock.stock + $3, updated_at = now()`, warehouseID, m.ProductID, m.Delta); err != nil {
+		if isFKViolation(err) {
+			return Movement{}, ErrWarehouseNotFound
+		}
+		return Movement{}, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE products SET stock = stock + $2 WHERE id=$1`, m.ProductID, m.Delta); err != nil {
+		return Movement{}, err
+	}
+	m.StockAfter = stock + m.Delta
+	m.WarehouseID = &warehouseID
+	if m, err = recordMovement(ctx, tx, m); err != nil {
+		return Movement{}, err
+	}
+	return m, tx.Commit(ctx)
+}
+
+// Movements: riwayat ledger satu produk, terbaru dulu.
+func (r *InventoryRepo) Movements(ctx context.Context, productID string, limit int) ([]Movement, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, product_id, warehouse_id, delta, kind, reason, actor, order_id, stock_after, created_at
+		FROM inventory_movements WHERE product_id=$1
+		ORDER BY created_at DESC LIMIT $2`, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Movement
+	for rows.Next() {
+		var m Movement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.WarehouseID, &m.Delta, &m.Kind, &m.Reason, &m.Actor, &m.OrderID, &m.StockAfter, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// Reconcile: bandingkan products.stock dengan SUM(delta) ledger; hanya produk yang drift dikembalikan.
+func (r *InventoryRepo) Reconcile(ctx context.Context) ([]Drift, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.id, p.sku, p.stock, COALESCE(SUM(m.delta), 0)::int AS ledger
+		FROM products p
+		LEFT JOIN inventory_movements m ON m.product_id = p.id
+		GROUP BY p.id, p.sku, p.stock
+		HAVING p.stock <> COALESCE(SUM(m.delta), 0)
+		ORDER BY p.sku`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Drift
+	for rows.Next() {
+		var d Drift
+		if err := rows.Scan(&d.ProductID, &d.SKU, &d.Stock, &d.LedgerStock); err != nil {
+			return nil, err
+		}
+		d.Diff = d.Stock - d.LedgerStock
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func isFKViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503" // foreign_key_violation
+}
+
+// HotProducts: stok products.stock untuk semua produk hot yang aktif (product_id -> stock).
+func (r *InventoryRepo) HotProducts(ctx context.Context) (map[string]int, error) {
+	rows, err := r.DB.Query(ctx, `SELECT id, stock FROM products WHERE hot AND archived_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]int{}
+	for rows.Next() {
+		var id string
+		var stock int
+		if err := rows.Scan(&id, &stock); err != nil {
+			return nil, err
+		}
+		out[id] = stock
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,270 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Operasi order untuk operator (cmd/ordersctl): lookup lengkap, paksa status, order macet, dan
+// rakit ulang payload event dari state di DB.
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// OrderDetail: order + item + reservasi (semua status) + riwayat status manual.
+type OrderDetail struct {
+	Order
+	Items         []OrderItem    `json:"items"`
+	Reservations  []Reservation  `json:"reservations"`
+	StatusChanges []StatusChange `json:"status_changes"`
+}
+
+// StatusChange: satu baris order_status_changes.
+type StatusChange struct {
+	From      Status    `json:"from"`
+	To        Status    `json:"to"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const orderCols = `id, external_id, user_id, status, total_cents, discount_cents, tax_cents, currency,
+	COALESCE(coupon_code, ''), created_at, updated_at`
+
+func scanOrder(row pgx.Row) (Order, error) {
+	var o Order
+	var st string
+	err := row.Scan(&o.ID, &o.ExternalID, &o.UserID, &st, &o.TotalCents, &o.DiscountCents, &o.TaxCents, &o.Currency,
+		&o.CouponCode, &o.CreatedAt, &o.UpdatedAt)
+	o.Status = Status(st)
+	return o, err
+}
+
+// FindOrder: ref = order id (uuid) atau external_id.
+func (r *Repo) FindOrder(ctx context.Context, ref string) (OrderDetail, error) {
+	// external_id bebas formatnya, jadi uuid dicocokkan ke keduanya (id menang)
+	var id *string
+	if _, err := uuid.Parse(ref); err == nil {
+		id = &ref
+	}
+	o, err := scanOrder(r.DB.QueryRow(ctx, `SELECT `+orderCols+` FROM orders
+		WHERE id=$1::uuid OR external_id=$2 ORDER BY id=$1::uuid DESC LIMIT 1`, id, ref))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return OrderDetail{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return OrderDetail{}, err
+	}
+
+	d := OrderDetail{Order: o, Items: []OrderItem{}, Reservations: []Reservation{}, StatusChanges: []StatusChange{}}
+	rows, err := r.DB.Query(ctx, `
+		SELECT oi.id, oi.product_id, p.sku, oi.qty, oi.fulfilled_qty, oi.price_cents, oi.discount_cents, oi.final_cents, oi.tax_cents
+		FROM order_items oi JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = $1 ORDER BY p.sku`, o.ID)
+	if err != nil {
+		return OrderDetail{}, err
+	}
+	for rows.Next() {
+		it := OrderItem{OrderID: o.ID}
+		if err := rows.Scan(&it.ID, &it.ProductID, &it.SKU, &it.Qty, &it.FulfilledQty, &it.PriceCents, &it.DiscountCents,
+			&it.FinalCents, &it.TaxCents); err != nil {
+			rows.Close()
+			return OrderDetail{}, err
+		}
+		d.Items = append(d.Items, it)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return OrderDetail{}, err
+	}
+
+	rows, err = r.DB.Query(ctx, `
+		SELECT r.id, r.product_id, w.code, r.qty, r.status, r.created_at, r.expires_at
+		FROM reservations r JOIN warehouses w ON w.id = r.warehouse_id
+		WHERE r.order_id = $1 ORDER BY r.created_at, r.product_id, w.code`, o.ID)
+	if err != nil {
+		return OrderDetail{}, err
+	}
+	for rows.Next() {
+		res := Reservation{OrderID: o.ID}
+		if err := rows.Scan(&res.ID, &res.ProductID, &res.WarehouseCode, &res.Qty, &res.Status, &res.CreatedAt, &res.ExpiresAt); err != nil {
+			rows.Close()
+			return OrderDetail{}, err
+		}
+		d.Reservations = append(d.Reservations, res)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return OrderDetail{}, err
+	}
+
+	rows, err = r.DB.Query(ctx, `
+		SELECT from_status, to_status, reason, actor, created_at
+		FROM order_status_changes WHERE order_id = $1 ORDER BY created_at, id`, o.ID)
+	if err != nil {
+		return OrderDetail{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c StatusChange
+		var from, to string
+		if err := rows.Scan(&from, &to, &c.Reason, &c.Actor, &c.CreatedAt); err != nil {
+			return OrderDetail{}, err
+		}
+		c.From, c.To = Status(from), Status(to)
+		d.StatusChanges = append(d.StatusChanges, c)
+	}
+	return d, rows.Err()
+}
+
+// ForceStatus: pindahkan status order secara manual. Tetap harus lolos CanTransition; setiap
+// perubahan dicatat di order_status_changes beserta reason & actor. Return status sebelumnya.
+func (r *Repo) ForceStatus(ctx context.Context, orderID string, to Status, reason, actor string) (from Status, err error) {
+	if reason == "" {
+		return "", errors.New("reason is required")
+	}
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		from, err = forceStatusTx(ctx, tx, orderID, to, reason, actor)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return from, nil
+}
+
+// forceStatusTx: lock order + ForceStatus di tx caller. to kosong = hanya lock & baca status.
+func forceStatusTx(ctx context.Context, tx pgx.Tx, orderID string, to Status, reason, actor string) (Status, error) {
+	var st string
+	if err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id=$1 FOR UPDATE`, orderID).Scan(&st); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrOrderNotFound
+		}
+		return "", err
+	}
+	from := Status(st)
+	if to == "" {
+		return from, nil
+	}
+	if !CanTransition(from, to) {
+		return "", fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE orders SET status=$2 WHERE id=$1`, orderID, string(to)); err != nil {
+		return "", err
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO order_status_changes(order_id, from_status, to_status, reason, actor)
+		VALUES ($1, $2, $3, $4, $5)`, orderID, string(from), string(to), reason, actor)
+	return from, err
+}
+
+// StuckOrders: order yang statusnya tidak berubah lebih lama dari olderThan (paling lama dulu).
+// statuses kosong = semua status yang belum final.
+func (r *Repo) StuckOrders(ctx context.Context, statuses []Status, olderThan time.Duration, limit int) ([]Order, error) {
+	sts := make([]string, 0, len(validNext))
+	for _, s := range statuses {
+		sts = append(sts, string(s))
+	}
+	if len(sts) == 0 {
+		for s, next := range validNext {
+			if len(next) > 0 {
+				sts = append(sts, string(s))
+			}
+		}
+	}
+	rows, err := r.DB.Query(ctx, `SELECT `+orderCols+` FROM orders
+		WHERE status = ANY($1::text[]) AND updated_at < now() - $2::interval
+		ORDER BY updated_at LIMIT $3`, sts, olderThan, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// ErrPayloadUnknown: order dibuat sebelum region & fulfilment policy disimpan (migration 016),
+// OrderCreated-nya tidak bisa dirakit ulang dengan benar.
+var ErrPayloadUnknown = errors.New("order predates stored region / fulfilment policy")
+
+// CreatedPayload: OrderCreated dirakit ulang dari DB.
+func (r *Repo) CreatedPayload(ctx context.Context, orderID string) (OrderCreatedPayload, error) {
+	p := OrderCreatedPayload{OrderID: orderID}
+	var region, policy *string
+	err := r.DB.QueryRow(ctx, `
+		SELECT external_id, user_id, total_cents, discount_cents, COALESCE(coupon_code, ''), COALESCE(quote_id::text, ''), currency, tax_cents,
+		       region, fulfilment_policy
+		FROM orders WHERE id=$1`, orderID).
+		Scan(&p.ExternalID, &p.UserID, &p.TotalCents, &p.DiscountCents, &p.CouponCode, &p.QuoteID, &p.Currency, &p.TaxCents,
+			&region, &policy)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return OrderCreatedPayload{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return OrderCreatedPayload{}, err
+	}
+	if region == nil || policy == nil {
+		return OrderCreatedPayload{}, fmt.Errorf("%w: %s", ErrPayloadUnknown, orderID)
+	}
+	p.Region, p.FulfilmentPolicy = *region, FulfilmentPolicy(*policy)
+	if p.Items, err = r.orderItems(ctx, orderID); err != nil {
+		return OrderCreatedPayload{}, err
+	}
+	if p.Taxes, err = r.orderTaxes(ctx, orderID); err != nil {
+		return OrderCreatedPayload{}, err
+	}
+	return p, nil
+}
+
+// ReservedPayload: StockReserved dirakit ulang dari reservasi yang masih RESERVED. Sama seperti
+// inventory, status/backorder/total hanya diisi untuk order PARTIALLY_RESERVED (Cancelled tidak bisa
+// dibedakan dari DB, jadi kosong).
+func (r *ReservationRepo) ReservedPayload(ctx context.Context, orderID string) (StockReservedPayload, error) {
+	var st, currency string
+	var total int
+	err := r.DB.QueryRow(ctx, `SELECT status, total_cents, currency FROM orders WHERE id=$1`, orderID).
+		Scan(&st, &total, &currency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return StockReservedPayload{}, ErrOrderNotFound
+	}
+	if err != nil {
+		return StockReservedPayload{}, err
+	}
+	p := StockReservedPayload{OrderID: orderID}
+	if p.Allocations, err = r.Allocations(ctx, orderID); err != nil {
+		return StockReservedPayload{}, err
+	}
+	p.Items = allocatedItems(p.Allocations)
+	if Status(st) != StatusPartiallyReserved {
+		return p, nil
+	}
+	p.Status, p.TotalCents, p.Currency = StatusPartiallyReserved, total, currency
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT product_id, SUM(qty)::int FROM backorders
+		WHERE order_id=$1 AND status='PENDING' GROUP BY product_id ORDER BY product_id`, orderID)
+	if err != nil {
+		return StockReservedPayload{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var it ItemQty
+		if err := rows.Scan(&it.ProductID, &it.Qty); err != nil {
+			return StockReservedPayload{}, err
+		}
+		p.Backordered = append(p.Backordered, it)
+	}
+	return p, rows.Err()
+}
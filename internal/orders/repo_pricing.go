@@ -0,0 +1,352 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/money"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/pricing"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrCouponInvalid     = errors.New("coupon invalid")        // tidak ada, di luar periode, atau bukan untuk user ini
+	ErrCouponUsedUp      = errors.New("coupon usage exceeded") // max_uses / max_uses_per_user habis
+	ErrCouponExists      = errors.New("coupon already exists")
+	ErrPromotionNotFound = errors.New("promotion not found")
+)
+
+// PricingOptions: opsi harga saat order dibuat.
+type PricingOptions struct {
+	CouponCode string // opsional
+	Region     string // region user, dipakai rule pajak
+	Currency   string // opsional: kalau diisi harus sama dengan currency produk
+	QuoteID    string // opsional: pakai harga dari quote (lihat applyQuote)
+	// Policy tidak dipakai pricing, hanya disimpan di orders (bersama Region) untuk rakit ulang OrderCreated
+	Policy FulfilmentPolicy
+}
+
+// orderLine: line order + atribut produk yang dipakai pricing / pajak.
+type orderLine struct {
+	ItemPrice
+	SKU           string
+	Currency      string
+	TaxCategory   string
+	ExpectedCents *int // expected_price_cents dari client (nil = tidak dicek)
+	Version       int  // products.version saat dibaca (lihat checkProductVersions)
+}
+
+// insertOrder: harga quote (kalau ada) -> cek expected price -> promosi otomatis + kupon -> pajak,
+// lalu insert orders, order_items, order_taxes dan coupon_redemptions di tx yang sama. lines berisi
+// list price dari tabel products (version dicek ulang oleh createOrder); semua line harus satu currency.
+func (r *Repo) insertOrder(ctx context.Context, tx pgx.Tx, externalID, userID string, lines []orderLine, opts PricingOptions) (CreatedOrder, error) {
+	if opts.QuoteID != "" {
+		if err := applyQuote(ctx, tx, opts.QuoteID, userID, lines); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+	if err := checkExpectedPrices(lines); err != nil {
+		return CreatedOrder{}, err
+	}
+
+	currency := lines[0].Currency
+	if opts.Currency != "" {
+		currency = opts.Currency
+	}
+	list := make([]money.Money, 0, len(lines))
+	for _, l := range lines {
+		list = append(list, money.New(l.PriceCents, l.Currency).Mul(l.Qty))
+	}
+	if _, err := money.Sum(currency, list...); err != nil {
+		return CreatedOrder{}, err
+	}
+
+	rules, err := pricingRules(ctx, tx, userID, currency, lines, opts.CouponCode)
+	if err != nil {
+		return CreatedOrder{}, err
+	}
+	in := make([]pricing.Line, 0, len(lines))
+	for _, l := range lines {
+		in = append(in, pricing.Line{ProductID: l.ProductID, Qty: l.Qty, UnitCents: l.PriceCents})
+	}
+	q := pricing.Price(in, rules)
+	items := make([]ItemPrice, len(lines))
+	taxReq := tax.Request{Region: opts.Region, Currency: currency, Lines: make([]tax.Line, len(lines))}
+	for i, l := range q.Lines {
+		items[i] = lines[i].ItemPrice
+		items[i].DiscountCents, items[i].FinalCents = l.DiscountCents, l.FinalCents
+		taxReq.Lines[i] = tax.Line{ProductID: l.ProductID, Category: lines[i].TaxCategory, AmountCents: l.FinalCents}
+	}
+
+	var taxes []tax.TaxLine
+	if r.Tax != nil {
+		if taxes, err = r.Tax.Calculate(ctx, taxReq); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+	taxTotal := money.Zero(currency)
+	for _, t := range taxes {
+		for i := range items {
+			if items[i].ProductID == t.ProductID {
+				items[i].TaxCents += t.TaxCents
+			}
+		}
+		taxTotal.Cents += t.TaxCents
+	}
+	total, err := money.New(q.TotalCents, currency).Add(taxTotal)
+	if err != nil {
+		return CreatedOrder{}, err
+	}
+
+	orderID := uuid.NewString()
+	var coupon, quote *string
+	if opts.CouponCode != "" {
+		coupon = &opts.CouponCode
+	}
+	if opts.QuoteID != "" {
+		quote = &opts.QuoteID
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO orders(id, external_id, user_id, status, currency, total_cents, discount_cents, tax_cents, coupon_code, quote_id,
+			region, fulfilment_policy)
+		VALUES ($1, $2, $3, 'CREATED', $4, $5, $6, $7, $8, $9, $10, $11)`,
+		orderID, externalID, userID, currency, total.Cents, q.DiscountCents, taxTotal.Cents, coupon, quote,
+		opts.Region, string(opts.Policy)); err != nil {
+		return CreatedOrder{}, err
+	}
+	if opts.QuoteID != "" {
+		if _, err := tx.Exec(ctx, `UPDATE quotes SET order_id=$2 WHERE id=$1`, opts.QuoteID, orderID); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+	for _, it := range items {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO order_items(order_id, product_id, qty, price_cents, discount_cents, final_cents, tax_cents)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			orderID, it.ProductID, it.Qty, it.PriceCents, it.DiscountCents, it.FinalCents, it.TaxCents); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+	for _, t := range taxes {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO order_taxes(order_id, product_id, name, rate_bp, taxable_cents, tax_cents)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			orderID, t.ProductID, t.Name, t.RateBP, t.TaxableCents, t.TaxCents); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+
+	if opts.CouponCode != "" {
+		var couponOff int
+		for _, a := range q.Applied {
+			if a.Coupon != "" {
+				couponOff += a.AmountCents
+			}
+		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO coupon_redemptions(code, user_id, order_id, discount_cents) VALUES ($1, $2, $3, $4)`,
+			opts.CouponCode, userID, orderID, couponOff); err != nil {
+			return CreatedOrder{}, err
+		}
+		if _, err := tx.Exec(ctx, `UPDATE coupons SET used_count = used_count + 1 WHERE code = $1`, opts.CouponCode); err != nil {
+			return CreatedOrder{}, err
+		}
+	}
+
+	return CreatedOrder{
+		OrderID: orderID, Currency: currency, TotalCents: total.Cents, DiscountCents: q.DiscountCents, TaxCents: taxTotal.Cents,
+		CouponCode: opts.CouponCode, QuoteID: opts.QuoteID, Items: items, Taxes: taxes, Discounts: q.Applied,
+	}, nil
+}
+
+const promotionCols = `p.id, p.name, p.kind, COALESCE(p.product_id::text, ''), p.percent, p.amount_cents, p.buy_qty, p.get_qty`
+
+func scanRule(row pgx.Row, r *pricing.Rule) error {
+	return row.Scan(&r.ID, &r.Name, &r.Kind, &r.ProductID, &r.Percent, &r.AmountCents, &r.BuyQty, &r.GetQty)
+}
+
+// pricingRules: promosi otomatis yang aktif sekarang + promosi dari kupon. Row kupon di-lock
+// (FOR UPDATE) supaya batas pemakaian tidak tembus oleh order paralel.
+func pricingRules(ctx context.Context, tx pgx.Tx, userID, currency string, lines []orderLine, coupon string) ([]pricing.Rule, error) {
+	pids := make([]string, 0, len(lines))
+	for _, l := range lines {
+		pids = append(pids, l.ProductID)
+	}
+	rows, err := tx.Query(ctx, `
+		SELECT `+promotionCols+`
+		FROM promotions p
+		WHERE p.active AND NOT p.coupon_only
+		  AND p.starts_at <= now() AND (p.ends_at IS NULL OR p.ends_at > now())
+		  AND (p.product_id IS NULL OR p.product_id = ANY($1::uuid[]))
+		  AND (p.currency IS NULL OR p.currency = $2)
+		ORDER BY p.created_at, p.id`, pids, currency)
+	if err != nil {
+		return nil, err
+	}
+	var rules []pricing.Rule
+	for rows.Next() {
+		var r pricing.Rule
+		if err := scanRule(rows, &r); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if coupon == "" {
+		return rules, nil
+	}
+
+	var (
+		r          pricing.Rule
+		owner      *string
+		maxUses    *int
+		maxPerUser int
+		used       int
+		inPeriod   bool
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT `+promotionCols+`, c.user_id::text, c.max_uses, c.max_uses_per_user, c.used_count,
+		       p.active AND p.starts_at <= now() AND (p.ends_at IS NULL OR p.ends_at > now())
+		         AND (p.currency IS NULL OR p.currency = $2)
+		FROM coupons c JOIN promotions p ON p.id = c.promotion_id
+		WHERE c.code = $1
+		FOR UPDATE OF c`, coupon, currency).Scan(
+		&r.ID, &r.Name, &r.Kind, &r.ProductID, &r.Percent, &r.AmountCents, &r.BuyQty, &r.GetQty,
+		&owner, &maxUses, &maxPerUser, &used, &inPeriod)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrCouponInvalid, coupon)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !inPeriod || (owner != nil && !strings.EqualFold(*owner, userID)) {
+		return nil, fmt.Errorf("%w: %s", ErrCouponInvalid, coupon)
+	}
+	if maxUses != nil && used >= *maxUses {
+		return nil, fmt.Errorf("%w: %s", ErrCouponUsedUp, coupon)
+	}
+	var mine int
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM coupon_redemptions WHERE code = $1 AND user_id = $2`,
+		coupon, userID).Scan(&mine); err != nil {
+		return nil, err
+	}
+	if mine >= maxPerUser {
+		return nil, fmt.Errorf("%w: %s", ErrCouponUsedUp, coupon)
+	}
+	r.Coupon = coupon
+	return append(rules, r), nil
+}
+
+// ---- Admin promosi & kupon ----
+
+type Promotion struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	ProductID   *string    `json:"product_id,omitempty"`
+	Percent     int        `json:"percent,omitempty"`
+	AmountCents int        `json:"amount_cents,omitempty"`
+	BuyQty      int        `json:"buy_qty,omitempty"`
+	GetQty      int        `json:"get_qty,omitempty"`
+	CouponOnly  bool       `json:"coupon_only"`
+	Active      bool       `json:"active"`
+	StartsAt    time.Time  `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type Coupon struct {
+	Code           string    `json:"code"`
+	PromotionID    string    `json:"promotion_id"`
+	UserID         *string   `json:"user_id,omitempty"`
+	MaxUses        *int      `json:"max_uses,omitempty"`
+	MaxUsesPerUser int       `json:"max_uses_per_user"`
+	UsedCount      int       `json:"used_count"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// PromotionRepo: CRUD promosi & kupon untuk admin marketing.
+type PromotionRepo struct{ DB *pgxpool.Pool }
+
+const promotionAdminCols = `id, name, kind, product_id::text, percent, amount_cents, buy_qty, get_qty, coupon_only, active, starts_at, ends_at, created_at`
+
+func scanPromotion(row pgx.Row) (Promotion, error) {
+	var p Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.Kind, &p.ProductID, &p.Percent, &p.AmountCents, &p.BuyQty, &p.GetQty,
+		&p.CouponOnly, &p.Active, &p.StartsAt, &p.EndsAt, &p.CreatedAt)
+	return p, err
+}
+
+// CreatePromotion: StartsAt kosong = mulai sekarang.
+func (r *PromotionRepo) CreatePromotion(ctx context.Context, in Promotion) (Promotion, error) {
+	var starts *time.Time
+	if !in.StartsAt.IsZero() {
+		starts = &in.StartsAt
+	}
+	p, err := scanPromotion(r.DB.QueryRow(ctx, `
+		INSERT INTO promotions(name, kind, product_id, percent, amount_cents, buy_qty, get_qty, coupon_only, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, now()), $10)
+		RETURNING `+promotionAdminCols,
+		in.Name, in.Kind, in.ProductID, in.Percent, in.AmountCents, in.BuyQty, in.GetQty, in.CouponOnly, starts, in.EndsAt))
+	if isFKViolation(err) {
+		return Promotion{}, ErrProductNotFound
+	}
+	return p, err
+}
+
+func (r *PromotionRepo) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+promotionAdminCols+` FROM promotions ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetActive: matikan / hidupkan promosi (promosi tidak dihapus karena direferensikan kupon).
+func (r *PromotionRepo) SetActive(ctx context.Context, id string, active bool) (Promotion, error) {
+	p, err := scanPromotion(r.DB.QueryRow(ctx, `
+		UPDATE promotions SET active = $2 WHERE id = $1 RETURNING `+promotionAdminCols, id, active))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Promotion{}, ErrPromotionNotFound
+	}
+	return p, err
+}
+
+// CreateCoupon: kode kupon disimpan apa adanya (case-sensitive).
+func (r *PromotionRepo) CreateCoupon(ctx context.Context, in Coupon) (Coupon, error) {
+	c := in
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO coupons(code, promotion_id, user_id, max_uses, max_uses_per_user)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING used_count, created_at`,
+		in.Code, in.PromotionID, in.UserID, in.MaxUses, in.MaxUsesPerUser).Scan(&c.UsedCount, &c.CreatedAt)
+	if isFKViolation(err) {
+		return Coupon{}, ErrPromotionNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation (code)
+		return Coupon{}, fmt.Errorf("%w: %s", ErrCouponExists, in.Code)
+	}
+	return c, err
+}
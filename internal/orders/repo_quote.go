@@ -0,0 +1,188 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/money"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	ErrPriceChanged  = errors.New("PRICE_CHANGED")
+	ErrQuoteNotFound = errors.New("quote not found") // tidak ada atau milik user lain
+	ErrQuoteExpired  = errors.New("quote expired")
+	ErrQuoteUsed     = errors.New("quote already used")
+	ErrQuoteMismatch = errors.New("order items do not match quote") // produk tidak di-quote / qty melebihi quote
+)
+
+// PriceChange: satu line yang harganya beda dengan expected_price_cents dari client.
+type PriceChange struct {
+	ProductID     string `json:"product_id"`
+	SKU           string `json:"sku"`
+	ExpectedCents int    `json:"expected_cents"`
+	ActualCents   int    `json:"actual_cents"`
+}
+
+// PriceChangedError: errors.Is(err, ErrPriceChanged) == true; Changes berisi semua line yang beda.
+type PriceChangedError struct {
+	Changes []PriceChange
+
+	concurrent bool // produk berubah di tengah pembuatan order (bukan expected_price_cents), boleh diulang
+	expected   bool // concurrent: ada line yang dikirim dengan expected_price_cents
+}
+
+func (e *PriceChangedError) Error() string {
+	parts := make([]string, 0, len(e.Changes))
+	for _, c := range e.Changes {
+		parts = append(parts, fmt.Sprintf("%s expected %d got %d", c.SKU, c.ExpectedCents, c.ActualCents))
+	}
+	return ErrPriceChanged.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *PriceChangedError) Unwrap() error { return ErrPriceChanged }
+
+type QuoteItem struct {
+	ProductID  string `json:"product_id"`
+	SKU        string `json:"sku"`
+	Qty        int    `json:"qty"`
+	PriceCents int    `json:"price_cents"` // list price per unit yang dikunci
+}
+
+type Quote struct {
+	ID        string      `json:"quote_id"`
+	UserID    string      `json:"user_id"`
+	Currency  string      `json:"currency"`
+	Items     []QuoteItem `json:"items"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// CreateQuote: simpan list price produk saat ini untuk user, berlaku selama ttl. Produk archived
+// ditolak; semua produk harus satu currency.
+func (r *Repo) CreateQuote(ctx context.Context, userID string, items []ItemInput, ttl time.Duration) (Quote, error) {
+	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return Quote{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	q := Quote{UserID: userID, Items: make([]QuoteItem, 0, len(items))}
+	for _, it := range items {
+		var (
+			qi       = QuoteItem{ProductID: it.ProductID, Qty: it.Qty}
+			archived bool
+			currency string
+		)
+		err := tx.QueryRow(ctx, `SELECT sku, price_cents, archived_at IS NOT NULL, currency FROM products WHERE id=$1`,
+			it.ProductID).Scan(&qi.SKU, &qi.PriceCents, &archived, &currency)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Quote{}, fmt.Errorf("%w: product_id=%s", ErrProductNotFound, it.ProductID)
+		}
+		if err != nil {
+			return Quote{}, err
+		}
+		if archived {
+			return Quote{}, fmt.Errorf("%w: product_id=%s", ErrProductArchived, it.ProductID)
+		}
+		if q.Currency == "" {
+			q.Currency = currency
+		} else if currency != q.Currency {
+			return Quote{}, fmt.Errorf("%w: %s and %s", money.ErrCurrencyMismatch, q.Currency, currency)
+		}
+		q.Items = append(q.Items, qi)
+	}
+
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO quotes(user_id, currency, expires_at) VALUES ($1, $2, now() + make_interval(secs => $3))
+		RETURNING id, expires_at`, userID, q.Currency, ttl.Seconds()).Scan(&q.ID, &q.ExpiresAt); err != nil {
+		return Quote{}, err
+	}
+	for _, it := range q.Items {
+		if _, err := tx.Exec(ctx, `INSERT INTO quote_items(quote_id, product_id, qty, price_cents) VALUES ($1, $2, $3, $4)`,
+			q.ID, it.ProductID, it.Qty, it.PriceCents); err != nil {
+			return Quote{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return Quote{}, err
+	}
+	return q, nil
+}
+
+// applyQuote: ganti list price line dengan harga quote. Row quote di-lock (FOR UPDATE) supaya satu
+// quote tidak dipakai dua order paralel; quote ditandai terpakai di insertOrder.
+func applyQuote(ctx context.Context, tx pgx.Tx, quoteID, userID string, lines []orderLine) error {
+	var (
+		owner         string
+		expired, used bool
+	)
+	err := tx.QueryRow(ctx, `SELECT user_id::text, expires_at <= now(), order_id IS NOT NULL FROM quotes WHERE id=$1 FOR UPDATE`,
+		quoteID).Scan(&owner, &expired, &used)
+	// user_id::text selalu lowercase, userID dari client belum tentu
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !strings.EqualFold(owner, userID)) {
+		return fmt.Errorf("%w: %s", ErrQuoteNotFound, quoteID)
+	}
+	if err != nil {
+		return err
+	}
+	if used {
+		return fmt.Errorf("%w: %s", ErrQuoteUsed, quoteID)
+	}
+	if expired {
+		return fmt.Errorf("%w: %s", ErrQuoteExpired, quoteID)
+	}
+
+	type quoted struct{ qty, price int }
+	rows, err := tx.Query(ctx, `SELECT product_id::text, qty, price_cents FROM quote_items WHERE quote_id=$1`, quoteID)
+	if err != nil {
+		return err
+	}
+	byID := map[string]quoted{}
+	for rows.Next() {
+		var (
+			id string
+			q  quoted
+		)
+		if err := rows.Scan(&id, &q.qty, &q.price); err != nil {
+			rows.Close()
+			return err
+		}
+		byID[id] = q
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range lines {
+		q, ok := byID[lines[i].ProductID]
+		if !ok {
+			return fmt.Errorf("%w: product_id=%s not quoted", ErrQuoteMismatch, lines[i].ProductID)
+		}
+		if lines[i].Qty > q.qty {
+			return fmt.Errorf("%w: product_id=%s qty %d > quoted %d", ErrQuoteMismatch, lines[i].ProductID, lines[i].Qty, q.qty)
+		}
+		lines[i].PriceCents = q.price
+	}
+	return nil
+}
+
+// checkExpectedPrices: bandingkan expected_price_cents client dengan list price yang dipakai order
+// (harga products atau harga quote).
+func checkExpectedPrices(lines []orderLine) error {
+	var changes []PriceChange
+	for _, l := range lines {
+		if l.ExpectedCents != nil && *l.ExpectedCents != l.PriceCents {
+			changes = append(changes, PriceChange{
+				ProductID: l.ProductID, SKU: l.SKU, ExpectedCents: *l.ExpectedCents, ActualCents: l.PriceCents,
+			})
+		}
+	}
+	if len(changes) > 0 {
+		return &PriceChangedError{Changes: changes}
+	}
+	return nil
+}
@@ -2,17 +2,52 @@ package orders
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-type ReservationRepo struct{ DB *pgxpool.Pool }
+// Alasan release (StockReleasedPayload.Reason).
+const (
+	ReleaseHoldExpired = "HOLD_EXPIRED"
+	ReleaseManual      = "MANUAL_RELEASE" // ordersctl order release
+)
+
+var ErrHoldNotFound = errors.New("no active hold for order")
+
+// ReservationRepo: reservasi stok per gudang. Strategy menentukan gudang sumber
+// (nil = SingleWarehouseStrategy). HoldTTL = umur reservasi sebelum di-release sweeper
+// (0 = tidak pernah expire).
+type ReservationRepo struct {
+	DB       *pgxpool.Pool
+	Strategy AllocationStrategy
+	HoldTTL  time.Duration
+}
+
+func (r *ReservationRepo) strategy() AllocationStrategy {
+	return r.strategyFor(nil)
+}
+
+// strategyFor: override per request (mis. rollout feature flag), fallback ke strategy repo.
+func (r *ReservationRepo) strategyFor(override AllocationStrategy) AllocationStrategy {
+	if override != nil {
+		return override
+	}
+	if r.Strategy == nil {
+		return SingleWarehouseStrategy{}
+	}
+	return r.Strategy
+}
 
 // Cek apakah seluruh item utk order sudah RESERVED (idempotency short-circuit).
+// Satu item bisa di-split ke beberapa gudang, jadi yang dihitung product_id distinct.
 func (r *ReservationRepo) SudahReserved(ctx context.Context, orderID string, itemCount int) (bool, error) {
 	var n int
 	err := r.DB.QueryRow(ctx, `
-		SELECT COUNT(*) FROM reservations
+		SELECT COUNT(DISTINCT product_id) FROM reservations
 		WHERE order_id = $1 AND status = 'RESERVED'`, orderID).Scan(&n)
 	if err != nil {
 		return false, err
@@ -20,94 +55,533 @@ func (r *ReservationRepo) SudahReserved(ctx context.Context, orderID string, ite
 	return n == itemCount, nil
 }
 
-// ReserveAll: lock stok per product (FOR UPDATE) -> kurangi -> catat reservation (idempotent).
-// Jika ada kekurangan pada salah satu item, tidak ada perubahan yg di-commit (rollback).
-func (r *ReservationRepo) ReserveAll(ctx context.Context, orderID string, items []ItemQty) (ok bool, details []StockRejectedDetail, err error) {
-	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+// Allocations: alokasi gudang dari reservasi yang masih RESERVED (untuk publish ulang StockReserved).
+func (r *ReservationRepo) Allocations(ctx context.Context, orderID string) ([]Allocation, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT r.product_id, r.warehouse_id, w.code, r.qty
+		FROM reservations r JOIN warehouses w ON w.id = r.warehouse_id
+		WHERE r.order_id = $1 AND r.status = 'RESERVED'
+		ORDER BY r.product_id, w.code`, orderID)
 	if err != nil {
-		return false, nil, err
+		return nil, err
 	}
-	defer tx.Rollback(ctx)
+	defer rows.Close()
+
+	var out []Allocation
+	for rows.Next() {
+		var a Allocation
+		if err := rows.Scan(&a.ProductID, &a.WarehouseID, &a.WarehouseCode, &a.Qty); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// errRejected: sinyal internal supaya BeginTxFunc rollback saat stok kurang.
+var errRejected = errors.New("reservation rejected")
 
-	var rejects []StockRejectedDetail
+type ReserveRequest struct {
+	OrderID string
+	Region  string
+	Policy  FulfilmentPolicy // kosong = ALL_OR_NOTHING
+	Items   []ItemQty
+	// Strategy: override allocation strategy untuk request ini (nil = ReservationRepo.Strategy)
+	Strategy AllocationStrategy
+	TraceID  string // trace event asal, dipakai event hasil yang dipublish belakangan (HotStock async)
+}
+
+type ReserveResult struct {
+	Status      Status // STOCK_RESERVED | PARTIALLY_RESERVED; kosong = ditolak (lihat Shortages)
+	Reserved    []ItemQty
+	Allocations []Allocation
+	Shortages   []StockRejectedDetail
+	Backordered []ItemQty // policy BACKORDER: antre sampai restock
+	Cancelled   []ItemQty // policy PARTIAL: tidak dikirim
+	TotalCents  int       // total order setelah dihitung ulang
+	Currency    string
+	Replayed    bool // order sudah pernah diproses; hasil dibaca ulang dari DB
+	// Pending: counter Redis sudah diambil (inventory.HotStock), write ke Postgres masih async.
+	// Belum final: Allocations belum ada dan hasil sebenarnya datang lewat HotStock.OnWritten
+	Pending bool
+}
 
+// ReserveAll: Reserve dengan policy ALL_OR_NOTHING.
+func (r *ReservationRepo) ReserveAll(ctx context.Context, orderID, region string, items []ItemQty) (ok bool, allocs []Allocation, details []StockRejectedDetail, err error) {
+	res, err := r.Reserve(ctx, ReserveRequest{OrderID: orderID, Region: region, Items: items})
+	if err != nil {
+		return false, nil, nil, err
+	}
+	return res.Status != "", res.Allocations, res.Shortages, nil
+}
+
+// Reserve: lock order -> lock stok gudang (FOR UPDATE, urut product_id, warehouse_id) -> strategy pilih
+// gudang -> kurangi warehouse_stock & products.stock, insert reservation + ledger secara batch (set-based).
+// Jumlah round trip tetap (tidak tergantung jumlah item). Deadlock/serialization failure di-retry.
+//
+// Kalau stok kurang: ALL_OR_NOTHING rollback semua; PARTIAL reserve yang ada dan hitung ulang total;
+// BACKORDER reserve yang ada dan sisanya masuk tabel backorders. Order yang statusnya sudah bukan
+// CREATED tidak diproses ulang (Replayed).
+func (r *ReservationRepo) Reserve(ctx context.Context, req ReserveRequest) (res ReserveResult, err error) {
+	items := mergeItemQty(req.Items)
+	policy := req.Policy.OrDefault()
+	productIDs := make([]string, 0, len(items))
 	for _, it := range items {
-		var stock int
-		if err := tx.QueryRow(ctx, `SELECT stock FROM products WHERE id=$1 FOR UPDATE`, it.ProductID).Scan(&stock); err != nil {
-			return false, nil, err
-		}
-		if stock < it.Qty {
-			rejects = append(rejects, StockRejectedDetail{
-				ProductID: it.ProductID, Required: it.Qty, Available: stock,
-			})
-			continue
+		productIDs = append(productIDs, it.ProductID)
+	}
+
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		res = ReserveResult{}
+
+		var st string
+		if err := tx.QueryRow(ctx, `SELECT status, total_cents, currency FROM orders WHERE id=$1 FOR UPDATE`, req.OrderID).Scan(&st, &res.TotalCents, &res.Currency); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if Status(st) != StatusCreated {
+			return replay(ctx, tx, req.OrderID, Status(st), &res)
 		}
 
-		ct, err := tx.Exec(ctx, `UPDATE products SET stock = stock - $2 WHERE id=$1`, it.ProductID, it.Qty)
+		stock, err := lockWarehouseStock(ctx, tx, productIDs)
 		if err != nil {
-			return false, nil, err
+			return err
 		}
-		if ct.RowsAffected() != 1 {
-			rejects = append(rejects, StockRejectedDetail{
-				ProductID: it.ProductID, Required: it.Qty, Available: stock,
-			})
-			continue
+		fit := items
+		strategy := r.strategyFor(req.Strategy)
+		allocs, shortages := strategy.Allocate(AllocationRequest{Region: req.Region, Items: items, Stock: stock})
+		if len(shortages) > 0 {
+			res.Shortages = shortages
+			if policy == FulfilAllOrNothing {
+				return errRejected
+			}
+			var short []ItemQty
+			fit, short = fitToAvailable(items, shortages)
+			if len(fit) == 0 && policy == FulfilPartial {
+				return errRejected
+			}
+			allocs = nil
+			if len(fit) > 0 {
+				var again []StockRejectedDetail
+				if allocs, again = strategy.Allocate(AllocationRequest{Region: req.Region, Items: fit, Stock: stock}); len(again) > 0 {
+					res.Shortages = again
+					return errRejected
+				}
+			}
+			if policy == FulfilBackorder {
+				res.Backordered = short
+			} else {
+				res.Cancelled = short
+			}
+		}
+
+		if err := moveStock(ctx, tx, allocs, -1, MoveReserve, "", req.OrderID); err != nil {
+			return err
+		}
+		if err := insertReservations(ctx, tx, req.OrderID, allocs, r.holdInterval()); err != nil {
+			return err
+		}
+
+		res.Status = StatusStockReserved
+		if len(res.Backordered) > 0 || len(res.Cancelled) > 0 {
+			res.Status = StatusPartiallyReserved
+		}
+		res.Reserved, res.Allocations = fit, allocs
+		if len(res.Backordered) > 0 {
+			bpids, bqtys := itemColumns(res.Backordered)
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO backorders(order_id, product_id, qty, region)
+				SELECT $1, b.pid, b.qty, $4 FROM unnest($2::uuid[], $3::int[]) AS b(pid, qty)`,
+				req.OrderID, bpids, bqtys, req.Region); err != nil {
+				return err
+			}
 		}
 
+		// fulfilled_qty per line, lalu status + total (PARTIAL: total = yang benar-benar dikirim,
+		// harga line setelah diskon + pajak dibagi rata per unit)
+		fpids, fqtys := itemColumns(fit)
 		if _, err := tx.Exec(ctx, `
-			INSERT INTO reservations(order_id, product_id, qty, status)
-			VALUES ($1,$2,$3,'RESERVED')
-			ON CONFLICT (order_id, product_id) DO NOTHING
-		`, orderID, it.ProductID, it.Qty); err != nil {
-			return false, nil, err
+			UPDATE order_items oi SET fulfilled_qty = COALESCE(
+				(SELECT f.qty FROM unnest($2::uuid[], $3::int[]) AS f(pid, qty) WHERE f.pid = oi.product_id), 0)
+			WHERE oi.order_id = $1`, req.OrderID, fpids, fqtys); err != nil {
+			return err
 		}
+		return tx.QueryRow(ctx, `
+			UPDATE orders SET status = $2,
+				total_cents = CASE WHEN $3 THEN
+					(SELECT COALESCE(SUM((final_cents + tax_cents) * fulfilled_qty / qty), 0)::int FROM order_items WHERE order_id = $1)
+				ELSE total_cents END
+			WHERE id = $1
+			RETURNING total_cents`, req.OrderID, string(res.Status), len(res.Cancelled) > 0).Scan(&res.TotalCents)
+	})
+	if errors.Is(err, errRejected) {
+		return ReserveResult{Shortages: res.Shortages}, nil
+	}
+	if err != nil {
+		return ReserveResult{}, err
 	}
+	return res, nil
+}
 
-	if len(rejects) > 0 {
-		return false, rejects, nil // rollback via defer
+// replay: hasil reservasi order yang sudah diproses sebelumnya (event OrderCreated dikirim ulang).
+func replay(ctx context.Context, tx pgx.Tx, orderID string, st Status, res *ReserveResult) error {
+	res.Replayed, res.Status = true, st
+	rows, err := tx.Query(ctx, `
+		SELECT r.product_id, r.warehouse_id, w.code, r.qty
+		FROM reservations r JOIN warehouses w ON w.id = r.warehouse_id
+		WHERE r.order_id = $1 AND r.status = 'RESERVED'
+		ORDER BY r.product_id, w.code`, orderID)
+	if err != nil {
+		return err
 	}
-	if err := tx.Commit(ctx); err != nil {
-		return false, nil, err
+	defer rows.Close()
+	for rows.Next() {
+		var a Allocation
+		if err := rows.Scan(&a.ProductID, &a.WarehouseID, &a.WarehouseCode, &a.Qty); err != nil {
+			return err
+		}
+		res.Allocations = append(res.Allocations, a)
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
-	return true, nil, nil
+	res.Reserved = allocatedItems(res.Allocations)
+	return nil
 }
 
-func (r *ReservationRepo) ReleaseAll(ctx context.Context, orderID string) error {
-	tx, err := r.DB.BeginTx(ctx, pgx.TxOptions{})
+// lockWarehouseStock: lock urut (product_id, warehouse_id) supaya dua tx tidak saling menunggu.
+func lockWarehouseStock(ctx context.Context, tx pgx.Tx, productIDs []string) (map[string][]WarehouseStock, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT ws.warehouse_id, w.code, w.region, w.priority, ws.product_id, ws.stock
+		FROM warehouse_stock ws JOIN warehouses w ON w.id = ws.warehouse_id
+		WHERE ws.product_id = ANY($1::uuid[]) AND w.active
+		ORDER BY ws.product_id, ws.warehouse_id
+		FOR UPDATE OF ws`, productIDs)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+	stock := map[string][]WarehouseStock{}
+	for rows.Next() {
+		var w WarehouseStock
+		if err := rows.Scan(&w.WarehouseID, &w.Code, &w.Region, &w.Priority, &w.ProductID, &w.Available); err != nil {
+			return nil, err
+		}
+		stock[w.ProductID] = append(stock[w.ProductID], w)
 	}
-	defer tx.Rollback(ctx)
+	return stock, rows.Err()
+}
 
-	rows, err := tx.Query(ctx, `SELECT product_id, qty FROM reservations WHERE order_id=$1 AND status='RESERVED'`, orderID)
+// insertReservations: qty ditambahkan kalau (order, product, warehouse) masih RESERVED (backorder yang
+// terisi dari gudang yang sama); baris lama yang sudah RELEASED / EXPIRED dihidupkan lagi dengan qty
+// baru. Setiap alokasi harus tercatat, kalau tidak stok yang sudah dikurangi tidak bisa di-release.
+func insertReservations(ctx context.Context, tx pgx.Tx, orderID string, allocs []Allocation, ttl *time.Duration) error {
+	if len(allocs) == 0 {
+		return nil
+	}
+	wids, pids, qtys := allocColumns(allocs)
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO reservations(order_id, product_id, warehouse_id, qty, status, expires_at)
+		SELECT $1, a.pid, a.wid, a.qty, 'RESERVED', now() + $5::interval
+		FROM unnest($2::uuid[], $3::uuid[], $4::int[]) AS a(wid, pid, qty)
+		ON CONFLICT (order_id, product_id, warehouse_id) DO UPDATE
+		SET qty = CASE WHEN reservations.status = 'RESERVED' THEN reservations.qty + EXCLUDED.qty ELSE EXCLUDED.qty END,
+			created_at = CASE WHEN reservations.status = 'RESERVED' THEN reservations.created_at ELSE now() END,
+			status = 'RESERVED',
+			expires_at = EXCLUDED.expires_at`,
+		orderID, wids, pids, qtys, ttl)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	if n := tag.RowsAffected(); n != int64(len(allocs)) {
+		return fmt.Errorf("insert reservations: %d of %d allocations recorded", n, len(allocs))
+	}
+	return nil
+}
 
-	type rec struct {
-		pid string
-		qty int
+func itemColumns(items []ItemQty) (pids []string, qtys []int) {
+	pids = make([]string, len(items))
+	qtys = make([]int, len(items))
+	for i, it := range items {
+		pids[i], qtys[i] = it.ProductID, it.Qty
 	}
-	var recs []rec
+	return pids, qtys
+}
+
+// allocatedItems: total qty per produk dari alokasi (urutan kemunculan pertama).
+func allocatedItems(allocs []Allocation) []ItemQty {
+	items := make([]ItemQty, 0, len(allocs))
+	for _, a := range allocs {
+		items = append(items, ItemQty{ProductID: a.ProductID, Qty: a.Qty})
+	}
+	return mergeItemQty(items)
+}
+
+// moveStock: ubah warehouse_stock & products.stock sebesar sign*qty per alokasi lalu catat ledger,
+// semuanya set-based. Urutan lock: warehouse_stock (product_id, warehouse_id) lalu products (id),
+// sama dengan InventoryRepo.apply. CHECK (stock >= 0) di kedua tabel jadi pengaman terakhir.
+func moveStock(ctx context.Context, tx pgx.Tx, allocs []Allocation, sign int, kind, reason, orderID string) error {
+	if len(allocs) == 0 {
+		return nil
+	}
+	wids, pids, qtys := allocColumns(allocs)
+	for i := range qtys {
+		qtys[i] *= sign
+	}
+
+	if _, err := tx.Exec(ctx, `
+		WITH a AS (
+			SELECT * FROM unnest($1::uuid[], $2::uuid[], $3::int[]) AS a(wid, pid, delta)
+		), locked AS (
+			SELECT ws.warehouse_id, ws.product_id FROM warehouse_stock ws JOIN a ON a.wid = ws.warehouse_id AND a.pid = ws.product_id
+			ORDER BY ws.product_id, ws.warehouse_id FOR UPDATE OF ws
+		)
+		UPDATE warehouse_stock ws SET stock = ws.stock + a.delta, updated_at = now()
+		FROM a, locked l
+		WHERE ws.warehouse_id = a.wid AND ws.product_id = a.pid
+		  AND l.warehouse_id = a.wid AND l.product_id = a.pid`, wids, pids, qtys); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, `
+		WITH a AS (
+			SELECT pid, SUM(delta)::int AS delta
+			FROM unnest($1::uuid[], $2::int[]) AS a(pid, delta) GROUP BY pid
+		), locked AS (
+			SELECT p.id FROM products p JOIN a ON a.pid = p.id ORDER BY p.id FOR UPDATE OF p
+		)
+		UPDATE products p SET stock = p.stock + a.delta
+		FROM a, locked l
+		WHERE p.id = a.pid AND l.id = a.pid
+		RETURNING p.id, p.stock`, pids, qtys)
+	if err != nil {
+		return err
+	}
+	final := map[string]int{}
 	for rows.Next() {
-		var x rec
-		if err := rows.Scan(&x.pid, &x.qty); err != nil {
+		var id string
+		var st int
+		if err := rows.Scan(&id, &st); err != nil {
+			rows.Close()
 			return err
 		}
-		recs = append(recs, x)
+		final[id] = st
 	}
+	rows.Close()
 	if err := rows.Err(); err != nil {
 		return err
 	}
 
-	for _, x := range recs {
-		if _, err := tx.Exec(ctx, `UPDATE products SET stock = stock + $2 WHERE id=$1`, x.pid, x.qty); err != nil {
+	// stock_after per baris ledger: diurutkan seolah alokasi diterapkan satu per satu
+	after := make([]int, len(allocs))
+	remaining := map[string]int{}
+	for i := len(allocs) - 1; i >= 0; i-- {
+		pid := allocs[i].ProductID
+		after[i] = final[pid] - remaining[pid]
+		remaining[pid] += qtys[i]
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO inventory_movements(product_id, warehouse_id, delta, kind, reason, actor, order_id, stock_after)
+		SELECT m.pid, m.wid, m.delta, $5, $6, 'inventory', $7, m.after
+		FROM unnest($1::uuid[], $2::uuid[], $3::int[], $4::int[]) WITH ORDINALITY AS m(wid, pid, delta, after, n)
+		ORDER BY m.n`, wids, pids, qtys, after, kind, reason, orderID)
+	return err
+}
+
+func allocColumns(allocs []Allocation) (wids, pids []string, qtys []int) {
+	wids = make([]string, len(allocs))
+	pids = make([]string, len(allocs))
+	qtys = make([]int, len(allocs))
+	for i, a := range allocs {
+		wids[i], pids[i], qtys[i] = a.WarehouseID, a.ProductID, a.Qty
+	}
+	return wids, pids, qtys
+}
+
+func (r *ReservationRepo) holdInterval() *time.Duration {
+	if r.HoldTTL <= 0 {
+		return nil // expires_at NULL
+	}
+	return &r.HoldTTL
+}
+
+// ReleaseAll: lepas semua reservasi RESERVED order (stok kembali ke gudang asal).
+// allocs kosong = tidak ada yang di-release.
+func (r *ReservationRepo) ReleaseAll(ctx context.Context, orderID string) ([]Allocation, error) {
+	return r.release(ctx, orderID)
+}
+
+// ExpiredOrders: order yang masih menunggu pembayaran (STOCK_RESERVED / PARTIALLY_RESERVED) dengan
+// reservasi RESERVED yang expires_at-nya sudah lewat (paling lama dulu). Order yang sudah PAID /
+// COMPLETED tidak pernah ikut: stoknya sudah terjual.
+func (r *ReservationRepo) ExpiredOrders(ctx context.Context, limit int) ([]string, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT r.order_id FROM reservations r JOIN orders o ON o.id = r.order_id
+		WHERE r.status = 'RESERVED' AND r.expires_at < now()
+		  AND o.status IN ('STOCK_RESERVED', 'PARTIALLY_RESERVED')
+		GROUP BY r.order_id
+		ORDER BY MIN(r.expires_at)
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+// ActorHoldSweeper: actor di order_status_changes untuk order yang gagal karena hold expired.
+const ActorHoldSweeper = "hold-sweeper"
+
+// ReleaseExpired: hold order habis -> semua reservasi RESERVED-nya dilepas (status EXPIRED), backorder
+// yang belum terisi batal dan order pindah ke FAILED (dicatat) dalam satu tx. Order di-lock dulu dan
+// status + expiry dicek ulang: order yang sementara itu sudah dibayar / gagal, atau hold-nya baru
+// diperpanjang, tidak disentuh. allocs kosong = tidak ada yang di-release.
+func (r *ReservationRepo) ReleaseExpired(ctx context.Context, orderID string) (allocs []Allocation, err error) {
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		allocs = nil
+		var expired bool
+		err := tx.QueryRow(ctx, `
+			SELECT o.status IN ('STOCK_RESERVED', 'PARTIALLY_RESERVED')
+			   AND EXISTS (SELECT 1 FROM reservations r WHERE r.order_id = o.id AND r.status = 'RESERVED' AND r.expires_at < now())
+			FROM orders o WHERE o.id = $1 FOR UPDATE`, orderID).Scan(&expired)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && !expired) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if allocs, err = releaseTx(ctx, tx, orderID, true); err != nil {
 			return err
 		}
+		_, err = forceStatusTx(ctx, tx, orderID, StatusFailed, "hold expired", ActorHoldSweeper)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-	if _, err := tx.Exec(ctx, `UPDATE reservations SET status='RELEASED' WHERE order_id=$1 AND status='RESERVED'`, orderID); err != nil {
+	return allocs, nil
+}
+
+// ExtendHold: perpanjang hold by dari sekarang (atau dari expires_at kalau masih lebih lama),
+// dibatasi maxHold sejak reservasi dibuat. Hold yang sudah expired tidak bisa diperpanjang.
+func (r *ReservationRepo) ExtendHold(ctx context.Context, orderID string, by, maxHold time.Duration) (time.Time, error) {
+	var exp time.Time
+	err := r.DB.QueryRow(ctx, `
+		UPDATE reservations
+		SET expires_at = LEAST(GREATEST(expires_at, now()) + $2::interval, created_at + $3::interval)
+		WHERE order_id = $1 AND status = 'RESERVED' AND expires_at > now()
+		RETURNING expires_at`, orderID, by, maxHold).Scan(&exp)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, ErrHoldNotFound
+	}
+	return exp, err
+}
+
+func (r *ReservationRepo) release(ctx context.Context, orderID string) (allocs []Allocation, err error) {
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		allocs, err = releaseTx(ctx, tx, orderID, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocs, nil
+}
+
+// ReleaseOrder: release manual operator (ordersctl order release): lepas semua reservasi RESERVED dan
+// pindahkan status order ke `to` (lewat aturan ForceStatus, dicatat di order_status_changes) dalam satu
+// tx, supaya order tidak tertinggal STOCK_RESERVED tanpa stok. to kosong = status tidak diubah.
+func (r *ReservationRepo) ReleaseOrder(ctx context.Context, orderID string, to Status, reason, actor string) (from Status, allocs []Allocation, err error) {
+	if reason == "" {
+		return "", nil, errors.New("reason is required")
+	}
+	err = inTxRetry(ctx, r.DB, func(tx pgx.Tx) error {
+		// status dulu: order di-lock sebelum reservasinya, urutan yang sama dengan reserve
+		if from, err = forceStatusTx(ctx, tx, orderID, to, reason, actor); err != nil {
+			return err
+		}
+		allocs, err = releaseTx(ctx, tx, orderID, false)
 		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return from, allocs, nil
+}
+
+// releaseTx: lepas semua reservasi RESERVED order + batalkan backorder PENDING-nya (order tidak akan
+// diisi lagi). expired = hold habis (status reservasi EXPIRED) atau dibatalkan (RELEASED).
+func releaseTx(ctx context.Context, tx pgx.Tx, orderID string, expired bool) ([]Allocation, error) {
+	status, reason := "RELEASED", ""
+	if expired {
+		status, reason = "EXPIRED", "hold expired"
+	}
+
+	var (
+		ids    []string
+		allocs []Allocation
+	)
+	rows, err := tx.Query(ctx, `
+		SELECT r.id, r.product_id, r.warehouse_id, w.code, r.qty
+		FROM reservations r JOIN warehouses w ON w.id = r.warehouse_id
+		WHERE r.order_id=$1 AND r.status='RESERVED'
+		ORDER BY r.product_id, r.warehouse_id
+		FOR UPDATE OF r`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id string
+		var a Allocation
+		if err := rows.Scan(&id, &a.ProductID, &a.WarehouseID, &a.WarehouseCode, &a.Qty); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+		allocs = append(allocs, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE backorders SET status='CANCELLED' WHERE order_id=$1 AND status='PENDING'`, orderID); err != nil {
+		return nil, err
+	}
+	if len(allocs) == 0 {
+		return nil, nil
+	}
+
+	if err := moveStock(ctx, tx, allocs, +1, MoveRelease, reason, orderID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE reservations SET status=$2 WHERE id = ANY($1::uuid[])`, ids, status); err != nil {
+		return nil, err
+	}
+	return allocs, nil
+}
+
+// mergeItemQty: product_id duplikat dijumlahkan (allocation bekerja per product).
+func mergeItemQty(items []ItemQty) []ItemQty {
+	idx := make(map[string]int, len(items))
+	out := make([]ItemQty, 0, len(items))
+	for _, it := range items {
+		if i, ok := idx[it.ProductID]; ok {
+			out[i].Qty += it.Qty
+			continue
+		}
+		idx[it.ProductID] = len(out)
+		out = append(out, it)
 	}
-	return tx.Commit(ctx)
+	return out
 }
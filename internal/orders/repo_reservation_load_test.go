@@ -0,0 +1,111 @@
+package orders
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Versi test dari cmd/reserveload (jalur postgres): banyak order berebut satu SKU panas secara
+// paralel, setiap order juga berisi produk COLD dengan urutan berlawanan (pemicu deadlock kalau lock
+// tidak deterministik), sebagian langsung di-release. Invariant stok harus tetap terjaga.
+func TestReserveConcurrentKeepsStockConsistent(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	const (
+		hotStock  = 20
+		coldStock = 1000
+		nOrders   = 80
+		workers   = 16
+		release   = 4 // setiap order ke-N langsung di-release
+	)
+	hot, cold := testProduct(t, db, hotStock), testProduct(t, db, coldStock)
+
+	orderIDs := make([]string, nOrders)
+	lines := make([][]ItemQty, nOrders)
+	for i := range orderIDs {
+		items := []ItemInput{{ProductID: hot.ID, Qty: 1}, {ProductID: cold.ID, Qty: 1}}
+		if i%2 == 1 {
+			items[0], items[1] = items[1], items[0]
+		}
+		orderIDs[i] = testOrder(t, db, items...)
+		for _, it := range items {
+			lines[i] = append(lines[i], ItemQty{ProductID: it.ProductID, Qty: it.Qty})
+		}
+	}
+
+	res := &ReservationRepo{DB: db, HoldTTL: time.Hour}
+	var reserved, rejected, released atomic.Int64
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				r, err := res.Reserve(ctx, ReserveRequest{OrderID: orderIDs[i], Items: lines[i]})
+				if err != nil {
+					t.Errorf("reserve %d: %v", i, err)
+					continue
+				}
+				if r.Status == "" {
+					rejected.Add(1)
+					continue
+				}
+				reserved.Add(1)
+				if i%release == 0 {
+					if _, err := res.ReleaseAll(ctx, orderIDs[i]); err != nil {
+						t.Errorf("release %d: %v", i, err)
+						continue
+					}
+					released.Add(1)
+				}
+			}
+		}()
+	}
+	for i := range orderIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if rejected.Load() == 0 {
+		t.Errorf("no order rejected with %d orders for stock %d", nOrders, hotStock)
+	}
+	if held := int(reserved.Load() - released.Load()); held > hotStock {
+		t.Errorf("%d orders still hold the hot product, stock was %d (oversold)", held, hotStock)
+	}
+	checkStock(t, db, hot, hotStock)
+	checkStock(t, db, cold, coldStock)
+}
+
+// checkStock: invariant stok satu produk (sama dengan cek di cmd/reserveload).
+func checkStock(t *testing.T, db *pgxpool.Pool, p Product, initial int) {
+	t.Helper()
+	var stock, whStock, held, ledger int
+	err := db.QueryRow(context.Background(), `
+		SELECT p.stock,
+		       (SELECT COALESCE(SUM(stock), 0)::int FROM warehouse_stock WHERE product_id = p.id),
+		       (SELECT COALESCE(SUM(qty), 0)::int FROM reservations WHERE product_id = p.id AND status = 'RESERVED'),
+		       (SELECT COALESCE(SUM(delta), 0)::int FROM inventory_movements WHERE product_id = p.id)
+		FROM products p WHERE p.id = $1`, p.ID).Scan(&stock, &whStock, &held, &ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stock < 0 || whStock < 0 {
+		t.Errorf("%s: negative stock (%d / %d)", p.SKU, stock, whStock)
+	}
+	if stock+held != initial {
+		t.Errorf("%s: stock %d + reserved %d != initial %d", p.SKU, stock, held, initial)
+	}
+	if whStock != stock {
+		t.Errorf("%s: warehouse_stock %d != products.stock %d", p.SKU, whStock, stock)
+	}
+	if ledger != stock {
+		t.Errorf("%s: ledger %d != stock %d", p.SKU, ledger, stock)
+	}
+}
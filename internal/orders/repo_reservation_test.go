@@ -0,0 +1,187 @@
+package orders
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// reserveExpired: order qty 2 di-reserve lalu hold-nya dibuat sudah lewat.
+func reserveExpired(t *testing.T, res *ReservationRepo, productID string) string {
+	t.Helper()
+	ctx := context.Background()
+	orderID := testOrder(t, res.DB, ItemInput{ProductID: productID, Qty: 2})
+	r, err := res.Reserve(ctx, ReserveRequest{OrderID: orderID, Items: []ItemQty{{ProductID: productID, Qty: 2}}})
+	if err != nil || r.Status != StatusStockReserved {
+		t.Fatalf("reserve: %+v %v", r, err)
+	}
+	if _, err := res.DB.Exec(ctx, `UPDATE reservations SET expires_at = now() - interval '1 minute' WHERE order_id=$1`, orderID); err != nil {
+		t.Fatal(err)
+	}
+	return orderID
+}
+
+// Hold order yang sudah dibayar tidak boleh di-release sweeper: stoknya sudah terjual.
+func TestReleaseExpiredSkipsPaidOrders(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	res := &ReservationRepo{DB: db, HoldTTL: time.Hour}
+	p := testProduct(t, db, 5)
+	orderID := reserveExpired(t, res, p.ID)
+	if _, err := (&Repo{DB: db}).ForceStatus(ctx, orderID, StatusPaid, "paid", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := res.ExpiredOrders(ctx, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(ids, orderID) {
+		t.Fatal("paid order listed as expired")
+	}
+	allocs, err := res.ReleaseExpired(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocs) != 0 {
+		t.Fatalf("paid order released: %+v", allocs)
+	}
+	if got := productStock(t, db, p.ID); got != 3 {
+		t.Fatalf("stock %d, want 3 (still sold)", got)
+	}
+	if st, _ := (&Repo{DB: db}).GetOrderStatus(ctx, orderID); st != StatusPaid {
+		t.Fatalf("status %s, want PAID", st)
+	}
+}
+
+// Order yang belum dibayar: stok kembali dan order FAILED di tx yang sama.
+func TestReleaseExpiredFailsUnpaidOrder(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	res := &ReservationRepo{DB: db, HoldTTL: time.Hour}
+	p := testProduct(t, db, 5)
+	orderID := reserveExpired(t, res, p.ID)
+
+	ids, err := res.ExpiredOrders(ctx, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(ids, orderID) {
+		t.Fatal("expired order not listed")
+	}
+	allocs, err := res.ReleaseExpired(ctx, orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocs) != 1 || allocs[0].Qty != 2 {
+		t.Fatalf("released %+v, want qty 2", allocs)
+	}
+	if got := productStock(t, db, p.ID); got != 5 {
+		t.Fatalf("stock %d, want 5", got)
+	}
+	if st, _ := (&Repo{DB: db}).GetOrderStatus(ctx, orderID); st != StatusFailed {
+		t.Fatalf("status %s, want FAILED", st)
+	}
+	if again, err := res.ReleaseExpired(ctx, orderID); err != nil || len(again) != 0 {
+		t.Fatalf("second release: %+v %v", again, err)
+	}
+}
+
+// PARTIAL: yang ada di-reserve, sisanya dibatalkan. BACKORDER: sisanya antre di backorders.
+func TestReservePartialPolicies(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	res := &ReservationRepo{DB: db, HoldTTL: time.Hour}
+
+	for _, policy := range []FulfilmentPolicy{FulfilPartial, FulfilBackorder} {
+		a, b := testProduct(t, db, 5), testProduct(t, db, 1)
+		items := []ItemQty{{ProductID: a.ID, Qty: 2}, {ProductID: b.ID, Qty: 3}}
+		orderID := testOrder(t, db, ItemInput{ProductID: a.ID, Qty: 2}, ItemInput{ProductID: b.ID, Qty: 3})
+
+		r, err := res.Reserve(ctx, ReserveRequest{OrderID: orderID, Policy: policy, Items: items})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Status != StatusPartiallyReserved {
+			t.Fatalf("%s: status %q, want PARTIALLY_RESERVED", policy, r.Status)
+		}
+		wantReserved := []ItemQty{{ProductID: a.ID, Qty: 2}, {ProductID: b.ID, Qty: 1}}
+		wantShort := []ItemQty{{ProductID: b.ID, Qty: 2}}
+		if !reflect.DeepEqual(sortedItems(r.Reserved), sortedItems(wantReserved)) {
+			t.Errorf("%s: reserved %v, want %v", policy, r.Reserved, wantReserved)
+		}
+		short := r.Cancelled
+		if policy == FulfilBackorder {
+			short = r.Backordered
+		}
+		if !reflect.DeepEqual(short, wantShort) || len(r.Cancelled)+len(r.Backordered) != 1 {
+			t.Errorf("%s: cancelled %v backordered %v, want %v", policy, r.Cancelled, r.Backordered, wantShort)
+		}
+		if got := productStock(t, db, a.ID); got != 3 {
+			t.Errorf("%s: stock a %d, want 3", policy, got)
+		}
+		if got := productStock(t, db, b.ID); got != 0 {
+			t.Errorf("%s: stock b %d, want 0", policy, got)
+		}
+
+		var pending int
+		if err := db.QueryRow(ctx, `SELECT COALESCE(SUM(qty), 0)::int FROM backorders WHERE order_id=$1 AND status='PENDING'`, orderID).Scan(&pending); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[FulfilmentPolicy]int{FulfilPartial: 0, FulfilBackorder: 2}[policy]; pending != want {
+			t.Errorf("%s: pending backorder qty %d, want %d", policy, pending, want)
+		}
+	}
+
+	// PARTIAL tanpa stok sama sekali tetap ditolak
+	c := testProduct(t, db, 0)
+	orderID := testOrder(t, db, ItemInput{ProductID: c.ID, Qty: 1})
+	r, err := res.Reserve(ctx, ReserveRequest{OrderID: orderID, Policy: FulfilPartial, Items: []ItemQty{{ProductID: c.ID, Qty: 1}}})
+	if err != nil || r.Status != "" || len(r.Shortages) != 1 {
+		t.Fatalf("partial without stock: %+v %v, want rejected", r, err)
+	}
+}
+
+// Stok yang kembali karena reservasi order lain dilepas dipakai untuk mengisi backorder.
+func TestFillBackordersAfterRelease(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	res := &ReservationRepo{DB: db, HoldTTL: time.Hour}
+	p := testProduct(t, db, 1)
+
+	holder := testOrder(t, db, ItemInput{ProductID: p.ID, Qty: 1})
+	if r, err := res.Reserve(ctx, ReserveRequest{OrderID: holder, Items: []ItemQty{{ProductID: p.ID, Qty: 1}}}); err != nil || r.Status != StatusStockReserved {
+		t.Fatalf("reserve holder: %+v %v", r, err)
+	}
+	waiting := testOrder(t, db, ItemInput{ProductID: p.ID, Qty: 2})
+	r, err := res.Reserve(ctx, ReserveRequest{OrderID: waiting, Policy: FulfilBackorder, Items: []ItemQty{{ProductID: p.ID, Qty: 2}}})
+	if err != nil || r.Status != StatusPartiallyReserved || len(r.Allocations) != 0 {
+		t.Fatalf("reserve backorder: %+v %v", r, err)
+	}
+
+	if fills, err := res.FillBackorders(ctx, p.ID); err != nil || len(fills) != 0 {
+		t.Fatalf("fill before release: %+v %v", fills, err)
+	}
+	if _, err := res.ReleaseAll(ctx, holder); err != nil {
+		t.Fatal(err)
+	}
+	fills, err := res.FillBackorders(ctx, p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 || fills[0].OrderID != waiting || fills[0].Complete || len(fills[0].Allocations) != 1 || fills[0].Allocations[0].Qty != 1 {
+		t.Fatalf("fills %+v, want 1 unit for %s (still pending)", fills, waiting)
+	}
+	if got := productStock(t, db, p.ID); got != 0 {
+		t.Fatalf("stock %d, want 0", got)
+	}
+}
+
+func sortedItems(items []ItemQty) []ItemQty {
+	out := slices.Clone(items)
+	slices.SortFunc(out, func(a, b ItemQty) int { return strings.Compare(a.ProductID, b.ProductID) })
+	return out
+}
@@ -0,0 +1,40 @@
+package orders
+
+import (
+	"context"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TaxRuleRepo: tax.Calculator di atas tabel tax_rules (rule dibaca per order, cukup kecil).
+type TaxRuleRepo struct{ DB *pgxpool.Pool }
+
+func (r *TaxRuleRepo) Rules(ctx context.Context, region string) (tax.Table, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT region, category, name, rate_bp FROM tax_rules
+		WHERE active AND (region = '' OR region = $1)
+		ORDER BY region, category, name`, region)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out tax.Table
+	for rows.Next() {
+		var t tax.Rule
+		if err := rows.Scan(&t.Region, &t.Category, &t.Name, &t.RateBP); err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (r *TaxRuleRepo) Calculate(ctx context.Context, req tax.Request) ([]tax.TaxLine, error) {
+	rules, err := r.Rules(ctx, req.Region)
+	if err != nil {
+		return nil, err
+	}
+	return rules.Calculate(ctx, req)
+}
@@ -0,0 +1,79 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// bumpingLoad: load yang membaca produk lalu mengubahnya dari koneksi lain (seperti update katalog yang
+// masuk di tengah pembuatan order), setiap attempt.
+func bumpingLoad(t *testing.T, r *Repo, p Product, expected *int) func(tx pgx.Tx) ([]orderLine, error) {
+	return func(tx pgx.Tx) ([]orderLine, error) {
+		ctx := context.Background()
+		byID, err := loadProducts(ctx, tx, `id = $1`, []any{p.ID}, func(p listedProduct) string { return p.id })
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.DB.Exec(ctx, `UPDATE products SET price_cents = price_cents + 1, version = version + 1 WHERE id=$1`, p.ID); err != nil {
+			t.Fatal(err)
+		}
+		return []orderLine{byID[p.ID].line(1, expected)}, nil
+	}
+}
+
+func TestCreateOrderProductsKeepChanging(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	r := &Repo{DB: db}
+
+	p := testProduct(t, db, 5)
+	_, err := r.createOrder(ctx, "TEST-"+uuid.NewString(), uuid.NewString(), PricingOptions{}, bumpingLoad(t, r, p, nil))
+	if !errors.Is(err, ErrProductsBusy) || errors.Is(err, ErrPriceChanged) {
+		t.Fatalf("without expected price: got %v, want ErrProductsBusy", err)
+	}
+
+	p = testProduct(t, db, 5)
+	price := p.PriceCents
+	_, err = r.createOrder(ctx, "TEST-"+uuid.NewString(), uuid.NewString(), PricingOptions{}, bumpingLoad(t, r, p, &price))
+	var pc *PriceChangedError
+	if !errors.As(err, &pc) || len(pc.Changes) != 1 || pc.Changes[0].ExpectedCents != price {
+		t.Fatalf("with expected price: got %v, want PRICE_CHANGED against %d", err, price)
+	}
+}
+
+// Setelah checkProductVersions, produk ter-lock sampai commit: update katalog harus menunggu.
+func TestCheckProductVersionsLocksUntilCommit(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	p := testProduct(t, db, 5)
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	line := listedProduct{id: p.ID, sku: p.SKU, price: p.PriceCents, version: p.Version}.line(1, nil)
+	if err := checkProductVersions(ctx, tx, []orderLine{line}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, `SET lock_timeout = '100ms'`); err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(ctx, `UPDATE products SET price_cents = price_cents + 1, version = version + 1 WHERE id=$1`, p.ID)
+	if err == nil {
+		t.Fatal("product updated while order tx still holds it")
+	}
+	if _, err := conn.Exec(ctx, `RESET lock_timeout`); err != nil {
+		t.Fatal(err)
+	}
+}
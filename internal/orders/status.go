@@ -3,19 +3,22 @@ package orders
 type Status string
 
 const (
-	StatusCreated       Status = "CREATED"
-	StatusStockReserved Status = "STOCK_RESERVED"
-	StatusPaid          Status = "PAID"
-	StatusCompleted     Status = "COMPLETED"
-	StatusFailed        Status = "FAILED"
+	StatusCreated           Status = "CREATED"
+	StatusStockReserved     Status = "STOCK_RESERVED"
+	StatusPartiallyReserved Status = "PARTIALLY_RESERVED" // fulfilment PARTIAL / BACKORDER
+	StatusPaid              Status = "PAID"
+	StatusCompleted         Status = "COMPLETED"
+	StatusFailed            Status = "FAILED"
 )
 
+// PARTIALLY_RESERVED -> STOCK_RESERVED saat semua backorder terpenuhi.
 var validNext = map[Status]map[Status]bool{
-	StatusCreated:       {StatusStockReserved: true, StatusFailed: true},
-	StatusStockReserved: {StatusPaid: true, StatusFailed: true},
-	StatusPaid:          {StatusCompleted: true},
-	StatusCompleted:     {},
-	StatusFailed:        {},
+	StatusCreated:           {StatusStockReserved: true, StatusPartiallyReserved: true, StatusFailed: true},
+	StatusStockReserved:     {StatusPaid: true, StatusFailed: true},
+	StatusPartiallyReserved: {StatusStockReserved: true, StatusPaid: true, StatusFailed: true},
+	StatusPaid:              {StatusCompleted: true},
+	StatusCompleted:         {},
+	StatusFailed:            {},
 }
 
 func CanTransition(from, to Status) bool {
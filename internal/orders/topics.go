@@ -1,13 +1,56 @@
 package orders
 
-const (
+// Nama topic; default di bawah, bisa di-override sekali saat startup lewat SetTopics (config
+// kafka.topics) sebelum producer/consumer dibuat.
+var (
 	TopicOrderCreated      = "order.created"
 	TopicStockReserved     = "order.stock.reserved"
 	TopicStockRejected     = "order.stock.rejected"
+	TopicStockReleased     = "order.stock.released"
 	TopicPaymentAuthorized = "order.payment.authorized"
 	TopicPaymentFailed     = "order.payment.failed"
 	TopicOrderFinalized    = "order.finalized"
+	TopicCatalog           = "catalog.products"
+	TopicStockAdjusted     = "inventory.stock.adjusted"
+	TopicStockAlerts       = "inventory.stock.alerts"
 )
 
+// Topics: field sama dengan config.Topics, jadi main cukup orders.SetTopics(orders.Topics(cfg.Kafka.Topics)).
+type Topics struct {
+	OrderCreated      string
+	StockReserved     string
+	StockRejected     string
+	StockReleased     string
+	PaymentAuthorized string
+	PaymentFailed     string
+	OrderFinalized    string
+	Catalog           string
+	StockAdjusted     string
+	StockAlerts       string
+}
+
+// SetTopics: field kosong = tetap default.
+func SetTopics(t Topics) {
+	for _, p := range []struct {
+		dst *string
+		v   string
+	}{
+		{&TopicOrderCreated, t.OrderCreated},
+		{&TopicStockReserved, t.StockReserved},
+		{&TopicStockRejected, t.StockRejected},
+		{&TopicStockReleased, t.StockReleased},
+		{&TopicPaymentAuthorized, t.PaymentAuthorized},
+		{&TopicPaymentFailed, t.PaymentFailed},
+		{&TopicOrderFinalized, t.OrderFinalized},
+		{&TopicCatalog, t.Catalog},
+		{&TopicStockAdjusted, t.StockAdjusted},
+		{&TopicStockAlerts, t.StockAlerts},
+	} {
+		if p.v != "" {
+			*p.dst = p.v
+		}
+	}
+}
+
 // Partition key = order_id, supaya semua event 1 order maintain urutan.
 func PartitionKey(orderID string) []byte { return []byte(orderID) }
@@ -0,0 +1,43 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// txAttempts: berapa kali tx diulang kalau kena deadlock / serialization failure.
+const txAttempts = 4
+
+// inTxRetry: jalankan fn dalam satu tx; commit kalau fn sukses. Deadlock (40P01) dan
+// serialization failure (40001) diulang dari awal dengan backoff kecil, error lain langsung dikembalikan.
+// fn harus bebas side effect di luar tx karena bisa dipanggil lebih dari sekali.
+func inTxRetry(ctx context.Context, db *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	var err error
+	for attempt := 0; attempt < txAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * 10 * time.Millisecond):
+			}
+		}
+		err = pgx.BeginTxFunc(ctx, db, pgx.TxOptions{}, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40P01" || pgErr.Code == "40001" // deadlock_detected, serialization_failure
+}
@@ -0,0 +1,49 @@
+package ordersvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/redisx"
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache: implementasi Cache di atas key redisx (idem:order:create:*, order_status:*).
+type RedisCache struct {
+	Client *redis.Client
+}
+
+func (c RedisCache) SetIdempotency(ctx context.Context, externalID, orderID string) error {
+	return c.Client.Set(ctx, fmt.Sprintf(redisx.KeyIdemOrderCreate, externalID), orderID, redisx.TTLIdempotency.Get()).Err()
+}
+
+func (c RedisCache) SetStatus(ctx context.Context, orderID string, s orders.Status) error {
+	b, _ := json.Marshal(map[string]any{"status": s})
+	return c.Client.Set(ctx, fmt.Sprintf(redisx.KeyOrderStatus, orderID), b, redisx.TTLStatusCache.Get()).Err()
+}
+
+// GetStatus: hasil lookup dicatat di metrics.CacheRequests{cache="order_status"}.
+func (c RedisCache) GetStatus(ctx context.Context, orderID string) (orders.Status, bool, error) {
+	b, err := c.Client.Get(ctx, fmt.Sprintf(redisx.KeyOrderStatus, orderID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		metrics.CacheRequests.WithLabelValues("order_status", "miss").Inc()
+		return "", false, nil
+	}
+	if err != nil {
+		metrics.CacheRequests.WithLabelValues("order_status", "error").Inc()
+		return "", false, err
+	}
+	var cached struct {
+		Status orders.Status `json:"status"`
+	}
+	if err := json.Unmarshal(b, &cached); err != nil || cached.Status == "" {
+		metrics.CacheRequests.WithLabelValues("order_status", "miss").Inc()
+		return "", false, nil
+	}
+	metrics.CacheRequests.WithLabelValues("order_status", "hit").Inc()
+	return cached.Status, true, nil
+}
@@ -0,0 +1,52 @@
+package ordersvc
+
+import (
+	"errors"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/money"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+)
+
+// ErrorClass: kelompok error use-case order. Dipetakan ke status HTTP (httpx) dan kode gRPC (grpcx)
+// di satu tempat supaya /orders, /orders/sku, /quotes dan gRPC menjawab error yang sama dengan cara sama.
+type ErrorClass int
+
+const (
+	ClassInternal     ErrorClass = iota // tidak dikenal: 500 / Internal
+	ClassInvalid                        // validation.Errors: 400 / InvalidArgument
+	ClassRejected                       // format valid tapi ditolak (produk tidak ada / archived, kupon, currency, quote): 422 / FailedPrecondition
+	ClassPriceChanged                   // orders.PriceChangedError: 409 / FailedPrecondition (+ daftar perubahan)
+	ClassBusy                           // orders.ErrProductsBusy: 503 / Unavailable, aman diulang
+	ClassDisabled                       // ErrFeatureDisabled: 403 / PermissionDenied
+	ClassNotFound                       // order tidak ada: 404 / NotFound
+)
+
+// Classify: err nil -> ClassInternal, cek err != nil dulu.
+func Classify(err error) ErrorClass {
+	var verrs validation.Errors
+	switch {
+	case errors.As(err, &verrs):
+		return ClassInvalid
+	case errors.Is(err, orders.ErrPriceChanged):
+		return ClassPriceChanged
+	case errors.Is(err, orders.ErrProductsBusy):
+		return ClassBusy
+	case errors.Is(err, ErrFeatureDisabled):
+		return ClassDisabled
+	case errors.Is(err, ErrNotFound):
+		return ClassNotFound
+	case errors.Is(err, orders.ErrProductNotFound),
+		errors.Is(err, orders.ErrProductArchived),
+		errors.Is(err, orders.ErrCouponInvalid),
+		errors.Is(err, orders.ErrCouponUsedUp),
+		errors.Is(err, money.ErrCurrencyMismatch),
+		errors.Is(err, orders.ErrQuoteNotFound),
+		errors.Is(err, orders.ErrQuoteExpired),
+		errors.Is(err, orders.ErrQuoteUsed),
+		errors.Is(err, orders.ErrQuoteMismatch):
+		return ClassRejected
+	default:
+		return ClassInternal
+	}
+}
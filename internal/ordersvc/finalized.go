@@ -0,0 +1,26 @@
+package ordersvc
+
+import (
+	"context"
+	"encoding/json"
+
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// HandleOrderFinalized: consumer topic order.finalized (dipublish orchestrator), saat ini hanya
+// menghitung metrics.OrdersFinalized per final_status. Payload rusak di-skip (tidak di-retry).
+func (s *Service) HandleOrderFinalized(_ context.Context, m kafkago.Message) error {
+	var env orders.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil || env.EventType != orders.EventOrderFinalized {
+		return nil
+	}
+	p, err := kafkax.UnwrapPayload[orders.OrderFinalizedPayload](env.Payload)
+	if err != nil || p.FinalStatus == "" {
+		return nil
+	}
+	metrics.OrdersFinalized.WithLabelValues(p.FinalStatus).Inc()
+	return nil
+}
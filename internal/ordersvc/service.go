@@ -0,0 +1,287 @@
+package ordersvc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/flags"
+	kafkax "github.com/ariefcatur/go-realtime-orders.git/internal/kafka"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/logx"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/metrics"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/validation"
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// ErrFeatureDisabled: jalur yang diminta belum dibuka untuk user / order ini (feature flag).
+var ErrFeatureDisabled = errors.New("feature not enabled")
+
+// Repository: bagian orders.Repo yang dipakai use-case order.
+type Repository interface {
+	CreateOrderTx(ctx context.Context, externalID, userID string, items []orders.ItemInput, opts orders.PricingOptions) (orders.CreatedOrder, error)
+	CreateOrderBySKU(ctx context.Context, externalID, userID string, items []orders.ItemInputSKU, opts orders.PricingOptions) (orders.CreatedOrder, error)
+	GetOrderStatus(ctx context.Context, orderID string) (orders.Status, error)
+	ListProducts(ctx context.Context) ([]orders.Product, error)
+	CreateQuote(ctx context.Context, userID string, items []orders.ItemInput, ttl time.Duration) (orders.Quote, error)
+}
+
+// Flags: evaluasi feature flag (implementasi: flags.Client).
+type Flags interface {
+	Enabled(ctx context.Context, key string, sub flags.Subject, def bool) bool
+}
+
+// Cache: shortcut idempotency + cache status order (implementasi: RedisCache).
+type Cache interface {
+	SetIdempotency(ctx context.Context, externalID, orderID string) error
+	SetStatus(ctx context.Context, orderID string, s orders.Status) error
+	// GetStatus: ok=false kalau tidak ada di cache.
+	GetStatus(ctx context.Context, orderID string) (s orders.Status, ok bool, err error)
+}
+
+// Publisher: cukup kafkax.Producer.Publish.
+type Publisher interface {
+	Publish(ctx context.Context, key, value []byte, headers ...kafkago.Header)
+}
+
+// Service: use-case order (application service) yang dipakai bersama oleh HTTP (httpx)
+// dan gRPC (grpcx). Urutan repo -> idempotency -> cache status -> publish OrderCreated
+// hanya ada di sini.
+type Service struct {
+	Repo      Repository
+	Cache     Cache
+	Publisher Publisher
+	Name      string // dipakai sebagai Envelope.Producer
+	Limits    validation.Limits
+	QuoteTTL  time.Duration    // masa berlaku harga quote; <= 0 -> defaultQuoteTTL
+	Now       func() time.Time // nil -> time.Now
+	Flags     Flags            // nil -> semua flag pakai default
+}
+
+const defaultQuoteTTL = 15 * time.Minute
+
+// PlaceOrderInput: input PlaceOrder; Items pakai product_id.
+type PlaceOrderInput struct {
+	ExternalID       string
+	UserID           string
+	Region           string // opsional, diteruskan ke inventory (allocation)
+	Items            []orders.ItemInput
+	TraceID          string
+	FulfilmentPolicy orders.FulfilmentPolicy // kosong = ALL_OR_NOTHING
+	CouponCode       string                  // opsional
+	Currency         string                  // opsional, harus sama dengan currency produk
+	QuoteID          string                  // opsional, pakai harga dari CreateQuote
+}
+
+// PlaceOrderBySKUInput: sama seperti PlaceOrderInput, item direferensikan lewat SKU.
+type PlaceOrderBySKUInput struct {
+	ExternalID       string
+	UserID           string
+	Region           string
+	Items            []orders.ItemInputSKU
+	TraceID          string
+	FulfilmentPolicy orders.FulfilmentPolicy
+	CouponCode       string
+	Currency         string
+	QuoteID          string
+}
+
+type PlaceResult struct {
+	OrderID       string
+	Currency      string
+	TotalCents    int
+	DiscountCents int
+	TaxCents      int
+	Items         []orders.ItemPrice // breakdown harga per line
+	Taxes         []tax.TaxLine
+	Idempotent    bool
+}
+
+type OrderView struct {
+	OrderID string
+	Status  orders.Status
+}
+
+// PlaceOrder: validasi -> repo (idempotent via external_id) -> cache -> publish OrderCreated.
+// Error validasi dikembalikan sebagai validation.Errors.
+func (s *Service) PlaceOrder(ctx context.Context, in PlaceOrderInput) (PlaceResult, error) {
+	items, err := validation.CreateOrder(in.ExternalID, in.UserID, in.Items, s.Limits)
+	if err != nil {
+		return PlaceResult{}, err
+	}
+	if err := validation.Region(in.Region); err != nil {
+		return PlaceResult{}, err
+	}
+	if err := validation.FulfilmentPolicy(in.FulfilmentPolicy); err != nil {
+		return PlaceResult{}, err
+	}
+	if err := validation.Pricing(in.CouponCode, in.Currency, in.QuoteID); err != nil {
+		return PlaceResult{}, err
+	}
+	created, err := s.Repo.CreateOrderTx(ctx, in.ExternalID, in.UserID, items, orders.PricingOptions{
+		CouponCode: in.CouponCode, Region: in.Region, Currency: in.Currency, QuoteID: in.QuoteID, Policy: in.FulfilmentPolicy,
+	})
+	if err != nil {
+		return PlaceResult{}, err
+	}
+	return s.afterCreate(ctx, orders.OrderCreatedPayload{
+		ExternalID: in.ExternalID, UserID: in.UserID, Region: in.Region, FulfilmentPolicy: in.FulfilmentPolicy,
+	}, created, in.TraceID), nil
+}
+
+// PlaceOrderBySKU: sama seperti PlaceOrder; product_id & harga di payload hasil resolve SKU di repo.
+func (s *Service) PlaceOrderBySKU(ctx context.Context, in PlaceOrderBySKUInput) (PlaceResult, error) {
+	items, err := validation.CreateOrderBySKU(in.ExternalID, in.UserID, in.Items, s.Limits)
+	if err != nil {
+		return PlaceResult{}, err
+	}
+	if !s.enabled(ctx, flags.OrdersSKU, flags.Subject{UserID: in.UserID, ExternalID: in.ExternalID}, true) {
+		return PlaceResult{}, ErrFeatureDisabled
+	}
+	if err := validation.Region(in.Region); err != nil {
+		return PlaceResult{}, err
+	}
+	if err := validation.FulfilmentPolicy(in.FulfilmentPolicy); err != nil {
+		return PlaceResult{}, err
+	}
+	if err := validation.Pricing(in.CouponCode, in.Currency, in.QuoteID); err != nil {
+		return PlaceResult{}, err
+	}
+	created, err := s.Repo.CreateOrderBySKU(ctx, in.ExternalID, in.UserID, items, orders.PricingOptions{
+		CouponCode: in.CouponCode, Region: in.Region, Currency: in.Currency, QuoteID: in.QuoteID, Policy: in.FulfilmentPolicy,
+	})
+	if err != nil {
+		return PlaceResult{}, err
+	}
+	return s.afterCreate(ctx, orders.OrderCreatedPayload{
+		ExternalID: in.ExternalID, UserID: in.UserID, Region: in.Region, FulfilmentPolicy: in.FulfilmentPolicy,
+	}, created, in.TraceID), nil
+}
+
+// GetOrder: cache dulu, fallback repo (lalu isi cache).
+func (s *Service) GetOrder(ctx context.Context, orderID string) (OrderView, error) {
+	if st, ok, err := s.Cache.GetStatus(ctx, orderID); err == nil && ok {
+		return OrderView{OrderID: orderID, Status: st}, nil
+	}
+
+	st, err := s.loadStatus(ctx, orderID)
+	if err != nil {
+		return OrderView{}, err
+	}
+	return OrderView{OrderID: orderID, Status: st}, nil
+}
+
+// loadStatus: status dari Postgres (tanpa cache), sekaligus menyegarkan cache status.
+func (s *Service) loadStatus(ctx context.Context, orderID string) (orders.Status, error) {
+	st, err := s.Repo.GetOrderStatus(ctx, orderID)
+	if errors.Is(err, orders.ErrOrderNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	_ = s.Cache.SetStatus(ctx, orderID, st)
+	return st, nil
+}
+
+func (s *Service) ListProducts(ctx context.Context) ([]orders.Product, error) {
+	return s.Repo.ListProducts(ctx)
+}
+
+// CreateQuote: kunci list price produk untuk user selama QuoteTTL; order yang menyebut quote_id
+// memakai harga ini walau harga produk berubah.
+func (s *Service) CreateQuote(ctx context.Context, userID string, items []orders.ItemInput) (orders.Quote, error) {
+	items, err := validation.CreateQuote(userID, items, s.Limits)
+	if err != nil {
+		return orders.Quote{}, err
+	}
+	ttl := s.QuoteTTL
+	if ttl <= 0 {
+		ttl = defaultQuoteTTL
+	}
+	return s.Repo.CreateQuote(ctx, userID, items, ttl)
+}
+
+// afterCreate: base berisi field dari request (external_id, user_id, region, policy);
+// sisanya (item, total, diskon, kupon, currency, pajak) diisi dari hasil repo.
+//
+// Replay (external_id sudah ada): status di cache tidak ditimpa (order bisa sudah lanjut) dan
+// OrderCreated hanya dikirim ulang kalau order masih CREATED, yaitu event pertama kemungkinan hilang
+// (publish async, proses mati sebelum terkirim); inventory tetap idempotent per order.
+func (s *Service) afterCreate(ctx context.Context, base orders.OrderCreatedPayload, created orders.CreatedOrder, traceID string) PlaceResult {
+	log := logx.FromContext(ctx)
+	// cache best-effort: DB tetap jadi kebenaran
+	_ = s.Cache.SetIdempotency(ctx, base.ExternalID, created.OrderID)
+	publish := true
+	if created.Existed {
+		st, err := s.Repo.GetOrderStatus(ctx, created.OrderID)
+		if err != nil {
+			log.Warn("replayed order status unavailable, OrderCreated not re-published", "order_id", created.OrderID, "err", err)
+		}
+		publish = err == nil && st == orders.StatusCreated
+	} else {
+		_ = s.Cache.SetStatus(ctx, created.OrderID, orders.StatusCreated)
+		metrics.OrdersCreated.Inc()
+	}
+	log.Info("order placed", "order_id", created.OrderID, "external_id", base.ExternalID,
+		"total_cents", created.TotalCents, "idempotent", created.Existed, "published", publish)
+	res := PlaceResult{
+		OrderID: created.OrderID, Currency: created.Currency, TotalCents: created.TotalCents,
+		DiscountCents: created.DiscountCents, TaxCents: created.TaxCents,
+		Items: created.Items, Taxes: created.Taxes, Idempotent: created.Existed,
+	}
+	if !publish {
+		return res
+	}
+
+	base.OrderID = created.OrderID
+	base.Items = created.Items
+	base.TotalCents = created.TotalCents
+	base.DiscountCents = created.DiscountCents
+	base.CouponCode = created.CouponCode
+	base.Discounts = created.Discounts
+	base.Currency = created.Currency
+	base.TaxCents = created.TaxCents
+	base.Taxes = created.Taxes
+	base.QuoteID = created.QuoteID
+	s.publishCreated(ctx, traceID, base)
+	return res
+}
+
+func (s *Service) publishCreated(ctx context.Context, traceID string, payload orders.OrderCreatedPayload) {
+	ev := orders.Envelope{
+		EventID:       uuid.NewString(),
+		EventType:     orders.EventOrderCreated,
+		EventVersion:  orders.VersionOrderCreated,
+		OccurredAt:    s.now().UTC(),
+		Producer:      s.Name,
+		TraceID:       traceID,
+		CorrelationID: payload.OrderID,
+		Payload:       kafkax.MustMarshal(payload),
+	}
+	s.Publisher.Publish(ctx,
+		orders.PartitionKey(payload.OrderID),
+		kafkax.MustMarshal(ev),
+		kafkago.Header{Key: "x-event-type", Value: []byte(orders.EventOrderCreated)},
+		kafkago.Header{Key: "x-event-version", Value: []byte(strconv.Itoa(orders.VersionOrderCreated))},
+	)
+}
+
+func (s *Service) enabled(ctx context.Context, key string, sub flags.Subject, def bool) bool {
+	if s.Flags == nil {
+		return def
+	}
+	return s.Flags.Enabled(ctx, key, sub, def)
+}
+
+func (s *Service) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
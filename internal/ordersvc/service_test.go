@@ -0,0 +1,255 @@
+package ordersvc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tax"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+const (
+	testOrderID = "7f9c2d4e-0000-4000-8000-000000000001"
+	testUserID  = "7f9c2d4e-0000-4000-8000-0000000000aa"
+	productA    = "7f9c2d4e-0000-4000-8000-00000000000a"
+	productB    = "7f9c2d4e-0000-4000-8000-00000000000b"
+)
+
+// fakeRepo: CreateOrder* mengembalikan created; external_id yang sama berikutnya -> Existed.
+type fakeRepo struct {
+	created orders.CreatedOrder
+	status  orders.Status
+	seen    map[string]bool
+	opts    orders.PricingOptions
+	skus    []orders.ItemInputSKU
+}
+
+func (r *fakeRepo) create(externalID string, opts orders.PricingOptions) (orders.CreatedOrder, error) {
+	if r.seen == nil {
+		r.seen = map[string]bool{}
+	}
+	out := r.created
+	out.Existed = r.seen[externalID]
+	r.seen[externalID] = true
+	r.opts = opts
+	return out, nil
+}
+
+func (r *fakeRepo) CreateOrderTx(_ context.Context, externalID, _ string, _ []orders.ItemInput, opts orders.PricingOptions) (orders.CreatedOrder, error) {
+	return r.create(externalID, opts)
+}
+
+func (r *fakeRepo) CreateOrderBySKU(_ context.Context, externalID, _ string, items []orders.ItemInputSKU, opts orders.PricingOptions) (orders.CreatedOrder, error) {
+	r.skus = items
+	return r.create(externalID, opts)
+}
+
+func (r *fakeRepo) GetOrderStatus(context.Context, string) (orders.Status, error) {
+	return r.status, nil
+}
+
+func (r *fakeRepo) ListProducts(context.Context) ([]orders.Product, error) { return nil, nil }
+
+func (r *fakeRepo) CreateQuote(context.Context, string, []orders.ItemInput, time.Duration) (orders.Quote, error) {
+	return orders.Quote{}, nil
+}
+
+type fakeCache struct {
+	idem   map[string]string
+	status map[string]orders.Status
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{idem: map[string]string{}, status: map[string]orders.Status{}}
+}
+
+func (c *fakeCache) SetIdempotency(_ context.Context, externalID, orderID string) error {
+	c.idem[externalID] = orderID
+	return nil
+}
+
+func (c *fakeCache) SetStatus(_ context.Context, orderID string, s orders.Status) error {
+	c.status[orderID] = s
+	return nil
+}
+
+func (c *fakeCache) GetStatus(_ context.Context, orderID string) (orders.Status, bool, error) {
+	s, ok := c.status[orderID]
+	return s, ok, nil
+}
+
+// fakePublisher: drop = pesan hilang (producer async gagal kirim ke broker).
+type fakePublisher struct {
+	drop bool
+	msgs []kafkago.Message
+}
+
+func (p *fakePublisher) Publish(_ context.Context, key, value []byte, headers ...kafkago.Header) {
+	if p.drop {
+		return
+	}
+	p.msgs = append(p.msgs, kafkago.Message{Key: key, Value: value, Headers: headers})
+}
+
+func newService() (*Service, *fakeRepo, *fakeCache, *fakePublisher) {
+	repo := &fakeRepo{
+		status: orders.StatusCreated,
+		created: orders.CreatedOrder{
+			OrderID: testOrderID, Currency: "IDR", TotalCents: 23310, DiscountCents: 1000, TaxCents: 2310,
+			Items: []orders.ItemPrice{
+				{ProductID: productA, Qty: 2, PriceCents: 5000, DiscountCents: 1000, FinalCents: 9000, TaxCents: 990},
+				{ProductID: productB, Qty: 1, PriceCents: 12000, FinalCents: 12000, TaxCents: 1320},
+			},
+			Taxes: []tax.TaxLine{
+				{ProductID: productA, Name: "PPN", RateBP: 1100, TaxableCents: 9000, TaxCents: 990},
+				{ProductID: productB, Name: "PPN", RateBP: 1100, TaxableCents: 12000, TaxCents: 1320},
+			},
+		},
+	}
+	cache, pub := newFakeCache(), &fakePublisher{}
+	return &Service{Repo: repo, Cache: cache, Publisher: pub, Name: "test"}, repo, cache, pub
+}
+
+func skuInput() PlaceOrderBySKUInput {
+	return PlaceOrderBySKUInput{
+		ExternalID: "ext-1", UserID: testUserID, Region: "ID-JK", TraceID: "trace-1",
+		FulfilmentPolicy: orders.FulfilPartial,
+		Items:            []orders.ItemInputSKU{{SKU: "SKU-A", Qty: 1}, {SKU: "SKU-B", Qty: 1}, {SKU: "SKU-A", Qty: 1}},
+	}
+}
+
+func decodeCreated(t *testing.T, m kafkago.Message) (orders.Envelope, orders.OrderCreatedPayload) {
+	t.Helper()
+	var env orders.Envelope
+	if err := json.Unmarshal(m.Value, &env); err != nil {
+		t.Fatal(err)
+	}
+	var p orders.OrderCreatedPayload
+	if err := json.Unmarshal(env.Payload, &p); err != nil {
+		t.Fatal(err)
+	}
+	return env, p
+}
+
+// Payload OrderCreated dari order by SKU berisi product_id + harga hasil resolve repo, bukan SKU.
+func TestPlaceOrderBySKUPublishesFullPayload(t *testing.T) {
+	svc, repo, cache, pub := newService()
+	res, err := svc.PlaceOrderBySKU(context.Background(), skuInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Idempotent || res.OrderID != testOrderID || res.TotalCents != 23310 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	wantSKUs := []orders.ItemInputSKU{{SKU: "SKU-A", Qty: 2}, {SKU: "SKU-B", Qty: 1}}
+	if !reflect.DeepEqual(repo.skus, wantSKUs) {
+		t.Fatalf("repo got %+v, want merged %+v", repo.skus, wantSKUs)
+	}
+	if repo.opts.Region != "ID-JK" || repo.opts.Policy != orders.FulfilPartial {
+		t.Fatalf("region / policy not passed to repo: %+v", repo.opts)
+	}
+	if cache.idem["ext-1"] != testOrderID || cache.status[testOrderID] != orders.StatusCreated {
+		t.Fatalf("cache not filled: %+v %+v", cache.idem, cache.status)
+	}
+
+	if len(pub.msgs) != 1 {
+		t.Fatalf("published %d messages, want 1", len(pub.msgs))
+	}
+	m := pub.msgs[0]
+	if string(m.Key) != testOrderID {
+		t.Fatalf("key %q, want order id", m.Key)
+	}
+	env, p := decodeCreated(t, m)
+	if env.EventType != orders.EventOrderCreated || env.EventVersion != orders.VersionOrderCreated ||
+		env.TraceID != "trace-1" || env.CorrelationID != testOrderID {
+		t.Fatalf("unexpected envelope %+v", env)
+	}
+	want := orders.OrderCreatedPayload{
+		OrderID: testOrderID, ExternalID: "ext-1", UserID: testUserID, Region: "ID-JK",
+		FulfilmentPolicy: orders.FulfilPartial, Items: repo.created.Items, TotalCents: 23310,
+		DiscountCents: 1000, Currency: "IDR", TaxCents: 2310, Taxes: repo.created.Taxes,
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Fatalf("payload\n got %+v\nwant %+v", p, want)
+	}
+}
+
+// Replay untuk order yang sudah lanjut: status cache tidak ditimpa CREATED, tidak publish ulang.
+func TestPlaceOrderReplayKeepsStatusAndDoesNotRepublish(t *testing.T) {
+	svc, repo, cache, pub := newService()
+	ctx := context.Background()
+	if _, err := svc.PlaceOrderBySKU(ctx, skuInput()); err != nil {
+		t.Fatal(err)
+	}
+	repo.status = orders.StatusStockReserved
+	cache.status[testOrderID] = orders.StatusStockReserved
+
+	res, err := svc.PlaceOrderBySKU(ctx, skuInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Idempotent || res.OrderID != testOrderID {
+		t.Fatalf("unexpected replay result %+v", res)
+	}
+	if got := cache.status[testOrderID]; got != orders.StatusStockReserved {
+		t.Fatalf("cached status overwritten with %s", got)
+	}
+	if len(pub.msgs) != 1 {
+		t.Fatalf("published %d messages, want only the original", len(pub.msgs))
+	}
+}
+
+// Publish pertama hilang: order tetap CREATED, retry dengan external_id yang sama mengirim ulang
+// OrderCreated untuk order yang sama.
+func TestPlaceOrderReplayRepublishesAfterLostPublish(t *testing.T) {
+	svc, _, _, pub := newService()
+	ctx := context.Background()
+	pub.drop = true
+	if _, err := svc.PlaceOrderBySKU(ctx, skuInput()); err != nil {
+		t.Fatal(err)
+	}
+	if len(pub.msgs) != 0 {
+		t.Fatalf("dropped publish recorded")
+	}
+
+	pub.drop = false
+	res, err := svc.PlaceOrderBySKU(ctx, skuInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Idempotent {
+		t.Fatalf("retry not idempotent: %+v", res)
+	}
+	if len(pub.msgs) != 1 {
+		t.Fatalf("published %d messages, want 1 re-publish", len(pub.msgs))
+	}
+	if _, p := decodeCreated(t, pub.msgs[0]); p.OrderID != testOrderID || len(p.Items) != 2 {
+		t.Fatalf("unexpected re-published payload %+v", p)
+	}
+}
+
+// Cache status masih CREATED padahal order sudah lanjut: watch harus melihat status Postgres.
+func TestWatchStatusIgnoresStaleCache(t *testing.T) {
+	svc, repo, cache, _ := newService()
+	cache.status[testOrderID] = orders.StatusCreated
+	repo.status = orders.StatusCompleted
+
+	var seen []orders.Status
+	err := svc.WatchStatus(context.Background(), testOrderID, time.Millisecond, func(s orders.Status) error {
+		seen = append(seen, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(seen, []orders.Status{orders.StatusCompleted}) {
+		t.Fatalf("watch saw %v, want [COMPLETED]", seen)
+	}
+	if cache.status[testOrderID] != orders.StatusCompleted {
+		t.Fatalf("cache not refreshed: %s", cache.status[testOrderID])
+	}
+}
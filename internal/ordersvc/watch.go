@@ -0,0 +1,46 @@
+package ordersvc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+// IsFinal: status yang tidak punya transisi lanjut (lihat orders.validNext).
+func IsFinal(s orders.Status) bool {
+	return s == orders.StatusCompleted || s == orders.StatusFailed
+}
+
+// WatchStatus memanggil fn dengan status saat ini lalu setiap kali status berubah (polling),
+// berhenti saat status final, ctx selesai, atau fn mengembalikan error. Polling langsung ke Postgres:
+// cache status hanya diisi saat order dibuat / dibaca, jadi bisa tertinggal dari status sebenarnya.
+func (s *Service) WatchStatus(ctx context.Context, orderID string, every time.Duration, fn func(orders.Status) error) error {
+	if every <= 0 {
+		every = time.Second
+	}
+	t := time.NewTicker(every)
+	defer t.Stop()
+
+	var last orders.Status
+	for {
+		st, err := s.loadStatus(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if st != last {
+			if err := fn(st); err != nil {
+				return err
+			}
+			last = st
+		}
+		if IsFinal(st) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
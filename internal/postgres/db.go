@@ -2,18 +2,25 @@ package postgres
 
 import (
 	"context"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/tracing"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"time"
 )
 
-func Connect(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
-	cfg, err := pgxpool.ParseConfig(dsn)
+func Connect(ctx context.Context, c config.Postgres) (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(c.DSN)
 	if err != nil {
 		return nil, err
 	}
-	cfg.MaxConns = 8
-	cfg.MinConns = 1
-	cfg.HealthCheckPeriod = 30 * time.Second
+	cfg.MaxConns = int32(c.MaxConns)
+	cfg.MinConns = int32(c.MinConns)
+	cfg.MaxConnLifetime = c.MaxConnLifetime
+	cfg.HealthCheckPeriod = c.HealthCheckPeriod
+	if c.ConnectTimeout > 0 {
+		cfg.ConnConfig.ConnectTimeout = c.ConnectTimeout
+	}
+	cfg.ConnConfig.Tracer = tracing.PgxTracer{} // span per query (noop kalau tracing tidak aktif)
 	pool, err := pgxpool.NewWithConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,177 @@
+// Package pricing: hitung harga order dari list price + promosi (diskon persen, potongan tetap,
+// buy-X-get-Y). Murni perhitungan; rule diambil dari DB oleh orders.Repo di dalam transaksi order.
+package pricing
+
+import "sort"
+
+// Jenis promosi (promotions.kind).
+const (
+	KindPercent  = "PERCENT"     // Percent% dari line (product_id di-set) atau seluruh order
+	KindFixed    = "FIXED"       // AmountCents per unit (product_id di-set) atau dari total order
+	KindBuyXGetY = "BUY_X_GET_Y" // tiap BuyQty+GetQty unit product_id, GetQty unit gratis
+)
+
+// Line: satu baris order sebelum diskon.
+type Line struct {
+	ProductID string
+	Qty       int
+	UnitCents int // list price per unit (products.price_cents)
+}
+
+// Rule: promosi yang berlaku untuk order ini (sudah difilter periode & kupon).
+type Rule struct {
+	ID          string
+	Name        string
+	Kind        string
+	ProductID   string // kosong = berlaku untuk seluruh order (PERCENT / FIXED)
+	Percent     int
+	AmountCents int
+	BuyQty      int
+	GetQty      int
+	Coupon      string // kode kupon kalau rule berasal dari kupon
+}
+
+// LineResult: breakdown harga per line (disimpan di order_items).
+type LineResult struct {
+	ProductID     string
+	Qty           int
+	UnitCents     int
+	ListCents     int // UnitCents * Qty
+	DiscountCents int
+	FinalCents    int // ListCents - DiscountCents
+}
+
+// Applied: promosi yang benar-benar memberi potongan.
+type Applied struct {
+	PromotionID string `json:"promotion_id"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Coupon      string `json:"coupon,omitempty"`
+	AmountCents int    `json:"amount_cents"`
+}
+
+type Quote struct {
+	Lines         []LineResult
+	ListCents     int
+	DiscountCents int
+	TotalCents    int
+	Applied       []Applied
+}
+
+// Price: urutan penerapan BUY_X_GET_Y -> PERCENT -> FIXED (tiap rule dihitung dari sisa harga
+// setelah rule sebelumnya), urutan dalam satu jenis mengikuti urutan rules. Harga line tidak
+// pernah di bawah 0.
+func Price(lines []Line, rules []Rule) Quote {
+	q := Quote{Lines: make([]LineResult, len(lines))}
+	for i, l := range lines {
+		list := l.UnitCents * l.Qty
+		q.Lines[i] = LineResult{ProductID: l.ProductID, Qty: l.Qty, UnitCents: l.UnitCents, ListCents: list, FinalCents: list}
+		q.ListCents += list
+	}
+
+	ordered := make([]Rule, len(rules))
+	copy(ordered, rules)
+	sort.SliceStable(ordered, func(i, j int) bool { return kindOrder(ordered[i].Kind) < kindOrder(ordered[j].Kind) })
+
+	for _, r := range ordered {
+		if amount := q.apply(r); amount > 0 {
+			q.Applied = append(q.Applied, Applied{PromotionID: r.ID, Name: r.Name, Kind: r.Kind, Coupon: r.Coupon, AmountCents: amount})
+		}
+	}
+
+	for _, l := range q.Lines {
+		q.DiscountCents += l.DiscountCents
+		q.TotalCents += l.FinalCents
+	}
+	return q
+}
+
+func kindOrder(kind string) int {
+	switch kind {
+	case KindBuyXGetY:
+		return 0
+	case KindPercent:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// apply: kembalikan total potongan rule ini.
+func (q *Quote) apply(r Rule) int {
+	if r.ProductID == "" {
+		switch r.Kind {
+		case KindPercent:
+			return q.spread(q.remaining() * clamp(r.Percent, 0, 100) / 100)
+		case KindFixed:
+			return q.spread(r.AmountCents)
+		}
+		return 0
+	}
+
+	total := 0
+	for i := range q.Lines {
+		l := &q.Lines[i]
+		if l.ProductID != r.ProductID {
+			continue
+		}
+		var off int
+		switch r.Kind {
+		case KindBuyXGetY:
+			if r.BuyQty > 0 && r.GetQty > 0 {
+				off = l.Qty / (r.BuyQty + r.GetQty) * r.GetQty * l.UnitCents
+			}
+		case KindPercent:
+			off = l.FinalCents * clamp(r.Percent, 0, 100) / 100
+		case KindFixed:
+			off = r.AmountCents * l.Qty
+		}
+		off = clamp(off, 0, l.FinalCents)
+		l.DiscountCents += off
+		l.FinalCents -= off
+		total += off
+	}
+	return total
+}
+
+func (q *Quote) remaining() int {
+	n := 0
+	for _, l := range q.Lines {
+		n += l.FinalCents
+	}
+	return n
+}
+
+// spread: potongan level order dibagi ke line proporsional sisa harga line (sisa pembulatan ke
+// line terakhir yang masih punya harga), supaya breakdown per line tetap jumlahnya pas.
+func (q *Quote) spread(amount int) int {
+	rem := q.remaining()
+	amount = clamp(amount, 0, rem)
+	if amount == 0 {
+		return 0
+	}
+	left, last := amount, -1
+	for i := range q.Lines {
+		l := &q.Lines[i]
+		if l.FinalCents == 0 {
+			continue
+		}
+		off := amount * l.FinalCents / rem
+		l.DiscountCents += off
+		l.FinalCents -= off
+		left -= off
+		last = i
+	}
+	for i := last; left > 0 && i >= 0; i-- {
+		l := &q.Lines[i]
+		off := min(left, l.FinalCents)
+		l.DiscountCents += off
+		l.FinalCents -= off
+		left -= off
+	}
+	return amount
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,106 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrice(t *testing.T) {
+	three := []Line{{ProductID: "p1", Qty: 1, UnitCents: 100}, {ProductID: "p2", Qty: 1, UnitCents: 100}, {ProductID: "p3", Qty: 1, UnitCents: 100}}
+
+	for _, tc := range []struct {
+		name      string
+		lines     []Line
+		rules     []Rule
+		discounts []int // DiscountCents per line
+		total     int
+		applied   []int // AmountCents per Applied, urut penerapan
+	}{
+		{
+			name:      "tanpa promosi",
+			lines:     []Line{{ProductID: "p1", Qty: 2, UnitCents: 250}},
+			discounts: []int{0}, total: 500,
+		},
+		{
+			// rules sengaja terbalik: tetap BUY_X_GET_Y (1000) -> PERCENT 10% dari 2000 -> FIXED 500
+			name:  "urutan BUY_X_GET_Y -> PERCENT -> FIXED",
+			lines: []Line{{ProductID: "p1", Qty: 3, UnitCents: 1000}},
+			rules: []Rule{
+				{ID: "fixed", Kind: KindFixed, AmountCents: 500},
+				{ID: "pct", Kind: KindPercent, ProductID: "p1", Percent: 10},
+				{ID: "bxgy", Kind: KindBuyXGetY, ProductID: "p1", BuyQty: 2, GetQty: 1},
+			},
+			discounts: []int{1700}, total: 1300, applied: []int{1000, 200, 500},
+		},
+		{
+			name:      "buy x get y belum cukup qty tidak tercatat",
+			lines:     []Line{{ProductID: "p1", Qty: 2, UnitCents: 1000}},
+			rules:     []Rule{{ID: "bxgy", Kind: KindBuyXGetY, ProductID: "p1", BuyQty: 2, GetQty: 1}},
+			discounts: []int{0}, total: 2000,
+		},
+		{
+			name:      "potongan order dibagi proporsional, sisa pembulatan ke line terakhir",
+			lines:     three,
+			rules:     []Rule{{ID: "fixed", Kind: KindFixed, AmountCents: 100}},
+			discounts: []int{33, 33, 34}, total: 200, applied: []int{100},
+		},
+		{
+			name:  "sisa pembulatan ke line terakhir yang masih punya harga",
+			lines: three,
+			rules: []Rule{
+				{ID: "free-p3", Kind: KindPercent, ProductID: "p3", Percent: 100},
+				{ID: "fixed", Kind: KindFixed, AmountCents: 101},
+			},
+			discounts: []int{50, 51, 100}, total: 99, applied: []int{100, 101},
+		},
+		{
+			name:      "percent order dibulatkan ke bawah",
+			lines:     []Line{{ProductID: "p1", Qty: 1, UnitCents: 999}},
+			rules:     []Rule{{ID: "pct", Kind: KindPercent, Percent: 10}},
+			discounts: []int{99}, total: 900, applied: []int{99},
+		},
+		{
+			name:      "potongan per unit tidak membuat line negatif",
+			lines:     []Line{{ProductID: "p1", Qty: 2, UnitCents: 300}, {ProductID: "p2", Qty: 1, UnitCents: 400}},
+			rules:     []Rule{{ID: "fixed-p1", Kind: KindFixed, ProductID: "p1", AmountCents: 500}},
+			discounts: []int{600, 0}, total: 400, applied: []int{600},
+		},
+		{
+			name:  "potongan order lebih dari total di-clamp ke 0",
+			lines: []Line{{ProductID: "p1", Qty: 2, UnitCents: 300}, {ProductID: "p2", Qty: 1, UnitCents: 400}},
+			rules: []Rule{
+				{ID: "pct", Kind: KindPercent, Percent: 150},
+				{ID: "fixed", Kind: KindFixed, AmountCents: 10_000},
+			},
+			discounts: []int{600, 400}, total: 0, applied: []int{1000},
+		},
+		{
+			name:      "percent negatif diabaikan",
+			lines:     []Line{{ProductID: "p1", Qty: 1, UnitCents: 1000}},
+			rules:     []Rule{{ID: "pct", Kind: KindPercent, ProductID: "p1", Percent: -20}},
+			discounts: []int{0}, total: 1000,
+		},
+	} {
+		q := Price(tc.lines, tc.rules)
+
+		var discounts []int
+		sum := 0
+		for _, l := range q.Lines {
+			discounts = append(discounts, l.DiscountCents)
+			sum += l.FinalCents
+			if l.FinalCents < 0 || l.FinalCents != l.ListCents-l.DiscountCents || l.ListCents != l.UnitCents*l.Qty {
+				t.Errorf("%s: inconsistent line %+v", tc.name, l)
+			}
+		}
+		var applied []int
+		for _, a := range q.Applied {
+			applied = append(applied, a.AmountCents)
+		}
+		if !reflect.DeepEqual(discounts, tc.discounts) || q.TotalCents != tc.total || !reflect.DeepEqual(applied, tc.applied) {
+			t.Errorf("%s: discounts %v total %d applied %v, want %v %d %v", tc.name, discounts, q.TotalCents, applied, tc.discounts, tc.total, tc.applied)
+		}
+		if sum != q.TotalCents || q.ListCents-q.DiscountCents != q.TotalCents {
+			t.Errorf("%s: lines sum to %d, list %d - discount %d, total %d", tc.name, sum, q.ListCents, q.DiscountCents, q.TotalCents)
+		}
+	}
+}
@@ -2,14 +2,30 @@ package redisx
 
 import (
 	"context"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
 	"github.com/redis/go-redis/v9"
-	"time"
 )
 
-func New(addr string) *redis.Client {
-	r := redis.NewClient(&redis.Options{Addr: addr})
-	_ = r.WithTimeout(2 * time.Second)
-	return r
+func New(c config.Redis) *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:         c.Addr,
+		Password:     c.Password,
+		DB:           c.DB,
+		PoolSize:     c.PoolSize,
+		DialTimeout:  c.DialTimeout,
+		ReadTimeout:  c.ReadTimeout,
+		WriteTimeout: c.WriteTimeout,
+	})
+}
+
+// ApplyTTLs: override TTL* dari config; dipanggil sekali di main sebelum service jalan.
+func ApplyTTLs(c config.Redis) {
+	TTLIdempotency.Set(c.IdempotencyTTL)
+	TTLStatusCache.Set(c.StatusCacheTTL)
+	TTLDedup.Set(c.DedupTTL)
+	TTLSaga.Set(c.SagaTTL)
+	TTLHotInflight.Set(c.HotInflightTTL)
 }
 
 func Exists(ctx context.Context, rdb *redis.Client, key string) (bool, error) {
@@ -1,6 +1,9 @@
 package redisx
 
-import "time"
+import (
+	"sync/atomic"
+	"time"
+)
 
 const (
 	// Idempotency create order: idem:order:create:{external_id} -> order_id
@@ -14,11 +17,54 @@ const (
 
 	// Saga state per order: hash saga:{order_id}
 	KeySaga = "saga:%s"
+
+	// Counter stok produk hot: hotstock:{product_id} -> stok tersedia (lihat inventory.HotStock)
+	KeyHotStock = "hotstock:%s"
+	// Qty yang sudah diambil dari counter tapi belum tersimpan di Postgres: hash product_id -> qty
+	KeyHotInflight = "hotstock:inflight"
+
+	// Snapshot availability per produk: availability:{product_id} -> JSON orders.Availability
+	KeyAvailability = "availability:%s"
+	// Lookup sku -> product_id untuk snapshot di atas (SKU tidak pernah berubah)
+	KeyAvailabilitySKU = "availability:sku"
+
+	// Cache definisi feature flag: hash flags -> key flag -> JSON orders.Flag (lihat internal/flags)
+	KeyFlags = "flags"
+	// Counter versi definisi flag, naik setiap Set/Delete; pengisi cache KeyFlags batal kalau berubah
+	KeyFlagsVersion = "flags:version"
+	// Channel pub/sub invalidasi cache flag (payload = key flag yang berubah)
+	ChannelFlags = "flags:changed"
 )
 
+// Default TTL; di-override dari config lewat ApplyTTLs dan bisa diubah live (internal/settings),
+// jadi selalu baca lewat Get().
 var (
-	TTLIdempotency = 24 * time.Hour
-	TTLStatusCache = 5 * time.Minute
-	TTLDedup       = 48 * time.Hour
-	TTLSaga        = 48 * time.Hour
+	TTLIdempotency = NewTTL(24 * time.Hour)
+	TTLStatusCache = NewTTL(5 * time.Minute)
+	TTLDedup       = NewTTL(48 * time.Hour)
+	TTLSaga        = NewTTL(48 * time.Hour)
+	// inflight yang tertinggal (proses crash) hilang sendiri setelah tidak ada aktivitas
+	TTLHotInflight = NewTTL(10 * time.Minute)
+	// batas atas umur snapshot availability kalau event invalidasi hilang / telat
+	TTLAvailability = NewTTL(30 * time.Second)
+	// pengaman kalau pesan invalidasi flag hilang (mis. Redis restart saat admin mengubah flag)
+	TTLFlags = NewTTL(5 * time.Minute)
 )
+
+// TTL: durasi yang aman dibaca & diubah dari goroutine berbeda.
+type TTL struct{ d atomic.Int64 }
+
+func NewTTL(d time.Duration) *TTL {
+	t := &TTL{}
+	t.d.Store(int64(d))
+	return t
+}
+
+func (t *TTL) Get() time.Duration { return time.Duration(t.d.Load()) }
+
+// Set: nilai <= 0 diabaikan (TTL 0 di Redis berarti tanpa expiry).
+func (t *TTL) Set(d time.Duration) {
+	if d > 0 {
+		t.d.Store(int64(d))
+	}
+}
@@ -0,0 +1,177 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+)
+
+// Fitur yang bisa di-toggle live (config features.<nama>).
+const (
+	FeatureHotStock = "hot_stock" // front Redis inventory.HotStock; false = reservasi langsung ke Postgres
+)
+
+// Settings: bagian config yang boleh berubah tanpa restart. Field lain di file tetap dibaca
+// hanya saat start.
+type Settings struct {
+	LogLevel             string
+	InventoryWorkers     int
+	IdempotencyTTL       time.Duration
+	StatusCacheTTL       time.Duration
+	DedupTTL             time.Duration
+	AvailabilityCacheTTL time.Duration
+	Features             map[string]bool
+}
+
+func FromConfig(c config.Config) Settings {
+	return Settings{
+		LogLevel:             c.Log.Level,
+		InventoryWorkers:     c.Inventory.Workers,
+		IdempotencyTTL:       c.Redis.IdempotencyTTL,
+		StatusCacheTTL:       c.Redis.StatusCacheTTL,
+		DedupTTL:             c.Redis.DedupTTL,
+		AvailabilityCacheTTL: c.API.AvailabilityCacheTTL,
+		Features:             c.Features,
+	}
+}
+
+// View: key = path config (sama dengan config print), dipakai untuk diff & JSON admin.
+func (s Settings) View() map[string]string {
+	v := map[string]string{
+		"log.level":                  s.LogLevel,
+		"inventory.workers":          fmt.Sprint(s.InventoryWorkers),
+		"redis.idempotency_ttl":      s.IdempotencyTTL.String(),
+		"redis.status_cache_ttl":     s.StatusCacheTTL.String(),
+		"redis.dedup_ttl":            s.DedupTTL.String(),
+		"api.availability_cache_ttl": s.AvailabilityCacheTTL.String(),
+	}
+	for name, on := range s.Features {
+		v["features."+name] = fmt.Sprint(on)
+	}
+	return v
+}
+
+// Change: satu setting yang berubah ("" = tidak ada sebelumnya / dihapus).
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func diff(prev, next Settings) []Change {
+	a, b := prev.View(), next.View()
+	var out []Change
+	for k, nv := range b {
+		if ov := a[k]; ov != nv {
+			out = append(out, Change{Key: k, Old: ov, New: nv})
+		}
+	}
+	for k, ov := range a {
+		if _, ok := b[k]; !ok {
+			out = append(out, Change{Key: k, Old: ov})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Store: settings aktif + subscriber. Subscriber dipanggil berurutan (satu Update sekaligus),
+// di luar lock baca sehingga boleh memanggil Current.
+type Store struct {
+	Source string // asal setting, mis. path file config (ditampilkan di endpoint admin)
+
+	updateMu sync.Mutex // serialisasi Update + pemanggilan subscriber
+	mu       sync.RWMutex
+	cur      Settings
+	version  int64
+	updated  time.Time
+	subs     []func(Settings)
+}
+
+func NewStore(initial Settings, source string) *Store {
+	return &Store{Source: source, cur: initial, version: 1, updated: time.Now().UTC()}
+}
+
+func (s *Store) Current() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cur
+}
+
+// Subscribe: fn langsung dipanggil dengan setting sekarang, lalu setiap kali ada perubahan.
+func (s *Store) Subscribe(fn func(Settings)) {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	s.mu.Lock()
+	s.subs = append(s.subs, fn)
+	cur := s.cur
+	s.mu.Unlock()
+	fn(cur)
+}
+
+// Update: pasang setting baru; setiap perubahan di-log (key, old, new). Tanpa perubahan = no-op.
+func (s *Store) Update(next Settings) []Change {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+	s.mu.Lock()
+	changes := diff(s.cur, next)
+	if len(changes) == 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	s.cur = next
+	s.version++
+	s.updated = time.Now().UTC()
+	subs := append([]func(Settings){}, s.subs...)
+	s.mu.Unlock()
+
+	for _, c := range changes {
+		slog.Info("setting changed", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+	for _, fn := range subs {
+		fn(next)
+	}
+	return changes
+}
+
+// Enabled: fungsi toggle fitur untuk dipasang di service; fitur yang tidak disebut di config
+// pakai def.
+func (s *Store) Enabled(name string, def bool) func() bool {
+	return func() bool {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if on, ok := s.cur.Features[name]; ok {
+			return on
+		}
+		return def
+	}
+}
+
+// Snapshot: body JSON GET /admin/settings.
+type Snapshot struct {
+	Version   int64             `json:"version"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Source    string            `json:"source,omitempty"`
+	Settings  map[string]string `json:"settings"`
+}
+
+func (s *Store) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return Snapshot{Version: s.version, UpdatedAt: s.updated, Source: s.Source, Settings: s.cur.View()}
+}
+
+// Handler: setting aktif (read-only; perubahan lewat file config).
+func (s *Store) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(s.Snapshot())
+	})
+}
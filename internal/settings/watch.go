@@ -0,0 +1,63 @@
+package settings
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/config"
+)
+
+// LoadFunc: baca ulang setting (biasanya lewat Loader).
+type LoadFunc func() (Settings, error)
+
+// Loader: config.Load ulang dengan argumen yang sama seperti saat start (file + env + flag),
+// jadi env/flag tetap menang atas file seperti urutan di Load.
+func Loader(name string, args []string) LoadFunc {
+	return func() (Settings, error) {
+		cfg, _, err := config.Load(name, args)
+		if err != nil {
+			return Settings{}, err
+		}
+		return FromConfig(cfg), nil
+	}
+}
+
+// Watch: poll mtime/size file setiap every; kalau berubah, load lalu Update. Config yang tidak
+// valid di-log dan setting lama tetap dipakai. Berhenti saat ctx selesai.
+func (s *Store) Watch(ctx context.Context, path string, every time.Duration, load LoadFunc) {
+	stat := func() (time.Time, int64) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, -1
+		}
+		return fi.ModTime(), fi.Size()
+	}
+	lastMod, lastSize := stat()
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		mod, size := stat()
+		if mod.Equal(lastMod) && size == lastSize {
+			continue
+		}
+		lastMod, lastSize = mod, size
+		if size < 0 {
+			slog.Warn("config file not readable, keeping current settings", "file", path)
+			continue
+		}
+		next, err := load()
+		if err != nil {
+			slog.Error("config reload failed, keeping current settings", "file", path, "err", err)
+			continue
+		}
+		changes := s.Update(next)
+		slog.Info("config reloaded", "file", path, "changes", len(changes))
+	}
+}
@@ -0,0 +1,92 @@
+// Package tax: hitung pajak per line order. Calculator bisa diganti (mis. provider eksternal);
+// implementasi bawaan Table memakai rule per region & kategori produk.
+package tax
+
+import "context"
+
+// Line: harga line yang kena pajak (setelah diskon).
+type Line struct {
+	ProductID   string
+	Category    string // products.tax_category
+	AmountCents int
+}
+
+type Request struct {
+	Region   string
+	Currency string
+	Lines    []Line
+}
+
+// TaxLine: satu pajak untuk satu line (disimpan di order_taxes).
+type TaxLine struct {
+	ProductID    string `json:"product_id"`
+	Name         string `json:"name"`
+	RateBP       int    `json:"rate_bp"` // basis point, 1100 = 11%
+	TaxableCents int    `json:"taxable_cents"`
+	TaxCents     int    `json:"tax_cents"`
+}
+
+type Calculator interface {
+	Calculate(ctx context.Context, req Request) ([]TaxLine, error)
+}
+
+// Rule: Region / Category kosong = wildcard.
+type Rule struct {
+	Region   string
+	Category string
+	Name     string
+	RateBP   int
+}
+
+// Table: per line dipakai rule paling spesifik (region+category > region > category > default);
+// beberapa rule di level yang sama (mis. VAT + pajak daerah) dijumlahkan. Rate 0 tetap dicatat
+// supaya terlihat line itu memang bebas pajak.
+type Table []Rule
+
+func (t Table) Calculate(_ context.Context, req Request) ([]TaxLine, error) {
+	var out []TaxLine
+	for _, l := range req.Lines {
+		for _, r := range t.match(req.Region, l.Category) {
+			out = append(out, TaxLine{
+				ProductID: l.ProductID, Name: r.Name, RateBP: r.RateBP,
+				TaxableCents: l.AmountCents, TaxCents: Amount(l.AmountCents, r.RateBP),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (t Table) match(region, category string) []Rule {
+	best, level := []Rule(nil), 0
+	for _, r := range t {
+		lv := specificity(r, region, category)
+		switch {
+		case lv == 0 || lv < level:
+		case lv > level:
+			best, level = []Rule{r}, lv
+		default:
+			best = append(best, r)
+		}
+	}
+	return best
+}
+
+// specificity: 0 = tidak cocok.
+func specificity(r Rule, region, category string) int {
+	if (r.Region != "" && r.Region != region) || (r.Category != "" && r.Category != category) {
+		return 0
+	}
+	lv := 1
+	if r.Category != "" {
+		lv += 1
+	}
+	if r.Region != "" {
+		lv += 2
+	}
+	return lv
+}
+
+// Amount: pembulatan half-up per line.
+func Amount(cents, rateBP int) int {
+	return (cents*rateBP + 5000) / 10000
+}
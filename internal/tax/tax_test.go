@@ -0,0 +1,71 @@
+package tax
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTableMatch(t *testing.T) {
+	table := Table{
+		{Name: "default", RateBP: 1000},
+		{Category: "food", Name: "food", RateBP: 500},
+		{Region: "ID-JK", Name: "vat", RateBP: 1100},
+		{Region: "ID-JK", Name: "pajak daerah", RateBP: 100},
+		{Region: "ID-JK", Category: "food", Name: "food jk", RateBP: 0},
+		{Region: "ID-BA", Category: "books", Name: "books ba", RateBP: 200},
+	}
+	for _, tc := range []struct {
+		region, category string
+		want             []string
+	}{
+		{"ID-JK", "food", []string{"food jk"}},              // region+category menang walau rate 0
+		{"ID-JK", "books", []string{"vat", "pajak daerah"}}, // region, dua rule dijumlahkan
+		{"ID-BA", "food", []string{"food"}},                 // category
+		{"ID-BA", "books", []string{"books ba"}},
+		{"ID-BA", "toys", []string{"default"}},
+		{"", "", []string{"default"}},
+	} {
+		var got []string
+		for _, r := range table.match(tc.region, tc.category) {
+			got = append(got, r.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s/%s: %v, want %v", tc.region, tc.category, got, tc.want)
+		}
+	}
+	if got := (Table{{Region: "ID-JK", Name: "vat", RateBP: 1100}}).match("ID-BA", "food"); got != nil {
+		t.Errorf("no matching rule: %v", got)
+	}
+}
+
+func TestCalculateSumsSameLevel(t *testing.T) {
+	table := Table{{Region: "ID-JK", Name: "vat", RateBP: 1100}, {Region: "ID-JK", Name: "pajak daerah", RateBP: 100}}
+	got, err := table.Calculate(context.Background(), Request{Region: "ID-JK", Currency: "IDR", Lines: []Line{{ProductID: "p1", AmountCents: 10_000}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TaxLine{
+		{ProductID: "p1", Name: "vat", RateBP: 1100, TaxableCents: 10_000, TaxCents: 1100},
+		{ProductID: "p1", Name: "pajak daerah", RateBP: 100, TaxableCents: 10_000, TaxCents: 100},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestAmountRoundsHalfUp(t *testing.T) {
+	for _, tc := range []struct{ cents, rateBP, want int }{
+		{1000, 1100, 110},
+		{50, 1000, 5},
+		{45, 1100, 5},     // 4.95
+		{5, 1000, 1},      // 0.5 naik
+		{4, 1000, 0},      // 0.4 turun
+		{1250, 1100, 138}, // 137.5 naik
+		{999, 0, 0},
+	} {
+		if got := Amount(tc.cents, tc.rateBP); got != tc.want {
+			t.Errorf("Amount(%d, %d) = %d, want %d", tc.cents, tc.rateBP, got, tc.want)
+		}
+	}
+}
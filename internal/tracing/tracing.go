@@ -0,0 +1,72 @@
+// Package tracing: setup OpenTelemetry (provider + propagator W3C traceparent) dan instrumentasi
+// kecil untuk pgx, Redis dan header Kafka. HTTP di-trace oleh middleware httpx, Kafka oleh kafkax.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nama exporter (config TRACING_EXPORTER).
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp" // gRPC ke OTLPEndpoint (mis. otel-collector:4317)
+)
+
+const instrumentation = "github.com/ariefcatur/go-realtime-orders.git"
+
+type Config struct {
+	ServiceName  string
+	Exporter     string  // none | stdout | otlp
+	OTLPEndpoint string  // host:port, tanpa TLS
+	SampleRatio  float64 // 0..1, dipakai untuk trace baru (parent yang sudah sampled tetap diikuti)
+}
+
+// Setup: pasang provider global sesuai cfg. Exporter none tetap memasang propagator supaya
+// traceparent dari upstream diteruskan. shutdown flush span yang tersisa.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exp sdktrace.SpanExporter
+	switch strings.ToLower(cfg.Exporter) {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exp, err = stdouttrace.New()
+	case ExporterOTLP:
+		exp, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint), otlptracegrpc.WithInsecure())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q (none | stdout | otlp)", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing exporter %s: %w", cfg.Exporter, err)
+	}
+
+	tp := NewProvider(cfg.ServiceName, sdktrace.WithBatcher(exp),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))))
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// NewProvider: provider dengan resource service.name. Test bisa pakai
+// sdktrace.WithSyncer(tracetest.NewInMemoryExporter()) lalu otel.SetTracerProvider.
+func NewProvider(serviceName string, opts ...sdktrace.TracerProviderOption) *sdktrace.TracerProvider {
+	res := resource.NewSchemaless(semconv.ServiceName(serviceName))
+	return sdktrace.NewTracerProvider(append([]sdktrace.TracerProviderOption{sdktrace.WithResource(res)}, opts...)...)
+}
+
+// Tracer: selalu ambil dari provider global saat dipanggil (provider bisa diganti di test).
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
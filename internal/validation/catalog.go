@@ -0,0 +1,46 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/money"
+)
+
+var (
+	skuPattern         = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{0,63}$`)
+	taxCategoryPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,31}$`)
+)
+
+// Product memvalidasi input create produk (admin API). currency / taxCategory kosong = default.
+func Product(sku, name string, priceCents, stock int, currency, taxCategory string) error {
+	var v Validator
+	if v.Required("sku", sku) {
+		v.Check(skuPattern.MatchString(sku), "sku", "must match "+skuPattern.String())
+	}
+	if v.Required("name", name) {
+		v.MaxLen("name", name, 200)
+	}
+	v.Check(priceCents >= 0, "price_cents", "must be >= 0")
+	v.Check(stock >= 0, "stock", "must be >= 0")
+	v.Check(currency == "" || money.ValidCurrency(currency), "currency", "must be a 3-letter ISO 4217 code")
+	v.Check(taxCategory == "" || taxCategoryPattern.MatchString(taxCategory), "tax_category", "must match "+taxCategoryPattern.String())
+	return v.Err()
+}
+
+// ProductPatch: field nil = tidak diubah, minimal satu field harus diisi.
+func ProductPatch(name *string, priceCents *int, hot *bool, taxCategory *string, version int) error {
+	var v Validator
+	v.Check(version > 0, "version", "is required")
+	v.Check(name != nil || priceCents != nil || hot != nil || taxCategory != nil, "",
+		"at least one of name, price_cents, hot, tax_category is required")
+	if taxCategory != nil {
+		v.Check(taxCategoryPattern.MatchString(*taxCategory), "tax_category", "must match "+taxCategoryPattern.String())
+	}
+	if name != nil && v.Required("name", *name) {
+		v.MaxLen("name", *name, 200)
+	}
+	if priceCents != nil {
+		v.Check(*priceCents >= 0, "price_cents", "must be >= 0")
+	}
+	return v.Err()
+}
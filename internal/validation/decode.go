@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBodyTooLarge = errors.New("request body too large")
+	ErrInvalidJSON  = errors.New("invalid json")
+)
+
+// DecodeJSON: batasi ukuran body, tolak field yang tidak dikenal, dan pastikan hanya ada 1 objek JSON.
+// Error unknown field / tipe salah dikembalikan sebagai Errors supaya bisa ditampilkan per field.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	if maxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		var maxErr *http.MaxBytesError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &maxErr):
+			return ErrBodyTooLarge
+		case errors.As(err, &typeErr):
+			return Errors{{Field: typeErr.Field, Message: "must be " + typeErr.Type.String()}}
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+			return Errors{{Field: name, Message: "unknown field"}}
+		default:
+			return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: body must contain a single JSON object", ErrInvalidJSON)
+	}
+	return nil
+}
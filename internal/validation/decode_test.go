@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decodeReq struct {
+	Name string `json:"name"`
+	Qty  int    `json:"qty"`
+}
+
+func decode(body string, max int64) (decodeReq, error) {
+	var dst decodeReq
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	err := DecodeJSON(httptest.NewRecorder(), r, max, &dst)
+	return dst, err
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		max     int64
+		want    decodeReq
+		wantErr error  // errors.Is
+		field   string // Errors dengan satu field ini
+	}{
+		{name: "ok", body: `{"name":"a","qty":2}`, want: decodeReq{Name: "a", Qty: 2}},
+		{name: "unknown field", body: `{"name":"a","price":1}`, field: "price"},
+		{name: "wrong type", body: `{"qty":"two"}`, field: "qty"},
+		{name: "too large", body: `{"name":"` + strings.Repeat("x", 100) + `"}`, max: 32, wantErr: ErrBodyTooLarge},
+		{name: "within limit", body: `{"name":"a"}`, max: 32, want: decodeReq{Name: "a"}},
+		{name: "two objects", body: `{"name":"a"}{"name":"b"}`, wantErr: ErrInvalidJSON},
+		{name: "malformed", body: `{"name":`, wantErr: ErrInvalidJSON},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decode(tc.body, tc.max)
+			switch {
+			case tc.field != "":
+				var verrs Errors
+				if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Field != tc.field {
+					t.Fatalf("err %v, want field error on %s", err, tc.field)
+				}
+			case tc.wantErr != nil:
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("err %v, want %v", err, tc.wantErr)
+				}
+			default:
+				if err != nil || !reflect.DeepEqual(got, tc.want) {
+					t.Fatalf("got %+v, %v; want %+v", got, err, tc.want)
+				}
+			}
+		})
+	}
+}
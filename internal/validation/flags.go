@@ -0,0 +1,28 @@
+package validation
+
+import (
+	"regexp"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+// sama dengan CHECK di migration 014_feature_flags.sql
+var flagKeyRe = regexp.MustCompile(`^[a-z0-9_.-]{1,64}$`)
+
+func Flag(f orders.Flag) error {
+	var v Validator
+	if v.Required("key", f.Key) {
+		v.Check(flagKeyRe.MatchString(f.Key), "key", "must match [a-z0-9_.-], max 64 chars")
+	}
+	v.MaxLen("description", f.Description, 500)
+	switch f.Kind {
+	case orders.FlagBoolean:
+	case orders.FlagPercentage:
+		v.Check(f.Percent >= 0 && f.Percent <= 100, "percent", "must be between 0 and 100")
+	default:
+		v.Add("kind", "must be boolean or percentage")
+	}
+	v.Check(f.TargetBy == orders.FlagTargetUserID || f.TargetBy == orders.FlagTargetExternalID,
+		"target_by", "must be user_id or external_id")
+	return v.Err()
+}
@@ -0,0 +1,16 @@
+package validation
+
+// Limits batas payload create order; diisi dari config.Config.
+type Limits struct {
+	MaxItemsPerOrder int   // jumlah line, baik sebelum maupun setelah merge duplikat
+	MaxQtyPerLine    int   // qty per line, baik per line mentah maupun setelah merge duplikat
+	MaxBodyBytes     int64 // ukuran body request
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxItemsPerOrder: 50,
+		MaxQtyPerLine:    1000,
+		MaxBodyBytes:     64 << 10,
+	}
+}
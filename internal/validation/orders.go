@@ -0,0 +1,212 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/money"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+const maxExternalIDLen = 128
+
+// CreateOrder memvalidasi payload /orders dan mengembalikan items yang sudah di-merge
+// (product_id duplikat dijumlahkan qty-nya, urutan mengikuti kemunculan pertama).
+func CreateOrder(externalID, userID string, items []orders.ItemInput, lim Limits) ([]orders.ItemInput, error) {
+	var v Validator
+	header(&v, externalID, userID, len(items))
+	if !lineCount(&v, len(items), lim) {
+		return nil, v.Err()
+	}
+	productLines(&v, items, lim)
+	if !v.Valid() {
+		return nil, v.Err()
+	}
+	return mergeChecked(&v, items, lim)
+}
+
+// CreateQuote memvalidasi payload /quotes (user_id + items by product_id, tanpa external_id).
+func CreateQuote(userID string, items []orders.ItemInput, lim Limits) ([]orders.ItemInput, error) {
+	var v Validator
+	if v.Required("user_id", userID) {
+		v.UUID("user_id", userID)
+	}
+	v.Check(len(items) > 0, "items", "must not be empty")
+	if !lineCount(&v, len(items), lim) {
+		return nil, v.Err()
+	}
+	productLines(&v, items, lim)
+	if !v.Valid() {
+		return nil, v.Err()
+	}
+	return mergeChecked(&v, items, lim)
+}
+
+func productLines(v *Validator, items []orders.ItemInput, lim Limits) {
+	expected := make(map[string]int, len(items))
+	for i, it := range items {
+		if v.Required(fmt.Sprintf("items[%d].product_id", i), it.ProductID) {
+			v.UUID(fmt.Sprintf("items[%d].product_id", i), it.ProductID)
+		}
+		lineQty(v, i, it.Qty, lim)
+		expectedPrice(v, expected, i, it.ProductID, it.ExpectedPriceCents)
+	}
+}
+
+// lineCount: jumlah line mentah (sebelum merge) juga dibatasi MaxItemsPerOrder, supaya ribuan line
+// duplikat tidak lolos lewat merge. false = sudah melewati batas (line tidak perlu diperiksa satu-satu).
+func lineCount(v *Validator, n int, lim Limits) bool {
+	ok := lim.MaxItemsPerOrder <= 0 || n <= lim.MaxItemsPerOrder
+	v.Check(ok, "items", fmt.Sprintf("must contain at most %d lines", lim.MaxItemsPerOrder))
+	return ok
+}
+
+// maxQty: MaxQtyPerLine, atau batas kolom INTEGER Postgres kalau tidak dibatasi.
+func maxQty(lim Limits) int {
+	if lim.MaxQtyPerLine > 0 {
+		return lim.MaxQtyPerLine
+	}
+	return math.MaxInt32
+}
+
+// lineQty: dicek per line mentah, bukan hanya setelah merge (jumlah line besar bisa overflow jadi negatif).
+func lineQty(v *Validator, i, qty int, lim Limits) {
+	field := fmt.Sprintf("items[%d].qty", i)
+	switch {
+	case qty <= 0:
+		v.Add(field, "must be greater than 0")
+	case qty > maxQty(lim):
+		v.Add(field, fmt.Sprintf("must be at most %d", maxQty(lim)))
+	}
+}
+
+// addQty: penjumlahan merge yang jenuh di math.MaxInt (tidak pernah wrap ke negatif).
+func addQty(a, b int) int {
+	if b > 0 && a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// expectedPrice: >= 0, dan line duplikat (di-merge) tidak boleh punya expected price berbeda.
+func expectedPrice(v *Validator, seen map[string]int, i int, key string, p *int) {
+	if p == nil {
+		return
+	}
+	field := fmt.Sprintf("items[%d].expected_price_cents", i)
+	v.Check(*p >= 0, field, "must be >= 0")
+	if prev, ok := seen[key]; ok && prev != *p {
+		v.Add(field, "conflicts with an earlier line for the same product")
+	}
+	seen[key] = *p
+}
+
+func mergeChecked(v *Validator, items []orders.ItemInput, lim Limits) ([]orders.ItemInput, error) {
+	merged := MergeItems(items)
+	for _, it := range merged {
+		v.Check(it.Qty <= maxQty(lim),
+			"items", fmt.Sprintf("total qty for product_id %s must be at most %d", it.ProductID, maxQty(lim)))
+	}
+	v.Check(lim.MaxItemsPerOrder <= 0 || len(merged) <= lim.MaxItemsPerOrder,
+		"items", fmt.Sprintf("must contain at most %d distinct products", lim.MaxItemsPerOrder))
+	return merged, v.Err()
+}
+
+// CreateOrderBySKU: sama seperti CreateOrder, tapi line di-merge berdasarkan sku.
+func CreateOrderBySKU(externalID, userID string, items []orders.ItemInputSKU, lim Limits) ([]orders.ItemInputSKU, error) {
+	var v Validator
+	header(&v, externalID, userID, len(items))
+	if !lineCount(&v, len(items), lim) {
+		return nil, v.Err()
+	}
+
+	expected := make(map[string]int, len(items))
+	for i, it := range items {
+		v.Required(fmt.Sprintf("items[%d].sku", i), it.SKU)
+		lineQty(&v, i, it.Qty, lim)
+		expectedPrice(&v, expected, i, it.SKU, it.ExpectedPriceCents)
+	}
+	if !v.Valid() {
+		return nil, v.Err()
+	}
+
+	merged := MergeItemsSKU(items)
+	for _, it := range merged {
+		v.Check(it.Qty <= maxQty(lim),
+			"items", fmt.Sprintf("total qty for sku %s must be at most %d", it.SKU, maxQty(lim)))
+	}
+	v.Check(lim.MaxItemsPerOrder <= 0 || len(merged) <= lim.MaxItemsPerOrder,
+		"items", fmt.Sprintf("must contain at most %d distinct products", lim.MaxItemsPerOrder))
+	return merged, v.Err()
+}
+
+func header(v *Validator, externalID, userID string, n int) {
+	if v.Required("external_id", externalID) {
+		v.MaxLen("external_id", externalID, maxExternalIDLen)
+	}
+	// kolom orders.user_id bertipe UUID: tolak di sini, jangan sampai gagal di Postgres
+	if v.Required("user_id", userID) {
+		v.UUID("user_id", userID)
+	}
+	v.Check(n > 0, "items", "must not be empty")
+}
+
+func MergeItems(items []orders.ItemInput) []orders.ItemInput {
+	idx := make(map[string]int, len(items))
+	out := make([]orders.ItemInput, 0, len(items))
+	for _, it := range items {
+		if i, ok := idx[it.ProductID]; ok {
+			out[i].Qty = addQty(out[i].Qty, it.Qty)
+			if out[i].ExpectedPriceCents == nil {
+				out[i].ExpectedPriceCents = it.ExpectedPriceCents
+			}
+			continue
+		}
+		idx[it.ProductID] = len(out)
+		out = append(out, it)
+	}
+	return out
+}
+
+func MergeItemsSKU(items []orders.ItemInputSKU) []orders.ItemInputSKU {
+	idx := make(map[string]int, len(items))
+	out := make([]orders.ItemInputSKU, 0, len(items))
+	for _, it := range items {
+		if i, ok := idx[it.SKU]; ok {
+			out[i].Qty = addQty(out[i].Qty, it.Qty)
+			if out[i].ExpectedPriceCents == nil {
+				out[i].ExpectedPriceCents = it.ExpectedPriceCents
+			}
+			continue
+		}
+		idx[it.SKU] = len(out)
+		out = append(out, it)
+	}
+	return out
+}
+
+// Region: opsional (kosong = tanpa preferensi gudang).
+func Region(region string) error {
+	var v Validator
+	v.MaxLen("region", region, 64)
+	return v.Err()
+}
+
+// FulfilmentPolicy: opsional (kosong = ALL_OR_NOTHING).
+func FulfilmentPolicy(p orders.FulfilmentPolicy) error {
+	var v Validator
+	v.Check(p == "" || p.Valid(), "fulfilment_policy", "must be ALL_OR_NOTHING, PARTIAL or BACKORDER")
+	return v.Err()
+}
+
+// Pricing: coupon_code, currency & quote_id opsional; keberadaan kupon / quote dan kecocokan
+// currency dengan produk dicek di repo (dalam tx order).
+func Pricing(couponCode, currency, quoteID string) error {
+	var v Validator
+	v.MaxLen("coupon_code", couponCode, 64)
+	if quoteID != "" {
+		v.UUID("quote_id", quoteID)
+	}
+	v.Check(currency == "" || money.ValidCurrency(currency), "currency", "must be a 3-letter ISO 4217 code")
+	return v.Err()
+}
@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+)
+
+const (
+	testUser = "7f9c2d4e-0000-4000-8000-0000000000aa"
+	productA = "7f9c2d4e-0000-4000-8000-00000000000a"
+	productB = "7f9c2d4e-0000-4000-8000-00000000000b"
+)
+
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	var verrs Errors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("want Errors, got %v", err)
+	}
+	out := make([]string, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, fe.Field)
+	}
+	return out
+}
+
+func intp(v int) *int { return &v }
+
+// Semua pelanggaran dikumpulkan sekaligus, bukan berhenti di yang pertama.
+func TestCreateOrderAggregatesErrors(t *testing.T) {
+	_, err := CreateOrder("", "not-a-uuid", []orders.ItemInput{
+		{ProductID: "", Qty: 1},
+		{ProductID: productA, Qty: 0},
+		{ProductID: productB, Qty: 1, ExpectedPriceCents: intp(-1)},
+	}, DefaultLimits())
+	want := []string{"external_id", "user_id", "items[0].product_id", "items[1].qty", "items[2].expected_price_cents"}
+	if got := fields(t, err); !reflect.DeepEqual(got, want) {
+		t.Fatalf("fields %v, want %v", got, want)
+	}
+}
+
+func TestCreateOrderMergesDuplicates(t *testing.T) {
+	lim := Limits{MaxItemsPerOrder: 3, MaxQtyPerLine: 1000}
+	tests := []struct {
+		name       string
+		items      []orders.ItemInput
+		want       []orders.ItemInput
+		wantFields []string
+	}{
+		{
+			name:  "qty summed, first expected price kept, order of first appearance",
+			items: []orders.ItemInput{{ProductID: productB, Qty: 1}, {ProductID: productA, Qty: 2}, {ProductID: productB, Qty: 3, ExpectedPriceCents: intp(500)}},
+			want:  []orders.ItemInput{{ProductID: productB, Qty: 4, ExpectedPriceCents: intp(500)}, {ProductID: productA, Qty: 2}},
+		},
+		{
+			name:       "merged qty over limit",
+			items:      []orders.ItemInput{{ProductID: productA, Qty: 600}, {ProductID: productA, Qty: 600}},
+			wantFields: []string{"items"},
+		},
+		{
+			name:       "raw lines over limit even if they merge",
+			items:      []orders.ItemInput{{ProductID: productA, Qty: 1}, {ProductID: productA, Qty: 1}, {ProductID: productA, Qty: 1}, {ProductID: productA, Qty: 1}},
+			wantFields: []string{"items"},
+		},
+		{
+			name:       "conflicting expected price on duplicate",
+			items:      []orders.ItemInput{{ProductID: productA, Qty: 1, ExpectedPriceCents: intp(100)}, {ProductID: productA, Qty: 1, ExpectedPriceCents: intp(200)}},
+			wantFields: []string{"items[1].expected_price_cents"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := CreateOrder("ext-1", testUser, tc.items, lim)
+			if tc.wantFields != nil {
+				if f := fields(t, err); !reflect.DeepEqual(f, tc.wantFields) {
+					t.Fatalf("fields %v, want %v", f, tc.wantFields)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("merged %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Merge jenuh di math.MaxInt, tidak wrap ke negatif (lalu lolos cek qty <= max).
+func TestMergeItemsSaturates(t *testing.T) {
+	got := MergeItems([]orders.ItemInput{{ProductID: productA, Qty: math.MaxInt}, {ProductID: productA, Qty: 5}})
+	if len(got) != 1 || got[0].Qty != math.MaxInt {
+		t.Fatalf("merged %+v, want qty MaxInt", got)
+	}
+	sku := MergeItemsSKU([]orders.ItemInputSKU{{SKU: "A", Qty: math.MaxInt - 1}, {SKU: "A", Qty: 2}, {SKU: "A", Qty: 3}})
+	if len(sku) != 1 || sku[0].Qty != math.MaxInt {
+		t.Fatalf("merged %+v, want qty MaxInt", sku)
+	}
+
+	// tanpa MaxQtyPerLine batasnya kolom INTEGER Postgres
+	_, err := CreateOrderBySKU("ext-1", testUser, []orders.ItemInputSKU{{SKU: "A", Qty: math.MaxInt32}, {SKU: "A", Qty: math.MaxInt32}}, Limits{})
+	if f := fields(t, err); !reflect.DeepEqual(f, []string{"items"}) {
+		t.Fatalf("fields %v, want [items]", f)
+	}
+}
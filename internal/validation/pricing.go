@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"time"
+
+	"github.com/ariefcatur/go-realtime-orders.git/internal/orders"
+	"github.com/ariefcatur/go-realtime-orders.git/internal/pricing"
+)
+
+// Promotion: field yang wajib tergantung kind.
+func Promotion(p orders.Promotion) error {
+	var v Validator
+	if v.Required("name", p.Name) {
+		v.MaxLen("name", p.Name, 200)
+	}
+	if p.ProductID != nil {
+		v.UUID("product_id", *p.ProductID)
+	}
+	switch p.Kind {
+	case pricing.KindPercent:
+		v.Check(p.Percent > 0 && p.Percent <= 100, "percent", "must be between 1 and 100")
+	case pricing.KindFixed:
+		v.Check(p.AmountCents > 0, "amount_cents", "must be greater than 0")
+	case pricing.KindBuyXGetY:
+		v.Check(p.ProductID != nil, "product_id", "is required for BUY_X_GET_Y")
+		v.Check(p.BuyQty > 0, "buy_qty", "must be greater than 0")
+		v.Check(p.GetQty > 0, "get_qty", "must be greater than 0")
+	default:
+		v.Add("kind", "must be PERCENT, FIXED or BUY_X_GET_Y")
+	}
+	if p.EndsAt != nil {
+		start := p.StartsAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		v.Check(p.EndsAt.After(start), "ends_at", "must be after starts_at")
+	}
+	return v.Err()
+}
+
+func Coupon(c orders.Coupon) error {
+	var v Validator
+	if v.Required("code", c.Code) {
+		v.MaxLen("code", c.Code, 64)
+	}
+	if v.Required("promotion_id", c.PromotionID) {
+		v.UUID("promotion_id", c.PromotionID)
+	}
+	if c.UserID != nil {
+		v.UUID("user_id", *c.UserID)
+	}
+	if c.MaxUses != nil {
+		v.Check(*c.MaxUses > 0, "max_uses", "must be greater than 0")
+	}
+	v.Check(c.MaxUsesPerUser > 0, "max_uses_per_user", "must be greater than 0")
+	return v.Err()
+}
@@ -0,0 +1,71 @@
+package validation
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// FieldError: satu pelanggaran aturan pada satu field (mis. items[1].qty).
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors mengumpulkan semua FieldError supaya client dapat semua masalah sekaligus.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validator: builder kecil, tiap rule menambah error ke list (tidak berhenti di error pertama).
+type Validator struct {
+	errs Errors
+}
+
+func (v *Validator) Add(field, msg string) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: msg})
+}
+
+// Check menambah error jika ok == false.
+func (v *Validator) Check(ok bool, field, msg string) {
+	if !ok {
+		v.Add(field, msg)
+	}
+}
+
+func (v *Validator) Required(field, val string) bool {
+	if strings.TrimSpace(val) == "" {
+		v.Add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (v *Validator) MaxLen(field, val string, n int) {
+	if len(val) > n {
+		v.Add(field, "must be at most "+strconv.Itoa(n)+" characters")
+	}
+}
+
+func (v *Validator) UUID(field, val string) {
+	if _, err := uuid.Parse(val); err != nil {
+		v.Add(field, "must be a valid UUID")
+	}
+}
+
+func (v *Validator) Valid() bool { return len(v.errs) == 0 }
+
+// Err: nil kalau valid, selain itu Errors.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
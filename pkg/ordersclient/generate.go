@@ -0,0 +1,5 @@
+// Package ordersclient adalah typed Go client untuk HTTP API orders,
+// di-generate dari api/openapi.json. Jangan edit client.gen.go manual; jalankan `go generate ./pkg/ordersclient`.
+package ordersclient
+
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen@v2.5.1 --config=oapi-codegen.yaml ../../api/openapi.json